@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -21,6 +22,7 @@ import (
 	"github.com/The-Gleb/banner_service/internal/domain/usecase"
 	"github.com/The-Gleb/banner_service/internal/logger"
 	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/The-Gleb/banner_service/pkg/jwks"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -56,32 +58,119 @@ func run(ctx context.Context) error {
 		return err
 	}
 
-	// err = prepairDB(postgresClient)
-	// if err != nil {
-	// 	return err
-	// }
-
 	bannerStorage := db.NewBannerStorage(postgresClient)
-	bannerCache := cache.NewRedisCache(redisClient, cfg.CacheExpiry)
+	redisCache := cache.NewBreakerCache(
+		cache.NewRedisCache(redisClient, cfg.CacheExpiry).
+			ServeStale(time.Duration(cfg.CacheStaleWindow)*time.Second),
+		cfg.CacheBreaker.Threshold,
+		time.Duration(cfg.CacheBreaker.CoolDown)*time.Second,
+	)
+	bannerCache := cache.NewLocalCache(
+		redisCache,
+		redisClient,
+		cfg.LocalCache.Size,
+		time.Duration(cfg.LocalCache.TTL)*time.Second,
+	)
 	tokenStorage := db.NewTokenStorage(postgresClient)
+	deleteJobStorage := db.NewDeleteJobStorage(postgresClient)
+	statsStorage := db.NewStatsStorage(postgresClient)
+	auditStorage := db.NewAuditStorage(postgresClient)
+	tagStorage := db.NewTagStorage(postgresClient)
+	featureStorage := db.NewFeatureStorage(postgresClient)
 
 	bannerService := service.NewBannerService(bannerStorage, bannerCache)
-	tokenService := service.NewTokenService(tokenStorage)
+	tokenService := service.NewTokenService(
+		tokenStorage,
+		cache.NewTokenInvalidations(redisClient, redisCache),
+		cfg.TokenCache.Size,
+		time.Duration(cfg.TokenCache.TTL)*time.Second,
+	)
+	deleteJobService := service.NewDeleteJobService(deleteJobStorage, bannerStorage, bannerCache)
+	statsService := service.NewStatsService(statsStorage, bannerStorage)
+	auditService := service.NewAuditService(auditStorage)
+	tagService := service.NewTagService(tagStorage, bannerCache)
+	featureService := service.NewFeatureService(featureStorage, bannerCache)
+
+	var jwtSecrets []string
+	for _, secret := range strings.Split(cfg.JWT.HMACSecrets, ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			jwtSecrets = append(jwtSecrets, secret)
+		}
+	}
+	var jwtKeys []jwks.Key
+	if cfg.JWT.JWKSFile != "" {
+		jwtKeys, err = jwks.LoadFile(cfg.JWT.JWKSFile)
+		if err != nil {
+			return err
+		}
+	}
+	jwtService := service.NewJWTService(jwtSecrets, jwtKeys, cfg.JWT.Issuer, cfg.JWT.Audience)
+
+	if cfg.AdminToken != "" {
+		err = tokenService.EnsureAdminToken(ctx, cfg.AdminToken)
+		if err != nil {
+			return fmt.Errorf("ADMIN_TOKEN: %w", err)
+		}
+	}
 
 	createBannerUsecase := usecase.NewCreateBannerUsecase(bannerService)
 	deleteBannerUsecase := usecase.NewDeleteBannerUsecase(bannerService)
-	getBannerUsecase := usecase.NewGetBannersUsecase(bannerService)
-	getUserBannerUsecase := usecase.NewGetUserBannerUsecase(bannerService)
+	getBannersUsecase := usecase.NewGetBannersUsecase(bannerService)
+	getBannerUsecase := usecase.NewGetBannerUsecase(bannerService)
+	getUserBannerUsecase := usecase.NewGetUserBannerUsecase(bannerService, statsService)
 	updateBannerUsecase := usecase.NewUpdateBannerUsecase(bannerService)
-	checkTokenUsecase := usecase.NewCheckTokenUsecase(tokenService)
+	getBannerVersionsUsecase := usecase.NewGetBannerVersionsUsecase(bannerService)
+	restoreBannerUsecase := usecase.NewRestoreBannerUsecase(bannerService)
+	deleteBannersUsecase := usecase.NewDeleteBannersUsecase(deleteJobService)
+	getDeleteJobUsecase := usecase.NewGetDeleteJobUsecase(deleteJobService)
+	clickBannerUsecase := usecase.NewClickBannerUsecase(bannerService, statsService)
+	getBannerStatsUsecase := usecase.NewGetBannerStatsUsecase(statsService)
+	issueTokenUsecase := usecase.NewIssueTokenUsecase(tokenService)
+	getTokensUsecase := usecase.NewGetTokensUsecase(tokenService)
+	updateTokenUsecase := usecase.NewUpdateTokenUsecase(tokenService)
+	revokeTokenUsecase := usecase.NewRevokeTokenUsecase(tokenService)
+	getAuditRecordsUsecase := usecase.NewGetAuditRecordsUsecase(auditService)
+	createTagUsecase := usecase.NewCreateTagUsecase(tagService)
+	getTagsUsecase := usecase.NewGetTagsUsecase(tagService)
+	updateTagUsecase := usecase.NewUpdateTagUsecase(tagService)
+	deleteTagUsecase := usecase.NewDeleteTagUsecase(tagService)
+	createFeatureUsecase := usecase.NewCreateFeatureUsecase(featureService)
+	getFeaturesUsecase := usecase.NewGetFeaturesUsecase(featureService)
+	updateFeatureUsecase := usecase.NewUpdateFeatureUsecase(featureService)
+	deleteFeatureUsecase := usecase.NewDeleteFeatureUsecase(featureService)
+	importBannersUsecase := usecase.NewImportBannersUsecase(bannerService)
+	exportBannersUsecase := usecase.NewExportBannersUsecase(bannerService)
+	checkTokenUsecase := usecase.NewCheckTokenUsecase(tokenService, jwtService)
 
 	s, err := v1.NewServer(
 		cfg.RunAddress,
 		createBannerUsecase,
 		deleteBannerUsecase,
+		getBannersUsecase,
 		getBannerUsecase,
 		getUserBannerUsecase,
 		updateBannerUsecase,
+		getBannerVersionsUsecase,
+		restoreBannerUsecase,
+		deleteBannersUsecase,
+		getDeleteJobUsecase,
+		clickBannerUsecase,
+		getBannerStatsUsecase,
+		issueTokenUsecase,
+		getTokensUsecase,
+		updateTokenUsecase,
+		revokeTokenUsecase,
+		getAuditRecordsUsecase,
+		createTagUsecase,
+		getTagsUsecase,
+		updateTagUsecase,
+		deleteTagUsecase,
+		createFeatureUsecase,
+		getFeaturesUsecase,
+		updateFeatureUsecase,
+		deleteFeatureUsecase,
+		importBannersUsecase,
+		exportBannersUsecase,
 		checkTokenUsecase,
 	)
 	if err != nil {
@@ -90,6 +179,35 @@ func run(ctx context.Context) error {
 
 	var wg sync.WaitGroup
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		deleteJobService.Run(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		bannerCache.Run(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tokenService.Run(ctx)
+	}()
+
+	// stats are flushed for the last time after the server has stopped, so
+	// the requests that were still in flight are counted too
+	statsCtx, stopStats := context.WithCancel(context.Background())
+	defer stopStats()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		statsService.Run(statsCtx)
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -103,6 +221,8 @@ func run(ctx context.Context) error {
 			panic(err)
 		}
 		slog.Info("server was successfuly shutdown")
+
+		stopStats()
 	}()
 
 	slog.Info("starting server")
@@ -110,40 +230,8 @@ func run(ctx context.Context) error {
 		slog.Error("server error", "error", err)
 	}
 
-	return nil
-}
+	cancel()
+	wg.Wait()
 
-func prepairDB(c postgresql.Client) error {
-	_, err := c.Exec(
-		context.Background(),
-		`
-		INSERT INTO tags (id)
-		VALUES (1),(2),(3),(4),(5);
-
-		INSERT INTO features (id)
-		VALUES (1),(2),(3),(4),(5);
-
-		INSERT INTO banners
-		(title, text, url, is_active, created_at)
-		VALUES
-			('title1', 'text1', 'url1', true, NOW()),
-			('title2', 'text2', 'url2', false, NOW()),
-			('title3', 'text3', 'url3', false, NOW());
-
-		INSERT INTO banner_tag (banner_id, tag_id)
-		VALUES
-			(1, 1), (1,2), (1,3),
-			(2, 4), (3, 5), (3, 2);
-
-		INSERT INTO banner_feature (banner_id, feature_id)
-		VALUES
-			(1, 1), (2, 3), (3, 3);
-
-
-		INSERT INTO tokens (token, is_admin, created_at)
-		VALUES
-			('admin_token', true, NOW()),
-			('user_token', false, NOW());`,
-	)
-	return err
+	return nil
 }
@@ -2,6 +2,10 @@ package cache
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	stdErrors "errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -11,9 +15,69 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// invalidateScript deletes every key listed in the banner keys set (KEYS[1])
+// and the set itself in one step, so that no key of the banner survives it.
+// It also raises the version floor of the banner (KEYS[2]) to ARGV[1] for
+// ARGV[2] milliseconds. The keys it deletes are read from the set instead of
+// being passed in KEYS, and they do not share a hash slot, so the cache does
+// not work with Redis Cluster and needs a single primary (see REDIS_URL in
+// the README).
+var invalidateScript = redis.NewScript(`
+local floor = redis.call('GET', KEYS[2])
+if not floor or tonumber(floor) < tonumber(ARGV[1]) then
+	redis.call('SET', KEYS[2], ARGV[1], 'PX', ARGV[2])
+end
+local keys = redis.call('SMEMBERS', KEYS[1])
+for _, key in ipairs(keys) do
+	redis.call('DEL', key)
+end
+redis.call('DEL', KEYS[1])
+return #keys
+`)
+
+// setScript caches the banner ARGV[1] with the ID ARGV[2] and the version
+// ARGV[3] under KEYS[1] for ARGV[4] milliseconds, and adds the key to the
+// banner keys set (KEYS[2]), which lives for ARGV[5] milliseconds. It returns
+// 0 without writing if the version is below the version floor of the banner
+// (KEYS[3]) or below the version already cached under the key, since the
+// banner was read before a change that has been invalidated meanwhile.
+var setScript = redis.NewScript(`
+local version = tonumber(ARGV[3])
+local floor = redis.call('GET', KEYS[3])
+if floor and tonumber(floor) > version then
+	return 0
+end
+local current = redis.call('GET', KEYS[1])
+if current then
+	local banner = cjson.decode(current)
+	if banner.banner_id == tonumber(ARGV[2]) and (banner.version or 0) > version then
+		return 0
+	end
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[4])
+redis.call('SADD', KEYS[2], KEYS[1])
+redis.call('PEXPIRE', KEYS[2], ARGV[5])
+return 1
+`)
+
+// unlockScript releases the lock in KEYS[1] if it is still held with the
+// token in ARGV[1], and not by someone who took it over after it expired.
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// lockTTL bounds how long a crashed lock holder keeps others waiting.
+const lockTTL = 5 * time.Second
+
 type redisCache struct {
 	client *redis.Client
 	expiry time.Duration
+	// staleWindow is how long a banner is served after its expiry while one
+	// caller refreshes it under the lock. Zero disables both.
+	staleWindow time.Duration
 }
 
 func NewRedisCache(client *redis.Client, expirySeconds int) *redisCache {
@@ -23,96 +87,201 @@ func NewRedisCache(client *redis.Client, expirySeconds int) *redisCache {
 	}
 }
 
+// ServeStale makes the cache keep banners for window past their expiry and
+// report them as stale meanwhile, and makes Lock take a Redis lock, so that
+// a single caller across all replicas refreshes an expired banner.
+func (c *redisCache) ServeStale(window time.Duration) *redisCache {
+	c.staleWindow = window
+	return c
+}
+
+// cachedBanner is the value stored under a bannerKey.
+type cachedBanner struct {
+	BannerID int64                `json:"banner_id"`
+	Version  int64                `json:"version"`
+	Content  entity.BannerContent `json:"content"`
+	IsActive bool                 `json:"is_active"`
+	entity.Schedule
+	RefreshAt time.Time `json:"refresh_at"`
+	CachedAt  time.Time `json:"cached_at"`
+}
+
+// bannerKey names the cached banner of a feature and tag pair.
+func bannerKey(featureID, tagID int64) string {
+	return fmt.Sprintf("bn:%d:%d", featureID, tagID)
+}
+
+// lockKey names the lock taken to refresh the banner of a feature and tag
+// pair.
+func lockKey(featureID, tagID int64) string {
+	return fmt.Sprintf("bn_lock:%d:%d", featureID, tagID)
+}
+
+// versionFloorKey names the lowest version of a banner that may still be
+// cached. Invalidate raises it, so that a reader that read the banner before
+// the change cannot cache it after the invalidation.
+func versionFloorKey(bannerID int64) string {
+	return fmt.Sprintf("bn_floor:%d", bannerID)
+}
+
+// bannerKeysKey names the set of the bannerKeys a banner is cached under. It
+// lets Invalidate find them even after the tags or the feature of the banner
+// changed.
+func bannerKeysKey(bannerID int64) string {
+	return fmt.Sprintf("bn_keys:%d", bannerID)
+}
+
+// Set caches the banner under its feature and tag in a single script, so
+// that the banner keys set never misses a key and an older version of the
+// banner never replaces a newer one.
 func (c *redisCache) Set(ctx context.Context, dto entity.UpdateCacheDTO) error {
-	bannerID := fmt.Sprint(dto.BannerID)
-	featureID := fmt.Sprintf("features:%d", dto.FeatureID)
-	tagID := fmt.Sprintf("tags:%d", dto.TagID)
+	expiry := c.ttl(dto.EndsAt)
+	now := time.Now()
 
-	err := c.client.HSet(ctx, bannerID, "content", dto.Content, "isActive", dto.IsActive).Err()
+	value, err := json.Marshal(cachedBanner{
+		BannerID:  dto.BannerID,
+		Version:   dto.Version,
+		Content:   dto.Content,
+		IsActive:  dto.IsActive,
+		Schedule:  dto.Schedule,
+		RefreshAt: now.Add(expiry),
+		CachedAt:  now,
+	})
 	if err != nil {
-		slog.Error("error updating banner in redis", "error", err)
-		return err
-	}
-	err = c.client.Expire(ctx, bannerID, c.expiry).Err()
-	if err != nil {
-		slog.Error("error setting expiry in redis", "error", err)
+		slog.Error("error marshalling banner for redis", "error", err)
 		return err
 	}
 
-	err = c.client.SAdd(ctx, featureID, bannerID).Err()
-	if err != nil {
-		slog.Error("error updating banner feature in redis", "error", err)
-		return err
+	keys := []string{
+		bannerKey(dto.FeatureID, dto.TagID),
+		bannerKeysKey(dto.BannerID),
+		versionFloorKey(dto.BannerID),
 	}
-	err = c.client.Expire(ctx, featureID, c.expiry).Err()
+	written, err := setScript.Run(
+		ctx, c.client, keys,
+		value, dto.BannerID, dto.Version,
+		(expiry + c.staleWindow).Milliseconds(), (c.expiry + c.staleWindow).Milliseconds(),
+	).Int()
 	if err != nil {
-		slog.Error("error setting expiry in redis", "error", err)
+		slog.Error("error updating banner in redis", "error", err)
 		return err
 	}
+	if written == 0 {
+		slog.Debug("outdated banner not cached", "banner_id", dto.BannerID, "version", dto.Version)
+	}
 
-	err = c.client.SAdd(ctx, tagID, bannerID).Err()
-	if err != nil {
-		slog.Error("error updating tags in redis", "error", err)
-		return err
+	return nil
+
+}
+
+// ttl keeps a cached banner from outliving its schedule. A banner that has
+// already ended, or ends within the millisecond Redis counts expiries in, is
+// cached for the usual time, since Get rejects it anyway.
+func (c *redisCache) ttl(endsAt *time.Time) time.Duration {
+	if endsAt == nil {
+		return c.expiry
+	}
+
+	untilEnd := time.Until(*endsAt)
+	if untilEnd < time.Millisecond || untilEnd > c.expiry {
+		return c.expiry
 	}
-	err = c.client.Expire(ctx, tagID, c.expiry).Err()
+
+	return untilEnd
+}
+
+// Invalidate drops the banner under every feature and tag it was cached
+// under, so that lookups by its old feature and tags fall through to the
+// storage. The versions before the given one are not cached again for as long
+// as any cached banner lives, which outlasts the readers that raced with the
+// change.
+func (c *redisCache) Invalidate(ctx context.Context, bannerID, version int64) error {
+	keys := []string{bannerKeysKey(bannerID), versionFloorKey(bannerID)}
+	err := invalidateScript.Run(ctx, c.client, keys, version, (c.expiry + c.staleWindow).Milliseconds()).Err()
 	if err != nil {
-		slog.Error("error setting expiry in redis", "error", err)
+		slog.Error("error deleting banner from redis", "error", err)
 		return err
 	}
 
 	return nil
-
 }
 
-func (c *redisCache) Get(ctx context.Context, dto entity.GetUserBannerDTO) (entity.BannerContent, error) {
-	tagKey := fmt.Sprintf("tags:%d", dto.TagID)
-	featureKey := fmt.Sprintf("features:%d", dto.FeatureID)
-
-	slog.Debug("keys", "tag", tagKey, "feature", featureKey)
+func (c *redisCache) Get(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UserBanner, error) {
+	key := bannerKey(dto.FeatureID, dto.TagID)
 
-	bannerIDs, err := c.client.SInter(ctx, featureKey, tagKey).Result()
+	value, err := c.client.Get(ctx, key).Bytes()
+	if stdErrors.Is(err, redis.Nil) {
+		slog.Debug("banner not found in cache", "key", key)
+		return entity.UserBanner{}, errors.NewDomainError(errors.ErrNotCached, "")
+	}
 	if err != nil {
-		slog.Error("error getting bannerIDs from redis", "error", err)
-		return entity.BannerContent{}, err
+		slog.Error("error getting banner from redis", "error", err)
+		return entity.UserBanner{}, err
 	}
 
-	if len(bannerIDs) < 1 {
-		slog.Error("banner with that tag not found cache", "tag_id", dto.TagID)
-		return entity.BannerContent{}, errors.NewDomainError(errors.ErrNotCached, "")
+	var banner cachedBanner
+	err = json.Unmarshal(value, &banner)
+	if err != nil {
+		slog.Error("error unmarshalling result from redis", "error", err)
+		return entity.UserBanner{}, err
 	}
 
-	slog.Debug("bannerIDs", "ids", bannerIDs)
-
-	strIsActive, err := c.client.HGet(ctx, bannerIDs[0], "isActive").Result()
-	if err != nil {
-		slog.Error("error getting banner content from redis", "error", err)
-		return entity.BannerContent{}, err
+	userBanner := entity.UserBanner{
+		BannerID: banner.BannerID,
+		Content:  banner.Content,
+		IsActive: banner.IsActive,
+		Schedule: banner.Schedule,
+		Stale:    !time.Now().Before(banner.RefreshAt),
+		CachedAt: banner.CachedAt,
 	}
-	slog.Debug(strIsActive)
 
-	slog.Debug("check permission", "is_active", strIsActive, "is_admin", dto.IsAdmin)
-	if strIsActive == "0" && !dto.IsAdmin {
+	slog.Debug("check permission", "is_active", banner.IsActive, "schedule", banner.Schedule, "is_admin", dto.IsAdmin)
+	if !userBanner.VisibleTo(dto.IsAdmin) {
 		slog.Error("banner is not active and user is not admin")
-		return entity.BannerContent{}, errors.NewDomainError(errors.ErrForbidden, "")
+		return entity.UserBanner{}, errors.NewDomainError(errors.ErrForbidden, "")
 	}
 
-	jsonContent, err := c.client.HGet(ctx, bannerIDs[0], "content").Result()
+	slog.Debug("got banner content from cache", "content", banner.Content, "stale", userBanner.Stale)
+
+	return userBanner, nil
+
+}
+
+// Lock takes the lock for refreshing the banner of a feature and tag pair.
+// It reports false if someone else holds it. Without ServeStale the lock is
+// always granted without touching Redis.
+func (c *redisCache) Lock(ctx context.Context, featureID, tagID int64) (string, bool, error) {
+	if c.staleWindow == 0 {
+		return "", true, nil
+	}
+
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
 	if err != nil {
-		slog.Error("error getting banner content from redis", "error", err)
-		return entity.BannerContent{}, err
+		return "", false, err
 	}
+	token := hex.EncodeToString(b)
 
-	slog.Debug(jsonContent)
-	content := entity.BannerContent{}
-	err = content.UnmarshalBinary([]byte(jsonContent))
+	ok, err := c.client.SetNX(ctx, lockKey(featureID, tagID), token, lockTTL).Result()
 	if err != nil {
-		slog.Error("error unmarshalling result from redis", "error", err)
-		return entity.BannerContent{}, err
+		slog.Error("error taking banner lock in redis", "error", err)
+		return "", false, err
 	}
 
-	slog.Debug("got banner content from cache", "content", content)
+	return token, ok, nil
+}
 
-	return content, nil
+// Unlock releases a lock taken by Lock.
+func (c *redisCache) Unlock(ctx context.Context, featureID, tagID int64, token string) error {
+	if token == "" {
+		return nil
+	}
 
+	err := unlockScript.Run(ctx, c.client, []string{lockKey(featureID, tagID)}, token).Err()
+	if err != nil {
+		slog.Error("error releasing banner lock in redis", "error", err)
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"net"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/ory/dockertest"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if stopRedis != nil {
+		stopRedis()
+	}
+	os.Exit(code)
+}
+
+var (
+	redisOnce sync.Once
+	redisAddr string
+	redisErr  error
+	stopRedis func()
+)
+
+// testClient returns a client of a Redis started for the tests of the
+// package, with its data flushed. The test is skipped if Redis cannot be
+// started, as the other tests of the package do not need it.
+func testClient(t *testing.T) *redis.Client {
+	redisOnce.Do(func() {
+		redisAddr, stopRedis, redisErr = startRedis()
+	})
+	if redisErr != nil {
+		t.Skipf("redis is unavailable: %v", redisErr)
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: redisAddr})
+	t.Cleanup(func() { client.Close() })
+	require.NoError(t, client.FlushAll(context.Background()).Err())
+	return client
+}
+
+func startRedis() (string, func(), error) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		return "", nil, err
+	}
+
+	resource, err := pool.Run("redis", "5-alpine", nil)
+	if err != nil {
+		return "", nil, err
+	}
+	stop := func() {
+		pool.Purge(resource)
+	}
+
+	// determine the port the container is listening on
+	addr := net.JoinHostPort("localhost", resource.GetPort("6379/tcp"))
+
+	// wait for the container to be ready
+	err = pool.Retry(func() error {
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		defer client.Close()
+
+		return client.Ping(context.Background()).Err()
+	})
+	if err != nil {
+		stop()
+		return "", nil, err
+	}
+
+	return addr, stop, nil
+}
+
+// cachedVersion returns the version of the banner cached under the key, or
+// zero if nothing is.
+func cachedVersion(t *testing.T, client *redis.Client, key string) int64 {
+	value, err := client.Get(context.Background(), key).Bytes()
+	if stdErrors.Is(err, redis.Nil) {
+		return 0
+	}
+	require.NoError(t, err)
+
+	var banner cachedBanner
+	require.NoError(t, json.Unmarshal(value, &banner))
+	return banner.Version
+}
+
+// testBanner is a version of banner 1 shown for feature 1 and the tag.
+func testBanner(tagID, version int64) entity.UpdateCacheDTO {
+	return entity.UpdateCacheDTO{
+		BannerID:  1,
+		FeatureID: 1,
+		TagID:     tagID,
+		Version:   version,
+		Content:   entity.BannerContent{Title: "title"},
+		IsActive:  true,
+	}
+}
+
+func Test_redisCache_Set(t *testing.T) {
+	tests := []struct {
+		name string
+		// sets are cached in turn
+		sets        []entity.UpdateCacheDTO
+		wantVersion int64
+		wantKeys    []string
+	}{
+		{
+			name:        "positive, cached under the feature and tag",
+			sets:        []entity.UpdateCacheDTO{testBanner(1, 1)},
+			wantVersion: 1,
+			wantKeys:    []string{"bn:1:1"},
+		},
+		{
+			name:        "positive, newer version replaces the cached one",
+			sets:        []entity.UpdateCacheDTO{testBanner(1, 1), testBanner(1, 2)},
+			wantVersion: 2,
+			wantKeys:    []string{"bn:1:1"},
+		},
+		{
+			name:        "positive, every key of the banner is listed",
+			sets:        []entity.UpdateCacheDTO{testBanner(1, 1), testBanner(2, 1)},
+			wantVersion: 1,
+			wantKeys:    []string{"bn:1:1", "bn:1:2"},
+		},
+		{
+			name:        "negative, older version does not replace the cached one",
+			sets:        []entity.UpdateCacheDTO{testBanner(1, 2), testBanner(1, 1)},
+			wantVersion: 2,
+			wantKeys:    []string{"bn:1:1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := testClient(t)
+			c := NewRedisCache(client, 60)
+
+			for _, dto := range tt.sets {
+				require.NoError(t, c.Set(context.Background(), dto))
+			}
+
+			require.Equal(t, tt.wantVersion, cachedVersion(t, client, "bn:1:1"))
+			keys, err := client.SMembers(context.Background(), "bn_keys:1").Result()
+			require.NoError(t, err)
+			require.ElementsMatch(t, tt.wantKeys, keys)
+
+			got, err := c.Get(context.Background(), entity.GetUserBannerDTO{FeatureID: 1, TagID: 1})
+			require.NoError(t, err)
+			require.Equal(t, int64(1), got.BannerID)
+			require.Equal(t, "title", got.Content.Title)
+		})
+	}
+}
+
+func Test_redisCache_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	client := testClient(t)
+	c := NewRedisCache(client, 60)
+
+	require.NoError(t, c.Set(ctx, testBanner(1, 1)))
+	require.NoError(t, c.Set(ctx, testBanner(2, 1)))
+
+	// tag 2 is removed from the banner in version 2
+	require.NoError(t, c.Invalidate(ctx, 1, 2))
+
+	n, err := client.Exists(ctx, "bn:1:1", "bn:1:2", "bn_keys:1").Result()
+	require.NoError(t, err)
+	require.Zero(t, n)
+	floor, err := client.Get(ctx, "bn_floor:1").Int64()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), floor)
+
+	// a reader that read version 1 before the change cannot cache it
+	require.NoError(t, c.Set(ctx, testBanner(2, 1)))
+	require.Zero(t, cachedVersion(t, client, "bn:1:2"))
+
+	require.NoError(t, c.Set(ctx, testBanner(1, 2)))
+	require.Equal(t, int64(2), cachedVersion(t, client, "bn:1:1"))
+	keys, err := client.SMembers(ctx, "bn_keys:1").Result()
+	require.NoError(t, err)
+	require.Equal(t, []string{"bn:1:1"}, keys)
+
+	// an older invalidation does not lower the floor
+	require.NoError(t, c.Invalidate(ctx, 1, 1))
+	floor, err = client.Get(ctx, "bn_floor:1").Int64()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), floor)
+}
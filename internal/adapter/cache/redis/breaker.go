@@ -0,0 +1,237 @@
+package cache
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/breaker"
+)
+
+// maxPendingInvalidations bounds the invalidations kept for retrying while
+// the cache is unavailable. Beyond it the banners stay stale until they
+// expire.
+const maxPendingInvalidations = 10000
+
+var (
+	cacheFailures             = expvar.NewInt("banner_cache_failures")
+	cacheRejections           = expvar.NewInt("banner_cache_rejections")
+	cacheBreakerOpenings      = expvar.NewInt("banner_cache_breaker_openings")
+	cacheRetriedInvalidations = expvar.NewInt("banner_cache_retried_invalidations")
+	cacheLostInvalidations    = expvar.NewInt("banner_cache_lost_invalidations")
+)
+
+// breakerCache stops calling the next cache for a cool-down period after
+// repeated failures, and fails fast with ErrCacheUnavailable meanwhile. The
+// invalidations that could not be made are retried once the next cache
+// works again, so that it does not serve the banners changed meanwhile.
+type breakerCache struct {
+	next    BannerCache
+	breaker *breaker.Breaker
+
+	mu sync.Mutex
+	// pending maps the banners whose invalidation failed to the highest
+	// version they were invalidated with
+	pending  map[int64]int64
+	retrying bool
+	// retried is told about the banners whose invalidation has been made
+	// by a retry
+	retried func(ctx context.Context, bannerID int64)
+}
+
+// NewBreakerCache returns a cache that opens after threshold consecutive
+// failures of next and rejects all calls for coolDown.
+func NewBreakerCache(next BannerCache, threshold int, coolDown time.Duration) *breakerCache {
+	return &breakerCache{
+		next:    next,
+		breaker: breaker.New(threshold, coolDown),
+		pending: make(map[int64]int64),
+	}
+}
+
+// OnRetried makes fn be called with every banner whose invalidation has
+// been made by a retry, so that the caches in front of this one can drop
+// the copies they took from the next cache meanwhile.
+func (c *breakerCache) OnRetried(fn func(ctx context.Context, bannerID int64)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.retried = fn
+}
+
+func (c *breakerCache) Get(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UserBanner, error) {
+	if !c.allow() {
+		return entity.UserBanner{}, errCacheUnavailable()
+	}
+
+	banner, err := c.next.Get(ctx, dto)
+	c.done(ctx, err)
+	return banner, err
+}
+
+func (c *breakerCache) Set(ctx context.Context, dto entity.UpdateCacheDTO) error {
+	if !c.allow() {
+		return errCacheUnavailable()
+	}
+
+	err := c.next.Set(ctx, dto)
+	c.done(ctx, err)
+	return err
+}
+
+func (c *breakerCache) Invalidate(ctx context.Context, bannerID, version int64) error {
+	if !c.allow() {
+		c.retryLater(bannerID, version)
+		return errCacheUnavailable()
+	}
+
+	err := c.next.Invalidate(ctx, bannerID, version)
+	if failed(ctx, err) {
+		c.retryLater(bannerID, version)
+	}
+	c.done(ctx, err)
+	return err
+}
+
+func (c *breakerCache) Lock(ctx context.Context, featureID, tagID int64) (string, bool, error) {
+	if !c.allow() {
+		return "", false, errCacheUnavailable()
+	}
+
+	token, ok, err := c.next.Lock(ctx, featureID, tagID)
+	c.done(ctx, err)
+	return token, ok, err
+}
+
+func (c *breakerCache) Unlock(ctx context.Context, featureID, tagID int64, token string) error {
+	if !c.allow() {
+		// the lock expires on its own
+		return errCacheUnavailable()
+	}
+
+	err := c.next.Unlock(ctx, featureID, tagID, token)
+	c.done(ctx, err)
+	return err
+}
+
+func (c *breakerCache) allow() bool {
+	if c.breaker.Allow() {
+		return true
+	}
+	cacheRejections.Add(1)
+	return false
+}
+
+// Do runs fn, a call to Redis made past the next cache such as a publish,
+// under the breaker. While the breaker is open fn is not run and Do fails
+// with ErrCacheUnavailable.
+func (c *breakerCache) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !c.allow() {
+		return errCacheUnavailable()
+	}
+
+	err := fn(ctx)
+	c.record(ctx, err)
+	return err
+}
+
+// done records the outcome of a call to the next cache and retries the
+// pending invalidations once it works.
+func (c *breakerCache) done(ctx context.Context, err error) {
+	if c.record(ctx, err) {
+		c.retryPending(context.WithoutCancel(ctx))
+	}
+}
+
+// record records the outcome of a call and reports whether it succeeded.
+// Domain errors such as a cache miss are answers, not failures.
+func (c *breakerCache) record(ctx context.Context, err error) bool {
+	switch {
+	case err == nil || errors.Code(err) != "":
+		c.breaker.Success()
+		return true
+	case ctx.Err() != nil:
+		c.breaker.Cancel()
+		return false
+	}
+
+	cacheFailures.Add(1)
+	if c.breaker.Failure() {
+		cacheBreakerOpenings.Add(1)
+		slog.Error("redis is unavailable, bypassing the cache", "error", err)
+	}
+	return false
+}
+
+// failed reports whether err is a failure of the next cache rather than a
+// domain error or the caller giving up.
+func failed(ctx context.Context, err error) bool {
+	return err != nil && errors.Code(err) == "" && ctx.Err() == nil
+}
+
+func errCacheUnavailable() error {
+	return errors.NewDomainError(errors.ErrCacheUnavailable, "")
+}
+
+func (c *breakerCache) retryLater(bannerID, version int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.pending[bannerID]
+	if !ok && len(c.pending) >= maxPendingInvalidations {
+		cacheLostInvalidations.Add(1)
+		return
+	}
+	c.pending[bannerID] = max(pending, version)
+}
+
+// retryPending makes the invalidations that failed before in the background.
+// Only one retry runs at a time.
+func (c *breakerCache) retryPending(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.retrying || len(c.pending) == 0 {
+		return
+	}
+	c.retrying = true
+	versions := make(map[int64]int64, len(c.pending))
+	for bannerID, version := range c.pending {
+		versions[bannerID] = version
+	}
+
+	go c.invalidate(ctx, versions)
+}
+
+func (c *breakerCache) invalidate(ctx context.Context, versions map[int64]int64) {
+	defer func() {
+		c.mu.Lock()
+		c.retrying = false
+		c.mu.Unlock()
+	}()
+
+	for bannerID, version := range versions {
+		err := c.next.Invalidate(ctx, bannerID, version)
+		if err != nil {
+			slog.Error("error retrying banner invalidation", "banner_id", bannerID, "error", err)
+			return
+		}
+
+		c.mu.Lock()
+		// a newer invalidation that failed meanwhile is retried next time
+		if c.pending[bannerID] == version {
+			delete(c.pending, bannerID)
+		}
+		retried := c.retried
+		c.mu.Unlock()
+		cacheRetriedInvalidations.Add(1)
+
+		if retried != nil {
+			retried(ctx, bannerID)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/lru"
+	"github.com/redis/go-redis/v9"
+)
+
+// invalidationChannel carries the IDs of the banners invalidated on any
+// replica, so that every replica drops its local copies of them.
+const invalidationChannel = "banners:invalidated"
+
+// invalidationRetryInterval is how long Run waits before resubscribing after
+// the subscription has failed.
+const invalidationRetryInterval = time.Second
+
+type BannerCache interface {
+	Set(ctx context.Context, dto entity.UpdateCacheDTO) error
+	Get(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UserBanner, error)
+	Invalidate(ctx context.Context, bannerID, version int64) error
+	Lock(ctx context.Context, featureID, tagID int64) (string, bool, error)
+	Unlock(ctx context.Context, featureID, tagID int64, token string) error
+}
+
+// retrier is implemented by the caches that retry failed invalidations in
+// the background, such as breakerCache.
+type retrier interface {
+	OnRetried(fn func(ctx context.Context, bannerID int64))
+}
+
+// guard is implemented by the caches that stop calling Redis while it is
+// unavailable, such as breakerCache. The calls made to Redis past them go
+// through it too.
+type guard interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// direct is the guard of the caches without one.
+type direct struct{}
+
+func (direct) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type localKey struct {
+	tagID     int64
+	featureID int64
+	isAdmin   bool
+}
+
+// localCache keeps the banners found in the next cache in process memory, so
+// that a hit costs no round trips to Redis. Only the banners a user may see
+// are kept, misses, stale and forbidden banners always go to the next cache.
+type localCache struct {
+	next    BannerCache
+	client  *redis.Client
+	guard   guard
+	banners *lru.Cache[localKey, entity.UserBanner]
+	// generation is bumped on every invalidation, so that a banner read from
+	// the next cache before the invalidation is not kept after it
+	generation atomic.Uint64
+}
+
+// NewLocalCache returns a cache that keeps up to size banners for ttl in
+// front of next. Run must be running for the invalidations made on other
+// replicas to reach this one; if it misses some, local copies are stale for
+// ttl at most.
+func NewLocalCache(next BannerCache, client *redis.Client, size int, ttl time.Duration) *localCache {
+	c := &localCache{
+		next:    next,
+		client:  client,
+		guard:   guardOf(next),
+		banners: lru.New[localKey, entity.UserBanner](size, ttl),
+	}
+	if r, ok := next.(retrier); ok {
+		r.OnRetried(c.retried)
+	}
+	return c
+}
+
+func (c *localCache) Get(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UserBanner, error) {
+	key := localKey{tagID: dto.TagID, featureID: dto.FeatureID, isAdmin: dto.IsAdmin}
+	if banner, ok := c.banners.Get(key); ok {
+		return banner, nil
+	}
+
+	generation := c.generation.Load()
+	banner, err := c.next.Get(ctx, dto)
+	if err != nil {
+		return entity.UserBanner{}, err
+	}
+
+	switch {
+	case c.generation.Load() != generation, banner.Stale:
+	case banner.EndsAt != nil:
+		c.banners.SetWithTTL(key, banner, time.Until(*banner.EndsAt))
+	default:
+		c.banners.Set(key, banner)
+	}
+
+	return banner, nil
+}
+
+// Set passes the banner read from the storage to the next cache and drops
+// the local copies for its tag and feature, which may predate it.
+func (c *localCache) Set(ctx context.Context, dto entity.UpdateCacheDTO) error {
+	c.banners.Remove(localKey{tagID: dto.TagID, featureID: dto.FeatureID, isAdmin: true})
+	c.banners.Remove(localKey{tagID: dto.TagID, featureID: dto.FeatureID, isAdmin: false})
+
+	return c.next.Set(ctx, dto)
+}
+
+// Invalidate drops the banner here and in the next cache, and tells the
+// other replicas to drop it too. They are told even if the next cache
+// failed, as their copies are out of date either way.
+func (c *localCache) Invalidate(ctx context.Context, bannerID, version int64) error {
+	c.drop(bannerID)
+
+	err := c.next.Invalidate(ctx, bannerID, version)
+	pubErr := c.publish(ctx, bannerID)
+	if err != nil {
+		return err
+	}
+
+	return pubErr
+}
+
+// retried is called once the next cache has made an invalidation that failed
+// before. Copies of the banner may have been taken from the next cache until
+// then, here and on the other replicas.
+func (c *localCache) retried(ctx context.Context, bannerID int64) {
+	c.drop(bannerID)
+	c.publish(ctx, bannerID)
+}
+
+// guardOf returns the guard of the cache, if it has one.
+func guardOf(cache BannerCache) guard {
+	if g, ok := cache.(guard); ok {
+		return g
+	}
+	return direct{}
+}
+
+// publish tells the other replicas about the invalidation, unless Redis is
+// known to be unavailable. Their subscriptions are down then too, and they
+// purge their copies once they are back.
+func (c *localCache) publish(ctx context.Context, bannerID int64) error {
+	err := c.guard.Do(ctx, func(ctx context.Context) error {
+		return c.client.Publish(ctx, invalidationChannel, bannerID).Err()
+	})
+	switch {
+	case errors.Code(err) == errors.ErrCacheUnavailable:
+		slog.Debug("redis is unavailable, banner invalidation not published", "banner_id", bannerID)
+	case err != nil:
+		slog.Error("error publishing banner invalidation to redis", "banner_id", bannerID, "error", err)
+	}
+	return err
+}
+
+func (c *localCache) Lock(ctx context.Context, featureID, tagID int64) (string, bool, error) {
+	return c.next.Lock(ctx, featureID, tagID)
+}
+
+func (c *localCache) Unlock(ctx context.Context, featureID, tagID int64, token string) error {
+	return c.next.Unlock(ctx, featureID, tagID, token)
+}
+
+func (c *localCache) drop(bannerID int64) {
+	c.generation.Add(1)
+	c.banners.RemoveFunc(func(_ localKey, banner entity.UserBanner) bool {
+		return banner.BannerID == bannerID
+	})
+}
+
+// Run applies the invalidations published by the replicas until ctx is
+// done. The local copies are purged whenever the subscription is
+// (re)established, since invalidations may have been missed meanwhile.
+func (c *localCache) Run(ctx context.Context) {
+	pubsub := c.client.Subscribe(ctx, invalidationChannel)
+	defer pubsub.Close()
+
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("error receiving banner invalidations from redis", "error", err)
+			c.purge()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(invalidationRetryInterval):
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			c.purge()
+		case *redis.Message:
+			bannerID, err := strconv.ParseInt(msg.Payload, 10, 64)
+			if err != nil {
+				slog.Error("error parsing invalidated banner ID", "payload", msg.Payload, "error", err)
+				continue
+			}
+			c.drop(bannerID)
+		}
+	}
+}
+
+func (c *localCache) purge() {
+	c.generation.Add(1)
+	c.banners.Purge()
+}
@@ -0,0 +1,294 @@
+package cache
+
+import (
+	"context"
+	stdErrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCache serves banner from Get and counts the calls. onGet runs before
+// Get returns. Invalidate fails with invalidateErr.
+type fakeCache struct {
+	banner        entity.UserBanner
+	err           error
+	gets          int
+	onGet         func()
+	invalidateErr error
+}
+
+func (c *fakeCache) Set(ctx context.Context, dto entity.UpdateCacheDTO) error {
+	return nil
+}
+
+func (c *fakeCache) Get(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UserBanner, error) {
+	c.gets++
+	if c.onGet != nil {
+		c.onGet()
+	}
+	return c.banner, c.err
+}
+
+func (c *fakeCache) Invalidate(ctx context.Context, bannerID, version int64) error {
+	return c.invalidateErr
+}
+
+func (c *fakeCache) Lock(ctx context.Context, featureID, tagID int64) (string, bool, error) {
+	return "", true, nil
+}
+
+func (c *fakeCache) Unlock(ctx context.Context, featureID, tagID int64, token string) error {
+	return nil
+}
+
+func Test_localCache_Get(t *testing.T) {
+	dto := entity.GetUserBannerDTO{FeatureID: 1, TagID: 1}
+	ended := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name string
+		// setup prepares the next cache and may act on the local cache while
+		// its Get runs
+		setup func(c *localCache, next *fakeCache)
+		// wantGets is how many times two consecutive Gets reach the next
+		// cache
+		wantGets int
+	}{
+		{
+			name:     "hit is kept",
+			setup:    func(c *localCache, next *fakeCache) {},
+			wantGets: 1,
+		},
+		{
+			name: "stale banner is not kept",
+			setup: func(c *localCache, next *fakeCache) {
+				next.banner.Stale = true
+			},
+			wantGets: 2,
+		},
+		{
+			name: "miss is not kept",
+			setup: func(c *localCache, next *fakeCache) {
+				next.err = errors.NewDomainError(errors.ErrNotCached, "")
+			},
+			wantGets: 2,
+		},
+		{
+			name: "banner invalidated during the read is not kept",
+			setup: func(c *localCache, next *fakeCache) {
+				next.onGet = func() {
+					next.onGet = nil
+					c.drop(next.banner.BannerID)
+				}
+			},
+			wantGets: 2,
+		},
+		{
+			name: "invalidation of another banner also discards the read",
+			setup: func(c *localCache, next *fakeCache) {
+				next.onGet = func() {
+					next.onGet = nil
+					c.drop(next.banner.BannerID + 1)
+				}
+			},
+			wantGets: 2,
+		},
+		{
+			name: "purge during the read discards it",
+			setup: func(c *localCache, next *fakeCache) {
+				next.onGet = func() {
+					next.onGet = nil
+					c.purge()
+				}
+			},
+			wantGets: 2,
+		},
+		{
+			name: "ended banner is not kept",
+			setup: func(c *localCache, next *fakeCache) {
+				next.banner.EndsAt = &ended
+			},
+			wantGets: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &fakeCache{banner: entity.UserBanner{BannerID: 1, IsActive: true}}
+			c := NewLocalCache(next, nil, 10, time.Minute)
+			tt.setup(c, next)
+
+			c.Get(context.Background(), dto)
+			c.Get(context.Background(), dto)
+
+			require.Equal(t, tt.wantGets, next.gets)
+		})
+	}
+}
+
+func Test_localCache_drop(t *testing.T) {
+	next := &fakeCache{banner: entity.UserBanner{BannerID: 1, IsActive: true}}
+	c := NewLocalCache(next, nil, 10, time.Minute)
+
+	pairs := []entity.GetUserBannerDTO{
+		{FeatureID: 1, TagID: 1},
+		{FeatureID: 1, TagID: 2},
+		{FeatureID: 1, TagID: 1, IsAdmin: true},
+	}
+	for _, dto := range pairs {
+		_, err := c.Get(context.Background(), dto)
+		require.NoError(t, err)
+	}
+	next.banner.BannerID = 2
+	_, err := c.Get(context.Background(), entity.GetUserBannerDTO{FeatureID: 2, TagID: 1})
+	require.NoError(t, err)
+	require.Equal(t, 4, c.banners.Len())
+
+	c.drop(1)
+
+	require.Equal(t, 1, c.banners.Len())
+	banner, err := c.Get(context.Background(), entity.GetUserBannerDTO{FeatureID: 2, TagID: 1})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, banner.BannerID)
+	require.Equal(t, 4, next.gets)
+}
+
+func Test_localCache_Set(t *testing.T) {
+	next := &fakeCache{banner: entity.UserBanner{BannerID: 1, IsActive: true}}
+	c := NewLocalCache(next, nil, 10, time.Minute)
+
+	for _, isAdmin := range []bool{false, true} {
+		_, err := c.Get(context.Background(), entity.GetUserBannerDTO{FeatureID: 1, TagID: 1, IsAdmin: isAdmin})
+		require.NoError(t, err)
+	}
+	_, err := c.Get(context.Background(), entity.GetUserBannerDTO{FeatureID: 1, TagID: 2})
+	require.NoError(t, err)
+	require.Equal(t, 3, c.banners.Len())
+
+	err = c.Set(context.Background(), entity.UpdateCacheDTO{BannerID: 1, FeatureID: 1, TagID: 1})
+	require.NoError(t, err)
+
+	require.Equal(t, 1, c.banners.Len())
+}
+
+// publishRecorder is a redis hook that keeps the banner IDs published on
+// invalidationChannel instead of sending them.
+type publishRecorder struct {
+	mu        sync.Mutex
+	bannerIDs []int64
+}
+
+func (h *publishRecorder) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *publishRecorder) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		args := cmd.Args()
+		if cmd.Name() != "publish" || len(args) != 3 || args[1] != invalidationChannel {
+			return stdErrors.New("unexpected command")
+		}
+		bannerID, ok := args[2].(int64)
+		if !ok {
+			return stdErrors.New("unexpected message")
+		}
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.bannerIDs = append(h.bannerIDs, bannerID)
+		return nil
+	}
+}
+
+func (h *publishRecorder) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func (h *publishRecorder) published() []int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]int64(nil), h.bannerIDs...)
+}
+
+func newPublishRecorder() (*redis.Client, *publishRecorder) {
+	client := redis.NewClient(&redis.Options{})
+	recorder := &publishRecorder{}
+	client.AddHook(recorder)
+	return client, recorder
+}
+
+func Test_localCache_Invalidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		invalidateErr error
+	}{
+		{
+			name: "positive",
+		},
+		{
+			name:          "negative, replicas are told even if the next cache fails",
+			invalidateErr: stdErrors.New("redis is down"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, recorder := newPublishRecorder()
+			next := &fakeCache{banner: entity.UserBanner{BannerID: 1, IsActive: true}, invalidateErr: tt.invalidateErr}
+			c := NewLocalCache(next, client, 10, time.Minute)
+
+			_, err := c.Get(context.Background(), entity.GetUserBannerDTO{FeatureID: 1, TagID: 1})
+			require.NoError(t, err)
+
+			err = c.Invalidate(context.Background(), 1, 2)
+			require.Equal(t, tt.invalidateErr, err)
+
+			require.Zero(t, c.banners.Len())
+			require.Equal(t, []int64{1}, recorder.published())
+		})
+	}
+}
+
+func Test_localCache_retried(t *testing.T) {
+	client, recorder := newPublishRecorder()
+	next := &fakeCache{
+		banner:        entity.UserBanner{BannerID: 1, IsActive: true},
+		invalidateErr: stdErrors.New("redis is down"),
+	}
+	c := NewLocalCache(NewBreakerCache(next, 5, time.Minute), client, 10, time.Minute)
+
+	err := c.Invalidate(context.Background(), 1, 2)
+	require.Error(t, err)
+	require.Equal(t, []int64{1}, recorder.published())
+
+	// redis is back: the banner is read into the local cache before the
+	// retry of the invalidation, which the successful read starts
+	next.invalidateErr = nil
+	_, err = c.Get(context.Background(), entity.GetUserBannerDTO{FeatureID: 1, TagID: 1})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(recorder.published()) == 2
+	}, time.Second, time.Millisecond)
+	require.Equal(t, []int64{1, 1}, recorder.published())
+	require.Zero(t, c.banners.Len())
+}
+
+func Test_localCache_Invalidate_BreakerOpen(t *testing.T) {
+	client, recorder := newPublishRecorder()
+	next := &fakeCache{invalidateErr: stdErrors.New("redis is down")}
+	c := NewLocalCache(NewBreakerCache(next, 1, time.Minute), client, 10, time.Minute)
+
+	// the failed invalidation opens the breaker before the publish
+	err := c.Invalidate(context.Background(), 1, 2)
+	require.Equal(t, next.invalidateErr, err)
+
+	err = c.Invalidate(context.Background(), 2, 2)
+	require.Equal(t, errors.ErrCacheUnavailable, errors.Code(err))
+
+	require.Empty(t, recorder.published())
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/service"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ service.TokenInvalidations = new(tokenInvalidations)
+
+// tokenInvalidationChannel carries the token checks invalidated on any
+// replica, as "token:<id>".
+const tokenInvalidationChannel = "tokens:invalidated"
+
+// tokenInvalidations broadcasts token invalidations over Redis pub/sub.
+type tokenInvalidations struct {
+	client *redis.Client
+	guard  guard
+}
+
+// NewTokenInvalidations returns invalidations published through client. If
+// cache stops calling Redis while it is unavailable, such as breakerCache,
+// nothing is published meanwhile either.
+func NewTokenInvalidations(client *redis.Client, cache BannerCache) *tokenInvalidations {
+	return &tokenInvalidations{
+		client: client,
+		guard:  guardOf(cache),
+	}
+}
+
+// Publish fails with ErrCacheUnavailable while Redis is known to be
+// unavailable. The subscriptions of the other replicas are down then too,
+// and they purge their caches once they are back.
+func (t *tokenInvalidations) Publish(ctx context.Context, inv entity.TokenInvalidation) error {
+	return t.guard.Do(ctx, func(ctx context.Context) error {
+		return t.client.Publish(ctx, tokenInvalidationChannel, formatTokenInvalidation(inv)).Err()
+	})
+}
+
+// Subscribe calls reset whenever the subscription is (re)established or
+// fails, since invalidations may have been missed meanwhile.
+func (t *tokenInvalidations) Subscribe(ctx context.Context, fn func(inv entity.TokenInvalidation), reset func()) {
+	pubsub := t.client.Subscribe(ctx, tokenInvalidationChannel)
+	defer pubsub.Close()
+
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Error("error receiving token invalidations from redis", "error", err)
+			reset()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(invalidationRetryInterval):
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			reset()
+		case *redis.Message:
+			inv, err := parseTokenInvalidation(msg.Payload)
+			if err != nil {
+				slog.Error("error parsing token invalidation", "payload", msg.Payload, "error", err)
+				// the checks it was about are unknown
+				reset()
+				continue
+			}
+			fn(inv)
+		}
+	}
+}
+
+func formatTokenInvalidation(inv entity.TokenInvalidation) string {
+	return "token:" + strconv.FormatInt(inv.TokenID, 10)
+}
+
+func parseTokenInvalidation(payload string) (entity.TokenInvalidation, error) {
+	kind, strID, ok := strings.Cut(payload, ":")
+	if !ok {
+		return entity.TokenInvalidation{}, fmt.Errorf("no kind in %q", payload)
+	}
+	id, err := strconv.ParseInt(strID, 10, 64)
+	if err != nil {
+		return entity.TokenInvalidation{}, err
+	}
+
+	switch kind {
+	case "token":
+		return entity.TokenInvalidation{TokenID: id}, nil
+	default:
+		return entity.TokenInvalidation{}, fmt.Errorf("unknown kind %q", kind)
+	}
+}
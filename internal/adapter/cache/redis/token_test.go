@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseTokenInvalidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    entity.TokenInvalidation
+		wantErr bool
+	}{
+		{
+			name:    "positive, token",
+			payload: formatTokenInvalidation(entity.TokenInvalidation{TokenID: 12}),
+			want:    entity.TokenInvalidation{TokenID: 12},
+		},
+		{
+			name:    "negative, unknown kind",
+			payload: "feature:3",
+			wantErr: true,
+		},
+		{
+			name:    "negative, no kind",
+			payload: "3",
+			wantErr: true,
+		},
+		{
+			name:    "negative, invalid ID",
+			payload: "token:x",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTokenInvalidation(tt.payload)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
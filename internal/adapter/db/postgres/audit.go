@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/service"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ service.AuditStorage = new(auditStorage)
+
+type auditStorage struct {
+	client postgresql.Client
+}
+
+func NewAuditStorage(client postgresql.Client) *auditStorage {
+	return &auditStorage{client}
+}
+
+func (s *auditStorage) GetAuditRecords(ctx context.Context, dto entity.GetAuditRecordsDTO) ([]entity.AuditRecord, error) {
+
+	var q queryBuilder
+	q.Write(`SELECT id, banner_id, action, actor, token_id, COALESCE(request_id, ''), before, after, created_at
+		FROM audit_log
+		WHERE TRUE`)
+
+	if dto.BannerID != 0 {
+		q.Write(" AND banner_id = " + q.Arg(dto.BannerID))
+	}
+	if dto.Actor != "" {
+		q.Write(" AND actor = " + q.Arg(dto.Actor))
+	}
+	if dto.From != nil {
+		q.Write(" AND created_at >= " + q.Arg(*dto.From))
+	}
+	if dto.To != nil {
+		q.Write(" AND created_at < " + q.Arg(*dto.To))
+	}
+
+	q.Write(" ORDER BY created_at DESC, id DESC")
+	q.Write(" LIMIT " + q.Arg(dto.Limit))
+	q.Write(" OFFSET " + q.Arg(dto.Offset) + ";")
+
+	rows, err := s.client.Query(ctx, q.String(), q.Args()...)
+	if err != nil {
+		slog.Error("error selecting from audit_log",
+			"error", err,
+		)
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	records, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.AuditRecord, error) {
+		var r entity.AuditRecord
+		err := row.Scan(
+			&r.ID, &r.BannerID, &r.Action, &r.Actor, &r.TokenID, &r.RequestID,
+			&r.Before, &r.After, &r.CreatedAt,
+		)
+		return r, err
+	})
+	if err != nil {
+		slog.Error("error collecting rows",
+			"error", err,
+		)
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	return records, nil
+}
+
+// bannerChange is a change to a banner to be written to the audit log.
+// before is nil for created banners and after is nil for deleted ones.
+type bannerChange struct {
+	action    entity.AuditAction
+	principal entity.Principal
+	requestID string
+	before    *entity.Banner
+	after     *entity.Banner
+}
+
+// insertAuditRecord writes the change to the audit log. It must be called in
+// the transaction that makes the change, so that no change goes unrecorded.
+func insertAuditRecord(ctx context.Context, tx pgx.Tx, bannerID int64, change bannerChange) error {
+
+	var before, after []byte
+	var err error
+	if change.before != nil {
+		before, err = json.Marshal(change.before)
+		if err != nil {
+			slog.Error("error marshaling banner", "error", err)
+			return errors.NewDomainError(errors.ErrDB, "")
+		}
+	}
+	if change.after != nil {
+		after, err = json.Marshal(change.after)
+		if err != nil {
+			slog.Error("error marshaling banner", "error", err)
+			return errors.NewDomainError(errors.ErrDB, "")
+		}
+	}
+
+	var tokenID *int64
+	if change.principal.TokenID != 0 {
+		tokenID = &change.principal.TokenID
+	}
+	var requestID *string
+	if change.requestID != "" {
+		requestID = &change.requestID
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO
+			audit_log (banner_id, action, actor, token_id, request_id, before, after, created_at)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, NOW());`,
+		bannerID, change.action, change.principal.Actor(), tokenID, requestID, before, after,
+	)
+	if err != nil {
+		slog.Error("error inserting in audit_log",
+			"error", err,
+		)
+		return dbError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// execTx records the arguments of the statements executed in it.
+type execTx struct {
+	fakeTx
+
+	args [][]any
+}
+
+func (tx *execTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tx.args = append(tx.args, args)
+	return pgconn.CommandTag{}, nil
+}
+
+func Test_insertAuditRecord(t *testing.T) {
+	before := entity.Banner{
+		BannerID:  3,
+		TagIDs:    []int64{1, 2},
+		FeatureID: 1,
+		Content:   entity.BannerContent{Title: "before"},
+		IsActive:  true,
+		Version:   1,
+	}
+	after := before
+	after.Content = entity.BannerContent{Title: "after"}
+	after.IsActive = false
+	after.Version = 2
+
+	tokenID := int64(7)
+	requestID := "req-1"
+
+	type want struct {
+		actor     string
+		tokenID   *int64
+		requestID *string
+	}
+	tests := []struct {
+		name   string
+		change bannerChange
+		want   want
+	}{
+		{
+			name: "positive, update by a token",
+			change: bannerChange{
+				action:    entity.AuditUpdate,
+				principal: entity.Principal{TokenID: 7, Role: entity.RoleEditor},
+				requestID: "req-1",
+				before:    &before,
+				after:     &after,
+			},
+			want: want{actor: "token:7", tokenID: &tokenID, requestID: &requestID},
+		},
+		{
+			name: "positive, update without a token or request ID",
+			change: bannerChange{
+				action:    entity.AuditUpdate,
+				principal: entity.Principal{Subject: "alice", Role: entity.RoleAdmin},
+				before:    &before,
+				after:     &after,
+			},
+			want: want{actor: "jwt:alice"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &execTx{}
+
+			err := insertAuditRecord(context.Background(), tx, 3, tt.change)
+			require.NoError(t, err)
+			require.Len(t, tx.args, 1)
+
+			args := tx.args[0]
+			require.Equal(t, int64(3), args[0])
+			require.Equal(t, entity.AuditUpdate, args[1])
+			require.Equal(t, tt.want.actor, args[2])
+			require.Equal(t, tt.want.tokenID, args[3])
+			require.Equal(t, tt.want.requestID, args[4])
+
+			var gotBefore, gotAfter entity.Banner
+			require.NoError(t, json.Unmarshal(args[5].([]byte), &gotBefore))
+			require.NoError(t, json.Unmarshal(args[6].([]byte), &gotAfter))
+			require.Equal(t, before, gotBefore)
+			require.Equal(t, after, gotAfter)
+		})
+	}
+
+	t.Run("positive, no snapshot of a created banner before it", func(t *testing.T) {
+		tx := &execTx{}
+
+		err := insertAuditRecord(context.Background(), tx, 3, bannerChange{
+			action: entity.AuditCreate,
+			after:  &after,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "system", tx.args[0][2])
+		require.Nil(t, tx.args[0][5])
+		require.NotNil(t, tx.args[0][6])
+	})
+}
@@ -6,8 +6,8 @@ import (
 	stdErrors "errors"
 	"fmt"
 	"log/slog"
-	"strconv"
-	"strings"
+	"sort"
+	"time"
 
 	"github.com/The-Gleb/banner_service/internal/domain/entity"
 	"github.com/The-Gleb/banner_service/internal/domain/service"
@@ -21,7 +21,6 @@ import (
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/jackc/pgx/v5/pgtype"
 )
 
 var _ service.BannerStorage = new(bannerStorage)
@@ -61,237 +60,246 @@ func RunMigrations(dsn string) error {
 
 func (s *bannerStorage) DeleteBanner(ctx context.Context, dto entity.DeleteBannerDTO) error {
 
-	c, err := s.client.Exec(
-		ctx,
-		`DELETE FROM banners CASCADE
-		WHERE id = $1;`,
-		dto.BannerID,
-	)
-	if err != nil {
-		slog.Error("error deleting from banners",
-			"error", err,
-		)
-		return errors.NewDomainError(errors.ErrDB, "")
-	}
-	if c.RowsAffected() == 0 {
-		slog.Error("error deleting from banner_tag, id not found")
-		return errors.NewDomainError(errors.ErrNoDataFound, "")
-	}
+	return inTx(ctx, s.client, func(tx pgx.Tx) error {
+		err := lockBannerVersion(ctx, tx, dto.BannerID, dto.ExpectedVersion)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		before, err := selectBanner(ctx, tx, dto.BannerID)
+		if err != nil {
+			return err
+		}
 
-}
+		_, err = deleteBanners(ctx, tx, []int64{dto.BannerID})
+		if err != nil {
+			return err
+		}
 
-func (s *bannerStorage) GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UpdateCacheDTO, error) {
+		return insertAuditRecord(ctx, tx, dto.BannerID, bannerChange{
+			action:    entity.AuditDelete,
+			principal: dto.Principal,
+			requestID: dto.RequestID,
+			before:    &before,
+		})
+	})
 
-	tx, err := s.client.Begin(ctx)
-	if err != nil {
-		slog.Error("error beginnig transaction",
-			"error", err,
+}
+
+// DeleteBanners deletes at most limit banners matching the filters, together
+// with their banner_tag and banner_feature rows, and returns their IDs. Each
+// deleted banner gets an audit record naming the principal of the DTO.
+// Banners locked by other transactions are waited for rather than skipped,
+// so a batch smaller than limit means that no matching banner is left.
+func (s *bannerStorage) DeleteBanners(ctx context.Context, dto entity.DeleteBannersDTO, limit int) ([]int64, error) {
+
+	var bannerIDs []int64
+	err := inTx(ctx, s.client, func(tx pgx.Tx) error {
+		rows, err := tx.Query(
+			ctx,
+			`SELECT b.id
+			FROM banners b
+			WHERE
+				($1::bigint = 0 OR EXISTS (
+					SELECT 1 FROM banner_feature bf
+					WHERE bf.banner_id = b.id AND bf.feature_id = $1
+				))
+				AND ($2::bigint = 0 OR EXISTS (
+					SELECT 1 FROM banner_tag bt
+					WHERE bt.banner_id = b.id AND bt.tag_id = $2
+				))
+			ORDER BY b.id
+			LIMIT $3
+			FOR UPDATE;`,
+			dto.FeatureID, dto.TagID, limit,
 		)
-		return entity.UpdateCacheDTO{}, errors.NewDomainError(errors.ErrDB, "")
-	}
-	defer tx.Rollback(ctx)
+		if err != nil {
+			slog.Error("error selecting banners to delete",
+				"error", err,
+			)
+			return dbError(err)
+		}
 
-	query := fmt.Sprintf(
-		`SELECT
-			banner_id
-		FROM
-			banner_tag
-		WHERE tag_id = %d;`,
-		dto.TagID,
-	)
+		bannerIDs, err = pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			slog.Error("error collecting rows",
+				"error", err,
+			)
+			return dbError(err)
+		}
 
-	rows, err := tx.Query(ctx, query)
-	if err != nil {
-		slog.Error("error selecting from banner_tag",
-			"error", err,
-		)
-		return entity.UpdateCacheDTO{}, errors.NewDomainError(errors.ErrDB, "")
-	}
+		befores := make([]entity.Banner, 0, len(bannerIDs))
+		for _, bannerID := range bannerIDs {
+			before, err := selectBanner(ctx, tx, bannerID)
+			if err != nil {
+				return err
+			}
+			befores = append(befores, before)
+		}
 
-	strBannerIDs, err := pgx.CollectRows[string](rows, func(row pgx.CollectableRow) (string, error) {
-		var id string
-		err := row.Scan(&id)
-		return id, err
-	})
+		_, err = deleteBanners(ctx, tx, bannerIDs)
+		if err != nil {
+			return err
+		}
+
+		for i := range befores {
+			err = insertAuditRecord(ctx, tx, befores[i].BannerID, bannerChange{
+				action:    entity.AuditDelete,
+				principal: dto.Principal,
+				requestID: dto.RequestID,
+				before:    &befores[i],
+			})
+			if err != nil {
+				return err
+			}
+		}
 
+		return nil
+	})
 	if err != nil {
-		slog.Error("error collecting rows",
-			"error", err,
-		)
-		return entity.UpdateCacheDTO{}, errors.NewDomainError(errors.ErrDB, "")
+		return nil, err
 	}
 
-	query = fmt.Sprintf(
-		`SELECT banner_id
-		FROM banner_feature
-		WHERE banner_id IN (%s) AND feature_id = %d;`,
-		strings.Join(strBannerIDs, ", "), dto.FeatureID,
-	)
+	return bannerIDs, nil
+}
 
-	row := tx.QueryRow(ctx, query)
+// deleteBanners removes the banners with their banner_tag and banner_feature
+// rows and returns the number of deleted banners.
+func deleteBanners(ctx context.Context, tx pgx.Tx, bannerIDs []int64) (int64, error) {
 
-	var bannerID int64
-	err = row.Scan(&bannerID)
-	if err != nil {
-		slog.Error("error scanning row",
-			"error", err,
-		)
-		if stdErrors.Is(err, pgx.ErrNoRows) {
-			return entity.UpdateCacheDTO{}, errors.NewDomainError(errors.ErrNoDataFound, "")
-		}
+	if len(bannerIDs) == 0 {
+		return 0, nil
+	}
 
-		return entity.UpdateCacheDTO{}, errors.NewDomainError(errors.ErrDB, "")
+	var c pgconn.CommandTag
+	var err error
+	for _, query := range []string{
+		`DELETE FROM banner_tag WHERE banner_id = ANY($1);`,
+		`DELETE FROM banner_feature WHERE banner_id = ANY($1);`,
+		`DELETE FROM banners WHERE id = ANY($1);`,
+	} {
+		c, err = tx.Exec(ctx, query, bannerIDs)
+		if err != nil {
+			slog.Error("error deleting banners",
+				"error", err,
+			)
+			return 0, dbError(err)
+		}
 	}
 
-	query = fmt.Sprintf(
-		`SELECT title, text, url, is_active
-		FROM banners
-		WHERE id = %d;`,
-		bannerID,
-	)
+	return c.RowsAffected(), nil
+}
 
-	row = tx.QueryRow(ctx, query)
+func (s *bannerStorage) GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UpdateCacheDTO, error) {
 
-	var isActive bool
-	content := entity.BannerContent{}
-	err = row.Scan(&content.Title, &content.Text, &content.URL, &isActive)
+	row := s.client.QueryRow(
+		ctx,
+		`SELECT b.id, b.version, b.title, b.text, b.url, b.is_active, b.starts_at, b.ends_at
+		FROM banner_feature_tag bft
+			JOIN banners b ON b.id = bft.banner_id
+		WHERE bft.tag_id = $1 AND bft.feature_id = $2;`,
+		dto.TagID, dto.FeatureID,
+	)
+
+	var (
+		bannerID int64
+		version  int64
+		isActive bool
+		content  entity.BannerContent
+		schedule entity.Schedule
+	)
+	err := row.Scan(&bannerID, &version, &content.Title, &content.Text, &content.URL, &isActive, &schedule.StartsAt, &schedule.EndsAt)
 	if err != nil {
 		slog.Error("error scanning row",
 			"error", err,
 		)
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return entity.UpdateCacheDTO{}, errors.NewDomainError(errors.ErrNoDataFound, "")
+		}
+
 		return entity.UpdateCacheDTO{}, errors.NewDomainError(errors.ErrDB, "")
 	}
 
-	if !isActive && !dto.IsAdmin {
+	if (!isActive || !schedule.Contains(time.Now())) && !dto.IsAdmin {
 		return entity.UpdateCacheDTO{}, errors.NewDomainError(errors.ErrForbidden, "")
 	}
 
-	err = tx.Commit(ctx)
-	if err != nil {
-		slog.Error("error commiting transaction",
-			"error", err,
-		)
-		return entity.UpdateCacheDTO{}, errors.NewDomainError(errors.ErrDB, "")
-	}
-
 	return entity.UpdateCacheDTO{
 		BannerID:  bannerID,
+		Version:   version,
 		Content:   content,
 		TagID:     dto.TagID,
 		FeatureID: dto.FeatureID,
 		IsActive:  isActive,
 		IsAdmin:   dto.IsAdmin,
+		Schedule:  schedule,
 	}, nil
 
 }
 
+// bannerFilters maps the keys accepted in GetBannersDTO.Filters to the
+// condition they add. Each condition takes exactly one argument.
+var bannerFilters = map[string]string{
+	entity.BannerFilterFeature: "bf.feature_id = %s",
+	entity.BannerFilterTag:     "EXISTS (SELECT 1 FROM banner_tag bt WHERE bt.banner_id = b.id AND bt.tag_id = %s)",
+}
+
 func (s *bannerStorage) GetBanners(ctx context.Context, dto entity.GetBannersDTO) ([]entity.Banner, error) {
 
-	tx, err := s.client.Begin(ctx)
-	if err != nil {
-		slog.Error("error beginnig transaction",
-			"error", err,
-		)
-		return nil, errors.NewDomainError(errors.ErrDB, "")
+	var q queryBuilder
+	q.Write(
+		`SELECT
+			b.id, bf.feature_id,
+			ARRAY(SELECT tag_id FROM banner_tag WHERE banner_id = b.id ORDER BY tag_id),
+			b.title, b.text, b.url, b.is_active, b.starts_at, b.ends_at, b.version,
+			b.created_at, COALESCE(b.updated_at, b.created_at)
+		FROM banners b
+			JOIN banner_feature bf ON bf.banner_id = b.id
+		WHERE TRUE`,
+	)
+
+	keys := make([]string, 0, len(dto.Filters))
+	for k := range dto.Filters {
+		keys = append(keys, k)
 	}
-	defer tx.Rollback(ctx)
-
-	// tagID, tagOK := dto.Filters["tag"]
-	// featureID, featureOK := dto.Filters["feature"]
-
-	query := `SELECT
-    bt.banner_id,
-    feature_id,
-    ARRAY_AGG(tag_id)
-	FROM
-		banner_tag bt
-		JOIN banner_feature bf
-		ON bt.banner_id = bf.banner_id
-	WHERE
-		bt.banner_id IN (
-        SELECT
-            bt.banner_id
-        FROM
-            banner_tag bt
-            JOIN banner_feature bf ON bt.banner_id = bf.banner_id
-        WHERE
-	`
-
-	for k, v := range dto.Filters {
-		query = fmt.Sprintf(
-			`%s
-			%s_id = %d
-			AND`,
-			query, k, v,
-		)
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		condition, ok := bannerFilters[k]
+		if !ok {
+			slog.Debug("unknown banner filter", "filter", k)
+			return nil, errors.NewDomainError(errors.ErrInvalidInput, "unknown filter %q", k)
+		}
+		q.Write("\n\t\t\tAND " + fmt.Sprintf(condition, q.Arg(dto.Filters[k])))
 	}
 
-	query = strings.TrimSuffix(query, "AND")
-
-	// if tagOK {
-	// 	query = fmt.Sprintf(
-	// 		`%s
-	// 		WHERE tag_id = %d
-	// 			`,
-	// 		query, tagID,
-	// 	)
-	// }
-
-	// query += "GROUP BY bt.banner_id, feature_id"
-
-	// if featureOK {
-	// 	query = fmt.Sprintf(
-	// 		`%s
-	// 		HAVING feature_id = %d
-	// 		`,
-	// 		query, featureID,
-	// 	)
-	// }
-
-	query = fmt.Sprintf(
-		`%s
-		LIMIT %d
-		OFFSET %d)
-		GROUP BY
-			bt.banner_id,
-			feature_id;`,
-		query, dto.Limit, dto.Offset,
-	)
+	if dto.FeatureIDs != nil {
+		q.Write("\n\t\t\tAND bf.feature_id = ANY(" + q.Arg(dto.FeatureIDs) + ")")
+	}
+
+	q.Write("\n\t\tORDER BY b.id")
+	q.Write("\n\t\tLIMIT " + q.Arg(dto.Limit))
+	q.Write("\n\t\tOFFSET " + q.Arg(dto.Offset) + ";")
 
-	slog.Debug("query", "filters", dto.Filters, "query", query)
+	slog.Debug("query", "filters", dto.Filters, "query", q.String())
 
-	rows, err := tx.Query(ctx, query)
+	rows, err := s.client.Query(ctx, q.String(), q.Args()...)
 	if err != nil {
-		slog.Error("error getting filtered ids from db",
+		slog.Error("error getting filtered banners from db",
 			"error", err,
 		)
 		return nil, errors.NewDomainError(errors.ErrDB, "")
 	}
 
-	bannerIndexes := make(map[int64]int)
-	bannerSlice := make([]entity.Banner, 0)
-	index := 0
-
-	bannerIDs, err := pgx.CollectRows[string](rows, func(row pgx.CollectableRow) (string, error) {
+	banners, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Banner, error) {
 		var banner entity.Banner
-		var stringTags pgtype.FlatArray[int64]
-		err := rows.Scan(&banner.BannerID, &banner.FeatureID, &stringTags)
-		if err != nil {
-			slog.Error("error scanning row",
-				"error", err,
-			)
-			return "", errors.NewDomainError(errors.ErrDB, "")
-		}
-
-		banner.TagIDs = stringTags
-
-		bannerSlice = append(bannerSlice, banner)
-		bannerIndexes[banner.BannerID] = index
-		index++
-
-		return strconv.FormatInt(banner.BannerID, 10), nil
+		err := row.Scan(
+			&banner.BannerID, &banner.FeatureID, &banner.TagIDs,
+			&banner.Content.Title, &banner.Content.Text, &banner.Content.URL, &banner.IsActive,
+			&banner.StartsAt, &banner.EndsAt, &banner.Version,
+			&banner.CreatedAt, &banner.UpdatedAt,
+		)
+		return banner, err
 	})
 	if err != nil {
 		slog.Error("error collecting rows",
@@ -300,382 +308,755 @@ func (s *bannerStorage) GetBanners(ctx context.Context, dto entity.GetBannersDTO
 		return nil, errors.NewDomainError(errors.ErrDB, "")
 	}
 
-	query = fmt.Sprintf(
-		`SELECT id, title, text, url
-		FROM banners
-		WHERE id IN (%s);`,
-		strings.Join(bannerIDs, ", "),
-	)
+	return banners, nil
+}
 
-	rows, err = tx.Query(ctx, query)
-	if err != nil {
-		slog.Error("error selecting banners content from db",
-			"error", err,
-		)
-		return nil, errors.NewDomainError(errors.ErrDB, "")
-	}
+func (s *bannerStorage) UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) (int64, error) {
 
-	for rows.Next() {
-		var (
-			id    int64
-			title string
-			text  string
-			url   string
-		)
-		err := rows.Scan(&id, &title, &text, &url)
+	var version int64
+	err := inTx(ctx, s.client, func(tx pgx.Tx) error {
+		err := lockBannerVersion(ctx, tx, dto.BannerID, dto.ExpectedVersion)
+		if err != nil {
+			return err
+		}
+
+		err = saveBannerVersion(ctx, tx, dto.BannerID)
 		if err != nil {
-			slog.Error("error scanning rows",
+			return err
+		}
+
+		current, err := selectBanner(ctx, tx, dto.BannerID)
+		if err != nil {
+			return err
+		}
+
+		if dto.TagIDs != nil || dto.FeatureID != nil {
+			featureID, tagIDs := current.FeatureID, current.TagIDs
+			if dto.FeatureID != nil {
+				featureID = *dto.FeatureID
+			}
+			if dto.TagIDs != nil {
+				tagIDs = dto.TagIDs
+			}
+
+			slog.Debug("tags", "slice", tagIDs)
+
+			err = reserveFeatureTags(ctx, tx, dto.BannerID, featureID, tagIDs)
+			if err != nil {
+				return err
+			}
+		}
+
+		if dto.TagIDs != nil {
+			err = replaceBannerTags(ctx, tx, dto.BannerID, dto.TagIDs)
+			if err != nil {
+				return err
+			}
+		}
+
+		if dto.FeatureID != nil {
+			err = updateBannerFeature(ctx, tx, dto.BannerID, *dto.FeatureID)
+			if err != nil {
+				return err
+			}
+		}
+
+		var q queryBuilder
+		q.Write("UPDATE banners SET updated_at = NOW(), version = version + 1")
+
+		if dto.Content != nil {
+			content, err := current.Content.MergePatch(dto.Content)
+			if err != nil {
+				slog.Debug("error applying content patch", "error", err)
+				return errors.NewDomainError(errors.ErrInvalidInput, "invalid content patch: %s", err)
+			}
+			if !content.Valid() {
+				return errors.NewDomainError(errors.ErrInvalidInput, "content must have title, text and url")
+			}
+			q.Write(", title = " + q.Arg(content.Title))
+			q.Write(", text = " + q.Arg(content.Text))
+			q.Write(", url = " + q.Arg(content.URL))
+		}
+
+		if dto.IsActive != nil {
+			q.Write(", is_active = " + q.Arg(*dto.IsActive))
+		}
+
+		if dto.StartsAt.Set {
+			q.Write(", starts_at = " + q.Arg(dto.StartsAt.Time))
+		}
+
+		if dto.EndsAt.Set {
+			q.Write(", ends_at = " + q.Arg(dto.EndsAt.Time))
+		}
+
+		q.Write(" WHERE id = " + q.Arg(dto.BannerID) + " RETURNING version;")
+
+		err = tx.QueryRow(ctx, q.String(), q.Args()...).Scan(&version)
+		if err != nil {
+			slog.Error("error updating banners",
 				"error", err,
 			)
-			return nil, errors.NewDomainError(errors.ErrDB, "")
+			if isScheduleViolation(err) {
+				return errors.NewDomainError(errors.ErrInvalidInput, "starts_at must be before ends_at")
+			}
+			return dbError(err)
 		}
 
-		bannerSlice[bannerIndexes[id]].Content.Title = title
-		bannerSlice[bannerIndexes[id]].Content.Text = text
-		bannerSlice[bannerIndexes[id]].Content.URL = url
+		after, err := selectBanner(ctx, tx, dto.BannerID)
+		if err != nil {
+			return err
+		}
 
+		return insertAuditRecord(ctx, tx, dto.BannerID, bannerChange{
+			action:    entity.AuditUpdate,
+			principal: dto.Principal,
+			requestID: dto.RequestID,
+			before:    &current,
+			after:     &after,
+		})
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	if err := rows.Err(); err != nil {
-		slog.Error("error scanning rows",
-			"error", err,
-		)
-		return nil, errors.NewDomainError(errors.ErrDB, "")
-	}
+	return version, nil
+}
 
-	err = tx.Commit(ctx)
+// lockBannerVersion locks the banner row for the rest of the transaction and
+// checks that it is still at the expected version, if one is given.
+func lockBannerVersion(ctx context.Context, tx pgx.Tx, bannerID int64, expectedVersion *int64) error {
+
+	row := tx.QueryRow(
+		ctx,
+		`SELECT version
+		FROM banners
+		WHERE id = $1
+		FOR UPDATE;`,
+		bannerID,
+	)
+
+	var version int64
+	err := row.Scan(&version)
 	if err != nil {
-		slog.Error("error commiting transaction",
+		slog.Error("error scanning row",
 			"error", err,
 		)
-		return nil, errors.NewDomainError(errors.ErrDB, "")
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return errors.NewDomainError(errors.ErrNoDataFound, "")
+		}
+		return dbError(err)
+	}
+
+	if expectedVersion != nil && *expectedVersion != version {
+		slog.Debug("banner version mismatch", "banner_id", bannerID, "expected", *expectedVersion, "actual", version)
+		return errors.NewDomainError(errors.ErrPreconditionFailed, "current version is %d", version)
 	}
 
-	return bannerSlice, nil
+	return nil
+}
+
+func (s *bannerStorage) GetBanner(ctx context.Context, dto entity.GetBannerDTO) (entity.Banner, error) {
+	return selectBanner(ctx, s.client, dto.BannerID)
 }
 
-func (s *bannerStorage) UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) error {
+// selectBanner reads the current state of the banner.
+func selectBanner(ctx context.Context, q querier, bannerID int64) (entity.Banner, error) {
 
-	tx, err := s.client.Begin(ctx)
+	row := q.QueryRow(
+		ctx,
+		`SELECT
+			b.id, COALESCE(bf.feature_id, 0),
+			ARRAY(SELECT tag_id FROM banner_tag WHERE banner_id = b.id ORDER BY tag_id),
+			b.title, b.text, b.url, b.is_active, b.starts_at, b.ends_at, b.version,
+			b.created_at, COALESCE(b.updated_at, b.created_at)
+		FROM banners b
+			LEFT JOIN banner_feature bf ON bf.banner_id = b.id
+		WHERE b.id = $1;`,
+		bannerID,
+	)
+
+	var banner entity.Banner
+	err := row.Scan(
+		&banner.BannerID, &banner.FeatureID, &banner.TagIDs,
+		&banner.Content.Title, &banner.Content.Text, &banner.Content.URL, &banner.IsActive,
+		&banner.StartsAt, &banner.EndsAt, &banner.Version,
+		&banner.CreatedAt, &banner.UpdatedAt,
+	)
 	if err != nil {
-		slog.Error("error beginnig transaction",
+		// unknown IDs come from clients, including the anonymous ones
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			slog.Debug("banner not found", "banner_id", bannerID)
+			return entity.Banner{}, errors.NewDomainError(errors.ErrNoDataFound, "")
+		}
+		slog.Error("error scanning row",
 			"error", err,
 		)
-		return errors.NewDomainError(errors.ErrDB, "")
+		return entity.Banner{}, dbError(err)
 	}
-	defer tx.Rollback(ctx)
 
-	unique, err := isUnique(ctx, tx, dto.TagIDs, dto.FeatureID)
+	return banner, nil
+}
+
+func (s *bannerStorage) CreateBanner(ctx context.Context, dto entity.CreateBannerDTO) (int64, error) {
+
+	var bannerID int64
+	err := inTx(ctx, s.client, func(tx pgx.Tx) error {
+		var err error
+		bannerID, err = insertBanner(ctx, tx, dto)
+		return err
+	})
 	if err != nil {
-		return errors.NewDomainError(errors.ErrDB, "")
+		return 0, err
 	}
 
-	if !unique {
-		slog.Debug("update params violate unique constraint")
-		return errors.NewDomainError(errors.ErrAlreadyExists, "banner with these tags and feature already exists")
-	}
+	return bannerID, nil
+}
 
-	query := fmt.Sprintf(
-		`DELETE FROM banner_tag
-		WHERE banner_id = %d;`,
-		dto.BannerID,
-	)
+// insertBanner creates the banner with its tags and feature inside tx.
+func insertBanner(ctx context.Context, tx pgx.Tx, dto entity.CreateBannerDTO) (int64, error) {
 
-	c, err := tx.Exec(ctx, query)
+	var bannerID int64
+	row := tx.QueryRow(
+		ctx,
+		`INSERT INTO
+			banners ("title", "text", "url", "is_active", "starts_at", "ends_at", "created_at")
+		VALUES
+			($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING id;`,
+		dto.Content.Title, dto.Content.Text, dto.Content.URL, dto.IsActive, dto.StartsAt, dto.EndsAt,
+	)
 
+	err := row.Scan(&bannerID)
 	if err != nil {
-		slog.Error("error updating banner_tag",
+		slog.Error("error scanning from row",
 			"error", err,
 		)
-		return errors.NewDomainError(errors.ErrDB, "")
-	}
-	if c.RowsAffected() == 0 {
-		slog.Error("error updating banner_tag, id not found")
-		return errors.NewDomainError(errors.ErrNoDataFound, "")
+		if isScheduleViolation(err) {
+			return 0, errors.NewDomainError(errors.ErrInvalidInput, "starts_at must be before ends_at")
+		}
+		return 0, dbError(err)
 	}
 
-	slog.Debug("tags", "slice", dto.TagIDs)
-
-	tagsString := ""
-	for _, tagID := range dto.TagIDs {
-		tagsString += fmt.Sprintf("(%d, %d),", dto.BannerID, tagID)
+	err = reserveFeatureTags(ctx, tx, bannerID, dto.FeatureID, dto.TagIDs)
+	if err != nil {
+		return 0, err
 	}
-	tagsString = strings.TrimSuffix(tagsString, ",")
 
-	query = fmt.Sprintf(
-		`INSERT INTO
-				banner_tag ("banner_id", "tag_id")
-			VALUES
-				%s;`,
-		tagsString,
-	)
-
-	slog.Info(query)
-
-	_, err = tx.Exec(ctx, query)
+	err = insertBannerTags(ctx, tx, bannerID, dto.TagIDs)
 	if err != nil {
 		slog.Error("error inserting in banner_tag",
 			"error", err,
 		)
 		var pgErr *pgconn.PgError
 		if stdErrors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
-			return errors.NewDomainError(errors.ErrTagNotFound, "")
+			return 0, errors.NewDomainError(errors.ErrTagNotFound, "")
 		}
-		return errors.NewDomainError(errors.ErrDB, "")
+		return 0, dbError(err)
 	}
 
-	query = fmt.Sprintf(
-		`UPDATE banner_feature
-			SET feature_id = %d
-			WHERE banner_id = %d;`,
-		dto.FeatureID, dto.BannerID,
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO
+			banner_feature ("banner_id", "feature_id")
+		VALUES
+			($1, $2);`,
+		bannerID, dto.FeatureID,
 	)
-
-	_, err = s.client.Exec(ctx, query)
 	if err != nil {
-		slog.Error("error updating banner_feature",
+		slog.Error("error inserting in banner_feature",
 			"error", err,
 		)
 		var pgErr *pgconn.PgError
 		if stdErrors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
-			return errors.NewDomainError(errors.ErrTagNotFound, "")
+			return 0, errors.NewDomainError(errors.ErrFeatureNotFound, "")
 		}
-		return errors.NewDomainError(errors.ErrDB, "")
+		return 0, dbError(err)
 	}
 
-	query = fmt.Sprintf(
-		`UPDATE banners
-			SET title = '%s'
-			WHERE id = %d;`,
-		dto.Content.Title, dto.BannerID,
+	after, err := selectBanner(ctx, tx, bannerID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertAuditRecord(ctx, tx, bannerID, bannerChange{
+		action:    entity.AuditCreate,
+		principal: dto.Principal,
+		requestID: dto.RequestID,
+		after:     &after,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return bannerID, nil
+}
+
+// errImportRejected rolls back an import that has invalid rows.
+var errImportRejected = errors.NewDomainError(errors.ErrInvalidInput, "import rejected")
+
+// ImportBanners creates the banners of all rows in one transaction. Each row
+// runs under its own savepoint, so that one invalid row does not hide the
+// problems of the rows after it. If any row fails, nothing is created and the
+// problems are returned in the result.
+func (s *bannerStorage) ImportBanners(ctx context.Context, dto entity.ImportBannersDTO) (entity.ImportResult, error) {
+
+	var result entity.ImportResult
+	err := inTx(ctx, s.client, func(tx pgx.Tx) error {
+		result = entity.ImportResult{BannerIDs: make([]int64, 0, len(dto.Rows))}
+
+		for _, row := range dto.Rows {
+			savepoint, err := tx.Begin(ctx)
+			if err != nil {
+				slog.Error("error creating savepoint",
+					"error", err,
+				)
+				return dbError(err)
+			}
+
+			bannerID, err := insertBanner(ctx, savepoint, row.Banner)
+			if err != nil {
+				savepoint.Rollback(ctx)
+				if errors.Code(err) == errors.ErrDB {
+					return err
+				}
+				result.Errors = append(result.Errors, entity.ImportError{Line: row.Line, Error: err.Error()})
+				continue
+			}
+
+			err = savepoint.Commit(ctx)
+			if err != nil {
+				slog.Error("error releasing savepoint",
+					"error", err,
+				)
+				return dbError(err)
+			}
+
+			result.BannerIDs = append(result.BannerIDs, bannerID)
+		}
+
+		if len(result.Errors) > 0 {
+			result.BannerIDs = nil
+			return errImportRejected
+		}
+
+		return nil
+	})
+	if err != nil && !stdErrors.Is(err, errImportRejected) {
+		return entity.ImportResult{}, err
+	}
+
+	return result, nil
+}
+
+// ExportBanners calls fn for every banner, in the order of their IDs, while
+// reading them from the database. It stops at the first error fn returns.
+func (s *bannerStorage) ExportBanners(ctx context.Context, dto entity.ExportBannersDTO, fn func(entity.Banner) error) error {
+
+	var q queryBuilder
+	q.Write(
+		`SELECT
+			b.id, bf.feature_id,
+			ARRAY(SELECT tag_id FROM banner_tag WHERE banner_id = b.id ORDER BY tag_id),
+			b.title, b.text, b.url, b.is_active, b.starts_at, b.ends_at, b.version,
+			b.created_at, COALESCE(b.updated_at, b.created_at)
+		FROM banners b
+			JOIN banner_feature bf ON bf.banner_id = b.id`,
 	)
+	if dto.FeatureIDs != nil {
+		q.Write("\n\t\tWHERE bf.feature_id = ANY(" + q.Arg(dto.FeatureIDs) + ")")
+	}
+	q.Write("\n\t\tORDER BY b.id;")
 
-	_, err = s.client.Exec(ctx, query)
+	rows, err := s.client.Query(ctx, q.String(), q.Args()...)
 	if err != nil {
-		slog.Error("error updating banners",
+		slog.Error("error selecting banners to export",
 			"error", err,
 		)
 		return errors.NewDomainError(errors.ErrDB, "")
 	}
+	defer rows.Close()
 
-	query = fmt.Sprintf(
-		`UPDATE banners
-			SET text = '%s'
-			WHERE id = %d;`,
-		dto.Content.Text, dto.BannerID,
-	)
+	for rows.Next() {
+		var banner entity.Banner
+		err := rows.Scan(
+			&banner.BannerID, &banner.FeatureID, &banner.TagIDs,
+			&banner.Content.Title, &banner.Content.Text, &banner.Content.URL, &banner.IsActive,
+			&banner.StartsAt, &banner.EndsAt, &banner.Version,
+			&banner.CreatedAt, &banner.UpdatedAt,
+		)
+		if err != nil {
+			slog.Error("error scanning row",
+				"error", err,
+			)
+			return errors.NewDomainError(errors.ErrDB, "")
+		}
 
-	_, err = s.client.Exec(ctx, query)
+		err = fn(banner)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = rows.Err()
 	if err != nil {
-		slog.Error("error updating banners",
+		slog.Error("error reading banners to export",
 			"error", err,
 		)
 		return errors.NewDomainError(errors.ErrDB, "")
 	}
 
-	query = fmt.Sprintf(
-		`UPDATE banners
-			SET url = '%s'
-			WHERE id = %d;`,
-		dto.Content.URL, dto.BannerID,
+	return nil
+}
+
+func (s *bannerStorage) GetBannerVersions(ctx context.Context, dto entity.GetBannerVersionsDTO) ([]entity.BannerVersion, error) {
+
+	row := s.client.QueryRow(
+		ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM banners WHERE id = $1
+		);`,
+		dto.BannerID,
 	)
 
-	_, err = s.client.Exec(ctx, query)
+	var exists bool
+	err := row.Scan(&exists)
 	if err != nil {
-		slog.Error("error updating banners",
+		slog.Error("error scanning row",
 			"error", err,
 		)
-		return errors.NewDomainError(errors.ErrDB, "")
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+	if !exists {
+		return nil, errors.NewDomainError(errors.ErrNoDataFound, "")
 	}
 
-	query = fmt.Sprintf(
-		`UPDATE banners
-		SET is_active = %t, updated_at = NOW()
-		WHERE id = %d;`,
-		dto.IsActive, dto.BannerID,
+	rows, err := s.client.Query(
+		ctx,
+		`SELECT
+			version, tag_ids, COALESCE(feature_id, 0),
+			title, text, url, is_active, starts_at, ends_at, created_at
+		FROM banner_versions
+		WHERE banner_id = $1
+		ORDER BY version DESC;`,
+		dto.BannerID,
 	)
-
-	_, err = s.client.Exec(ctx, query)
 	if err != nil {
-		slog.Error("error updating banners",
+		slog.Error("error selecting from banner_versions",
 			"error", err,
 		)
-		return errors.NewDomainError(errors.ErrDB, "")
+		return nil, errors.NewDomainError(errors.ErrDB, "")
 	}
 
-	err = tx.Commit(ctx)
+	versions, err := pgx.CollectRows[entity.BannerVersion](rows, func(row pgx.CollectableRow) (entity.BannerVersion, error) {
+		var v entity.BannerVersion
+		err := row.Scan(
+			&v.Version, &v.TagIDs, &v.FeatureID,
+			&v.Content.Title, &v.Content.Text, &v.Content.URL, &v.IsActive,
+			&v.StartsAt, &v.EndsAt, &v.CreatedAt,
+		)
+		return v, err
+	})
 	if err != nil {
-		slog.Error("error commiting transaction",
+		slog.Error("error collecting rows",
 			"error", err,
 		)
-		return errors.NewDomainError(errors.ErrDB, "")
+		return nil, errors.NewDomainError(errors.ErrDB, "")
 	}
 
-	return nil
-
+	return versions, nil
 }
 
-func (s *bannerStorage) CreateBanner(ctx context.Context, dto entity.CreateBannerDTO) (int64, error) {
+func (s *bannerStorage) RestoreBanner(ctx context.Context, dto entity.RestoreBannerDTO) (int64, error) {
 
-	tx, err := s.client.Begin(ctx)
-	if err != nil {
-		slog.Error("error beginnig transaction",
-			"error", err,
+	var version int64
+	err := inTx(ctx, s.client, func(tx pgx.Tx) error {
+		err := lockBannerVersion(ctx, tx, dto.BannerID, dto.ExpectedVersion)
+		if err != nil {
+			return err
+		}
+
+		row := tx.QueryRow(
+			ctx,
+			`SELECT tag_ids, COALESCE(feature_id, 0), title, text, url, is_active, starts_at, ends_at
+			FROM banner_versions
+			WHERE banner_id = $1 AND version = $2;`,
+			dto.BannerID, dto.Version,
 		)
-		return 0, errors.NewDomainError(errors.ErrDB, "")
-	}
-	defer tx.Rollback(ctx)
 
-	unique, err := isUnique(ctx, tx, dto.TagIDs, dto.FeatureID)
-	if err != nil {
-		return 0, errors.NewDomainError(errors.ErrDB, "")
-	}
+		var v entity.BannerVersion
+		err = row.Scan(
+			&v.TagIDs, &v.FeatureID,
+			&v.Content.Title, &v.Content.Text, &v.Content.URL, &v.IsActive,
+			&v.StartsAt, &v.EndsAt,
+		)
+		if err != nil {
+			slog.Error("error scanning row",
+				"error", err,
+			)
+			if stdErrors.Is(err, pgx.ErrNoRows) {
+				return errors.NewDomainError(errors.ErrNoDataFound, "banner version not found")
+			}
+			return dbError(err)
+		}
 
-	if !unique {
-		return 0, errors.NewDomainError(errors.ErrAlreadyExists, "banner with these tags and feature already exists")
-	}
+		before, err := selectBanner(ctx, tx, dto.BannerID)
+		if err != nil {
+			return err
+		}
 
-	query := fmt.Sprintf(
-		`INSERT INTO
-			banners ("title", "text", "url", "is_active", "created_at")
-		VALUES
-			('%s', '%s', '%s', %t, NOW())
-		RETURNING id;`,
-		dto.Content.Title, dto.Content.Text, dto.Content.URL, dto.IsActive,
-	)
+		err = saveBannerVersion(ctx, tx, dto.BannerID)
+		if err != nil {
+			return err
+		}
 
-	row := s.client.QueryRow(ctx, query)
+		err = reserveFeatureTags(ctx, tx, dto.BannerID, v.FeatureID, v.TagIDs)
+		if err != nil {
+			return err
+		}
 
-	var bannerID int64
-	err = row.Scan(&bannerID)
-	if err != nil {
-		slog.Error("error scanning from row",
-			"error", err,
+		err = replaceBannerTags(ctx, tx, dto.BannerID, v.TagIDs)
+		if err != nil {
+			return err
+		}
+
+		err = updateBannerFeature(ctx, tx, dto.BannerID, v.FeatureID)
+		if err != nil {
+			return err
+		}
+
+		row = tx.QueryRow(
+			ctx,
+			`UPDATE banners
+			SET
+				title = $1, text = $2, url = $3, is_active = $4, starts_at = $5, ends_at = $6,
+				updated_at = NOW(), version = version + 1
+			WHERE id = $7
+			RETURNING version;`,
+			v.Content.Title, v.Content.Text, v.Content.URL, v.IsActive, v.StartsAt, v.EndsAt, dto.BannerID,
 		)
-		return 0, errors.NewDomainError(errors.ErrDB, "")
-	}
 
-	tagsString := ""
-	for _, tagID := range dto.TagIDs {
-		tagsString += fmt.Sprintf("(%d, %d),", bannerID, tagID)
+		err = row.Scan(&version)
+		if err != nil {
+			slog.Error("error updating banners",
+				"error", err,
+			)
+			return dbError(err)
+		}
+
+		after, err := selectBanner(ctx, tx, dto.BannerID)
+		if err != nil {
+			return err
+		}
+
+		return insertAuditRecord(ctx, tx, dto.BannerID, bannerChange{
+			action:    entity.AuditRestore,
+			principal: dto.Principal,
+			requestID: dto.RequestID,
+			before:    &before,
+			after:     &after,
+		})
+	})
+	if err != nil {
+		return 0, err
 	}
-	tagsString = strings.TrimSuffix(tagsString, ",")
 
-	query = fmt.Sprintf(
-		`INSERT INTO
-			banner_tag ("banner_id", "tag_id")
-		VALUES
-			%s;`,
-		tagsString,
+	return version, nil
+}
+
+func replaceBannerTags(ctx context.Context, tx pgx.Tx, bannerID int64, tagIDs []int64) error {
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM banner_tag
+		WHERE banner_id = $1;`,
+		bannerID,
 	)
+	if err != nil {
+		slog.Error("error deleting from banner_tag",
+			"error", err,
+		)
+		return dbError(err)
+	}
 
-	_, err = s.client.Exec(ctx, query)
+	err = insertBannerTags(ctx, tx, bannerID, tagIDs)
 	if err != nil {
 		slog.Error("error inserting in banner_tag",
 			"error", err,
 		)
 		var pgErr *pgconn.PgError
 		if stdErrors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
-			return 0, errors.NewDomainError(errors.ErrTagNotFound, "")
+			return errors.NewDomainError(errors.ErrTagNotFound, "")
 		}
-		return 0, errors.NewDomainError(errors.ErrDB, "")
+		return dbError(err)
 	}
 
-	query = fmt.Sprintf(
-		`INSERT INTO
-			banner_feature ("banner_id", "feature_id")
-		VALUES
-			(%d, %d);`,
-		bannerID, dto.FeatureID,
-	)
+	return nil
+}
+
+func updateBannerFeature(ctx context.Context, tx pgx.Tx, bannerID int64, featureID int64) error {
 
-	_, err = s.client.Exec(ctx, query)
+	_, err := tx.Exec(
+		ctx,
+		`UPDATE banner_feature
+		SET feature_id = $1
+		WHERE banner_id = $2;`,
+		featureID, bannerID,
+	)
 	if err != nil {
-		slog.Error("error inserting in banner_feature",
+		slog.Error("error updating banner_feature",
 			"error", err,
 		)
 		var pgErr *pgconn.PgError
 		if stdErrors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
-			return 0, errors.NewDomainError(errors.ErrFeatureNotFound, "")
+			return errors.NewDomainError(errors.ErrFeatureNotFound, "")
 		}
-		return 0, errors.NewDomainError(errors.ErrDB, "")
+		return dbError(err)
 	}
 
-	err = tx.Commit(ctx)
+	return nil
+}
+
+// saveBannerVersion copies the current state of the banner (content, tags,
+// feature, active flag and schedule) into banner_versions. It must be called inside
+// the transaction that is about to change the banner.
+func saveBannerVersion(ctx context.Context, tx pgx.Tx, bannerID int64) error {
+
+	c, err := tx.Exec(
+		ctx,
+		`INSERT INTO banner_versions
+			(banner_id, version, title, text, url, is_active, starts_at, ends_at, feature_id, tag_ids, created_at)
+		SELECT
+			b.id, b.version,
+			b.title, b.text, b.url, b.is_active, b.starts_at, b.ends_at,
+			(SELECT feature_id FROM banner_feature WHERE banner_id = b.id),
+			ARRAY(SELECT tag_id FROM banner_tag WHERE banner_id = b.id ORDER BY tag_id),
+			NOW()
+		FROM banners b
+		WHERE b.id = $1
+		FOR UPDATE OF b;`,
+		bannerID,
+	)
 	if err != nil {
-		slog.Error("error commiting transaction",
+		slog.Error("error inserting in banner_versions",
 			"error", err,
 		)
-		return 0, errors.NewDomainError(errors.ErrDB, "")
+		return dbError(err)
+	}
+	if c.RowsAffected() == 0 {
+		slog.Error("error saving banner version, id not found")
+		return errors.NewDomainError(errors.ErrNoDataFound, "")
 	}
 
-	return bannerID, nil
+	return nil
 }
 
-func isUnique(ctx context.Context, tx pgx.Tx, tagsID []int64, featureID int64) (bool, error) {
-	tagsString := strings.Trim(strings.ReplaceAll(fmt.Sprint(tagsID), " ", ", "), "[]")
-	slog.Debug("tags string", "string", tagsString)
-
-	query := fmt.Sprintf(
-		`SELECT banner_id
-		FROM banner_tag
-		WHERE banner_id NOT IN(
-			SELECT banner_id FROM banner_tag bt
-			WHERE bt.tag_id NOT IN(%s)
-			GROUP BY banner_id
-		)
-		GROUP BY banner_id
-		HAVING COUNT(tag_id) = %d;`,
-		tagsString, len(tagsID),
-	)
+// reserveFeatureTags makes the banner the owner of every (feature, tag) pair
+// it is shown for, replacing the pairs it held before. The unique index on
+// banner_feature_tag rejects pairs owned by another banner, even if that
+// banner is being written by a concurrent transaction.
+func reserveFeatureTags(ctx context.Context, tx pgx.Tx, bannerID int64, featureID int64, tagIDs []int64) error {
 
-	rows, err := tx.Query(ctx, query)
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM banner_feature_tag
+		WHERE banner_id = $1;`,
+		bannerID,
+	)
 	if err != nil {
-		slog.Error("error selecting from db",
+		slog.Error("error deleting from banner_feature_tag",
 			"error", err,
 		)
-		return false, errors.NewDomainError(errors.ErrDB, "")
+		return dbError(err)
 	}
 
-	bannerIDs, err := pgx.CollectRows[string](rows, func(row pgx.CollectableRow) (string, error) {
-		var id string
-		err := row.Scan(&id)
-		return id, err
-	})
-
-	if len(bannerIDs) == 0 {
-		return true, nil
+	rows, err := tx.Query(
+		ctx,
+		`INSERT INTO banner_feature_tag (banner_id, feature_id, tag_id)
+		SELECT DISTINCT $1::bigint, $2::bigint, UNNEST($3::bigint[])
+		ON CONFLICT (feature_id, tag_id) DO NOTHING
+		RETURNING tag_id;`,
+		bannerID, featureID, tagIDs,
+	)
+	if err != nil {
+		slog.Error("error inserting in banner_feature_tag",
+			"error", err,
+		)
+		return featureTagError(err)
 	}
 
+	reserved, err := pgx.CollectRows(rows, pgx.RowTo[int64])
 	if err != nil {
 		slog.Error("error collecting rows",
 			"error", err,
 		)
-		return false, errors.NewDomainError(errors.ErrDB, "")
+		return featureTagError(err)
 	}
 
-	query = fmt.Sprintf(
-		`SELECT CASE WHEN EXISTS (
-			SELECT *
-			FROM banner_feature
-			WHERE banner_id IN (%s) AND feature_id = %d
-		)
-		THEN FALSE
-		ELSE TRUE END;`,
-		strings.Join(bannerIDs, ","), featureID,
-	)
-
-	row := tx.QueryRow(ctx, query)
+	if len(reserved) == len(uniqueIDs(tagIDs)) {
+		return nil
+	}
 
-	var unique bool
-	err = row.Scan(&unique)
+	row := tx.QueryRow(
+		ctx,
+		`SELECT banner_id, tag_id
+		FROM banner_feature_tag
+		WHERE feature_id = $1 AND tag_id = ANY($2) AND banner_id <> $3
+		ORDER BY tag_id
+		LIMIT 1;`,
+		featureID, tagIDs, bannerID,
+	)
 
+	var conflictID, tagID int64
+	err = row.Scan(&conflictID, &tagID)
 	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			// the pair was released after the insert skipped it, so the
+			// transaction is retried to take it
+			slog.Debug("conflicting feature and tag released, retrying", "banner_id", bannerID, "feature_id", featureID)
+			return errTxConflict
+		}
 		slog.Error("error scanning row",
 			"error", err,
 		)
-		return false, errors.NewDomainError(errors.ErrDB, "")
+		return dbError(err)
+	}
+
+	slog.Debug("feature and tag are already taken", "banner_id", conflictID, "feature_id", featureID, "tag_id", tagID)
+	return errors.NewDomainError(
+		errors.ErrAlreadyExists,
+		"banner %d already has feature %d and tag %d", conflictID, featureID, tagID,
+	)
+}
+
+// featureTagError tells which of the feature and the tags of a pair does not
+// exist, if that is why the pair could not be reserved.
+func featureTagError(err error) error {
+	var pgErr *pgconn.PgError
+	if stdErrors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+		switch pgErr.ConstraintName {
+		case "banner_feature_tag_feature_id_fkey":
+			return errors.NewDomainError(errors.ErrFeatureNotFound, "")
+		case "banner_feature_tag_tag_id_fkey":
+			return errors.NewDomainError(errors.ErrTagNotFound, "")
+		}
+	}
+	return dbError(err)
+}
+
+func uniqueIDs(ids []int64) map[int64]struct{} {
+	set := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
 	}
+	return set
+}
 
-	return unique, nil
+// isScheduleViolation reports whether err was caused by a banner whose
+// starts_at is not before its ends_at.
+func isScheduleViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return stdErrors.As(err, &pgErr) &&
+		pgErr.Code == pgerrcode.CheckViolation &&
+		pgErr.ConstraintName == "banners_schedule_check"
 }
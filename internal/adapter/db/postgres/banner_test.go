@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	stdErrors "errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// errRow is a row that fails to scan with err.
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(dest ...any) error {
+	return r.err
+}
+
+// reserveTx reserves none of the pairs, as if all were taken, and answers
+// the lookup of the banner taking them with row.
+type reserveTx struct {
+	fakeTx
+
+	row pgx.Row
+}
+
+func (tx *reserveTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *reserveTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+func (tx *reserveTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return tx.row
+}
+
+func Test_reserveFeatureTags_Released(t *testing.T) {
+	// the banner taking the pair is deleted between the insert and the lookup
+	tx := &reserveTx{row: errRow{err: pgx.ErrNoRows}}
+
+	err := reserveFeatureTags(context.Background(), tx, 1, 1, []int64{1})
+	require.True(t, stdErrors.Is(err, errTxConflict))
+}
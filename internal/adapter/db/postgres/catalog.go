@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	stdErrors "errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/jackc/pgx/v5"
+)
+
+const catalogColumns = `id, name, description, owner_team, created_at, updated_at`
+
+// catalogEntry is a row of the tags or the features table, which have the
+// same columns. It converts to both entity.Tag and entity.Feature.
+type catalogEntry struct {
+	ID          int64
+	Name        string
+	Description string
+	OwnerTeam   string
+	CreatedAt   time.Time
+	UpdatedAt   *time.Time
+}
+
+func scanCatalogEntry(row pgx.Row) (catalogEntry, error) {
+	var entry catalogEntry
+	err := row.Scan(&entry.ID, &entry.Name, &entry.Description, &entry.OwnerTeam, &entry.CreatedAt, &entry.UpdatedAt)
+	return entry, err
+}
+
+// catalog runs the queries that tags and features share against one of their
+// tables. noun names a row of the table in errors.
+type catalog struct {
+	client postgresql.Client
+	table  string
+	noun   string
+}
+
+func (c catalog) create(ctx context.Context, name, description, ownerTeam string) (catalogEntry, error) {
+
+	row := c.client.QueryRow(
+		ctx,
+		`INSERT INTO
+			`+c.table+` (name, description, owner_team, created_at)
+		VALUES
+			($1, $2, $3, NOW())
+		RETURNING `+catalogColumns+`;`,
+		name, description, ownerTeam,
+	)
+
+	entry, err := scanCatalogEntry(row)
+	if err != nil {
+		slog.Error("error inserting in "+c.table,
+			"error", err,
+		)
+		if isUniqueViolation(err) {
+			return catalogEntry{}, errors.NewDomainError(errors.ErrAlreadyExists, "%s %q already exists", c.noun, name)
+		}
+		return catalogEntry{}, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	return entry, nil
+}
+
+func (c catalog) list(ctx context.Context, ownerTeam string, limit, offset int) ([]catalogEntry, error) {
+
+	rows, err := c.client.Query(
+		ctx,
+		`SELECT `+catalogColumns+`
+		FROM `+c.table+`
+		WHERE $1 = '' OR owner_team = $1
+		ORDER BY id
+		LIMIT $2
+		OFFSET $3;`,
+		ownerTeam, limit, offset,
+	)
+	if err != nil {
+		slog.Error("error selecting from "+c.table,
+			"error", err,
+		)
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (catalogEntry, error) {
+		return scanCatalogEntry(row)
+	})
+	if err != nil {
+		slog.Error("error collecting rows",
+			"error", err,
+		)
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	return entries, nil
+}
+
+// update sets the non-nil fields of the row.
+func (c catalog) update(ctx context.Context, id int64, name, description, ownerTeam *string) (catalogEntry, error) {
+
+	var q queryBuilder
+	sets := make([]string, 0, 3)
+
+	if name != nil {
+		sets = append(sets, "name = "+q.Arg(*name))
+	}
+	if description != nil {
+		sets = append(sets, "description = "+q.Arg(*description))
+	}
+	if ownerTeam != nil {
+		sets = append(sets, "owner_team = "+q.Arg(*ownerTeam))
+	}
+
+	if len(sets) == 0 {
+		return catalogEntry{}, errors.NewDomainError(errors.ErrInvalidInput, "nothing to update")
+	}
+
+	q.Write("UPDATE " + c.table + " SET updated_at = NOW(), " + strings.Join(sets, ", "))
+	q.Write(" WHERE id = " + q.Arg(id))
+	q.Write(" RETURNING " + catalogColumns + ";")
+
+	entry, err := scanCatalogEntry(c.client.QueryRow(ctx, q.String(), q.Args()...))
+	if err != nil {
+		slog.Error("error updating "+c.table,
+			"error", err,
+		)
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return catalogEntry{}, errors.NewDomainError(errors.ErrNoDataFound, "")
+		}
+		if isUniqueViolation(err) {
+			return catalogEntry{}, errors.NewDomainError(errors.ErrAlreadyExists, "%s %q already exists", c.noun, *name)
+		}
+		return catalogEntry{}, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	return entry, nil
+}
+
+// lockRow locks the row for the rest of the transaction.
+func (c catalog) lockRow(ctx context.Context, tx pgx.Tx, id int64) error {
+
+	var lockedID int64
+	err := tx.QueryRow(ctx, `SELECT id FROM `+c.table+` WHERE id = $1 FOR UPDATE;`, id).Scan(&lockedID)
+	if err != nil {
+		slog.Error("error scanning row",
+			"error", err,
+		)
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return errors.NewDomainError(errors.ErrNoDataFound, "")
+		}
+		return dbError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	stdErrors "errors"
+	"log/slog"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/service"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ service.DeleteJobStorage = new(deleteJobStorage)
+
+type deleteJobStorage struct {
+	client postgresql.Client
+}
+
+func NewDeleteJobStorage(client postgresql.Client) *deleteJobStorage {
+	return &deleteJobStorage{client: client}
+}
+
+const deleteJobColumns = `id, COALESCE(feature_id, 0), COALESCE(tag_id, 0), status, deleted,
+	COALESCE(error, ''), created_at, finished_at,
+	COALESCE(token_id, 0), COALESCE(subject, ''), COALESCE(request_id, ''), lease`
+
+func scanDeleteJob(row pgx.Row) (entity.DeleteJob, error) {
+	var job entity.DeleteJob
+	err := row.Scan(
+		&job.ID, &job.FeatureID, &job.TagID, &job.Status, &job.Deleted,
+		&job.Error, &job.CreatedAt, &job.FinishedAt,
+		&job.Principal.TokenID, &job.Principal.Subject, &job.RequestID, &job.Lease,
+	)
+	return job, err
+}
+
+func (s *deleteJobStorage) CreateDeleteJob(ctx context.Context, dto entity.DeleteBannersDTO) (entity.DeleteJob, error) {
+
+	row := s.client.QueryRow(
+		ctx,
+		`INSERT INTO delete_jobs (feature_id, tag_id, status, token_id, subject, request_id, created_at)
+		VALUES (NULLIF($1, 0), NULLIF($2, 0), $3, NULLIF($4, 0), NULLIF($5, ''), NULLIF($6, ''), NOW())
+		RETURNING `+deleteJobColumns+`;`,
+		dto.FeatureID, dto.TagID, entity.DeleteJobPending,
+		dto.Principal.TokenID, dto.Principal.Subject, dto.RequestID,
+	)
+
+	job, err := scanDeleteJob(row)
+	if err != nil {
+		slog.Error("error inserting in delete_jobs",
+			"error", err,
+		)
+		return entity.DeleteJob{}, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	return job, nil
+}
+
+func (s *deleteJobStorage) GetDeleteJob(ctx context.Context, dto entity.GetDeleteJobDTO) (entity.DeleteJob, error) {
+
+	row := s.client.QueryRow(
+		ctx,
+		`SELECT `+deleteJobColumns+`
+		FROM delete_jobs
+		WHERE id = $1;`,
+		dto.JobID,
+	)
+
+	job, err := scanDeleteJob(row)
+	if err != nil {
+		slog.Error("error scanning row",
+			"error", err,
+		)
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return entity.DeleteJob{}, errors.NewDomainError(errors.ErrNoDataFound, "")
+		}
+		return entity.DeleteJob{}, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	return job, nil
+}
+
+// ClaimDeleteJob moves a pending job to running, or takes over a running job
+// whose heartbeat is older than lease, and starts a new lease of the job. It
+// returns ErrNoDataFound if the job does not exist or is held by another
+// worker.
+func (s *deleteJobStorage) ClaimDeleteJob(ctx context.Context, jobID int64, lease time.Duration) (entity.DeleteJob, error) {
+
+	row := s.client.QueryRow(
+		ctx,
+		`UPDATE delete_jobs
+		SET status = $1, heartbeat_at = NOW(), lease = lease + 1
+		WHERE id = $2 AND (
+			status = $3
+			OR status = $1 AND COALESCE(heartbeat_at, '-infinity') < NOW() - make_interval(secs => $4)
+		)
+		RETURNING `+deleteJobColumns+`;`,
+		entity.DeleteJobRunning, jobID, entity.DeleteJobPending, lease.Seconds(),
+	)
+
+	job, err := scanDeleteJob(row)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return entity.DeleteJob{}, errors.NewDomainError(errors.ErrNoDataFound, "")
+		}
+		slog.Error("error claiming delete job",
+			"error", err,
+		)
+		return entity.DeleteJob{}, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	return job, nil
+}
+
+// GetPendingDeleteJobs returns the jobs ClaimDeleteJob would claim: pending
+// ones and running ones whose heartbeat is older than lease.
+func (s *deleteJobStorage) GetPendingDeleteJobs(ctx context.Context, lease time.Duration) ([]int64, error) {
+
+	rows, err := s.client.Query(
+		ctx,
+		`SELECT id
+		FROM delete_jobs
+		WHERE status = $1
+			OR status = $2 AND COALESCE(heartbeat_at, '-infinity') < NOW() - make_interval(secs => $3)
+		ORDER BY id;`,
+		entity.DeleteJobPending, entity.DeleteJobRunning, lease.Seconds(),
+	)
+	if err != nil {
+		slog.Error("error selecting from delete_jobs",
+			"error", err,
+		)
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		slog.Error("error collecting rows",
+			"error", err,
+		)
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	return ids, nil
+}
+
+// HeartbeatDeleteJob extends the lease of a running job. It returns
+// ErrNoDataFound if the job has been taken over by another worker or is no
+// longer running.
+func (s *deleteJobStorage) HeartbeatDeleteJob(ctx context.Context, job entity.DeleteJob) error {
+
+	tag, err := s.client.Exec(
+		ctx,
+		`UPDATE delete_jobs
+		SET heartbeat_at = NOW()
+		WHERE id = $1 AND lease = $2 AND status = $3;`,
+		job.ID, job.Lease, entity.DeleteJobRunning,
+	)
+	if err != nil {
+		slog.Error("error updating delete_jobs",
+			"error", err,
+		)
+		return errors.NewDomainError(errors.ErrDB, "")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.NewDomainError(errors.ErrNoDataFound, "")
+	}
+
+	return nil
+}
+
+// UpdateDeleteJob saves the progress of the job. It returns ErrNoDataFound if
+// the job has been taken over by another worker.
+func (s *deleteJobStorage) UpdateDeleteJob(ctx context.Context, job entity.DeleteJob) error {
+
+	tag, err := s.client.Exec(
+		ctx,
+		`UPDATE delete_jobs
+		SET status = $1, deleted = $2, error = NULLIF($3, ''), finished_at = $4, heartbeat_at = NOW()
+		WHERE id = $5 AND lease = $6;`,
+		job.Status, job.Deleted, job.Error, job.FinishedAt, job.ID, job.Lease,
+	)
+	if err != nil {
+		slog.Error("error updating delete_jobs",
+			"error", err,
+		)
+		return errors.NewDomainError(errors.ErrDB, "")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.NewDomainError(errors.ErrNoDataFound, "")
+	}
+
+	return nil
+}
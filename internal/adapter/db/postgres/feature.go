@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/service"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ service.FeatureStorage = new(featureStorage)
+
+type featureStorage struct {
+	client  postgresql.Client
+	catalog catalog
+}
+
+func NewFeatureStorage(client postgresql.Client) *featureStorage {
+	return &featureStorage{
+		client:  client,
+		catalog: catalog{client: client, table: "features", noun: "feature"},
+	}
+}
+
+func (s *featureStorage) CreateFeature(ctx context.Context, dto entity.CreateFeatureDTO) (entity.Feature, error) {
+	entry, err := s.catalog.create(ctx, dto.Name, dto.Description, dto.OwnerTeam)
+	return entity.Feature(entry), err
+}
+
+func (s *featureStorage) GetFeatures(ctx context.Context, dto entity.GetFeaturesDTO) ([]entity.Feature, error) {
+	entries, err := s.catalog.list(ctx, dto.OwnerTeam, dto.Limit, dto.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	features := make([]entity.Feature, 0, len(entries))
+	for _, entry := range entries {
+		features = append(features, entity.Feature(entry))
+	}
+
+	return features, nil
+}
+
+func (s *featureStorage) UpdateFeature(ctx context.Context, dto entity.UpdateFeatureDTO) (entity.Feature, error) {
+	entry, err := s.catalog.update(ctx, dto.FeatureID, dto.Name, dto.Description, dto.OwnerTeam)
+	return entity.Feature(entry), err
+}
+
+// DeleteFeature deletes the feature and returns the IDs of the banners that
+// were deleted with it. Each of those banners gets an audit record.
+func (s *featureStorage) DeleteFeature(ctx context.Context, dto entity.DeleteFeatureDTO) ([]int64, error) {
+
+	var bannerIDs []int64
+	err := inTx(ctx, s.client, func(tx pgx.Tx) error {
+		// the lock keeps banners from picking the feature up while it is deleted
+		err := s.catalog.lockRow(ctx, tx, dto.FeatureID)
+		if err != nil {
+			return err
+		}
+
+		err = checkFeatureTokens(ctx, tx, dto.FeatureID)
+		if err != nil {
+			return err
+		}
+
+		befores, err := lockBannersUsing(
+			ctx, tx,
+			`SELECT id
+			FROM banners b
+			WHERE EXISTS (
+				SELECT 1 FROM banner_feature bf
+				WHERE bf.banner_id = b.id AND bf.feature_id = $1
+			)
+			ORDER BY id
+			FOR UPDATE;`,
+			dto.FeatureID,
+		)
+		if err != nil {
+			return err
+		}
+		if len(befores) > 0 && !dto.Cascade {
+			return errors.NewDomainError(errors.ErrInUse, "feature is used by %d banners", len(befores))
+		}
+
+		bannerIDs = make([]int64, 0, len(befores))
+		for _, before := range befores {
+			bannerIDs = append(bannerIDs, before.BannerID)
+		}
+
+		_, err = deleteBanners(ctx, tx, bannerIDs)
+		if err != nil {
+			return err
+		}
+
+		for i := range befores {
+			err = insertAuditRecord(ctx, tx, befores[i].BannerID, bannerChange{
+				action:    entity.AuditDelete,
+				principal: dto.Principal,
+				requestID: dto.RequestID,
+				before:    &befores[i],
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`DELETE FROM features WHERE id = $1;`,
+			dto.FeatureID,
+		)
+		if err != nil {
+			slog.Error("error deleting from features",
+				"error", err,
+			)
+			return dbError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bannerIDs, nil
+}
+
+// checkFeatureTokens returns ErrInUse if tokens are scoped to the feature, as
+// a token scoped to nothing but the feature would lose its limit with it.
+// Revoked tokens cannot be used again, so they are unscoped from the feature
+// instead.
+func checkFeatureTokens(ctx context.Context, tx pgx.Tx, featureID int64) error {
+
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM token_features tf
+		USING tokens t
+		WHERE tf.token_id = t.id
+			AND tf.feature_id = $1
+			AND t.revoked_at IS NOT NULL;`,
+		featureID,
+	)
+	if err != nil {
+		slog.Error("error deleting from token_features",
+			"error", err,
+		)
+		return dbError(err)
+	}
+
+	var tokens int
+	err = tx.QueryRow(
+		ctx,
+		`SELECT COUNT(*) FROM token_features WHERE feature_id = $1;`,
+		featureID,
+	).Scan(&tokens)
+	if err != nil {
+		slog.Error("error counting token_features",
+			"error", err,
+		)
+		return dbError(err)
+	}
+	if tokens > 0 {
+		return errors.NewDomainError(errors.ErrInUse, "feature is used by %d tokens", tokens)
+	}
+
+	return nil
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS banner_versions CASCADE;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE "banner_versions" (
+  "id" bigserial PRIMARY KEY,
+  "banner_id" bigint NOT NULL,
+  "version" bigint NOT NULL,
+  "title" varchar,
+  "text" text,
+  "url" varchar,
+  "is_active" boolean,
+  "feature_id" bigint,
+  "tag_ids" bigint[],
+  "created_at" timestamp,
+  UNIQUE ("banner_id", "version")
+);
+
+ALTER TABLE "banner_versions" ADD FOREIGN KEY ("banner_id") REFERENCES "banners" ("id") ON DELETE CASCADE;
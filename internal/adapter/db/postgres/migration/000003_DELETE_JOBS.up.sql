@@ -0,0 +1,12 @@
+CREATE TABLE "delete_jobs" (
+  "id" bigserial PRIMARY KEY,
+  "feature_id" bigint,
+  "tag_id" bigint,
+  "status" varchar NOT NULL,
+  "deleted" bigint NOT NULL DEFAULT 0,
+  "error" text,
+  "created_at" timestamp,
+  "finished_at" timestamp
+);
+
+CREATE INDEX ON "delete_jobs" ("status");
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS banner_feature_tag CASCADE;
\ No newline at end of file
@@ -0,0 +1,39 @@
+-- One row per (feature, tag) pair a banner is shown for. The unique index
+-- enforces that a pair resolves to at most one banner. Existing banners that
+-- share a pair must be fixed before this migration can be applied; the check
+-- below lists them.
+DO $$
+DECLARE
+  conflicts text;
+BEGIN
+  SELECT string_agg(format('feature %s, tag %s: banners %s', feature_id, tag_id, banner_ids), '; ')
+  INTO conflicts
+  FROM (
+    SELECT bf.feature_id, bt.tag_id, string_agg(bt.banner_id::text, ', ' ORDER BY bt.banner_id) AS banner_ids
+    FROM banner_tag bt
+      JOIN banner_feature bf ON bf.banner_id = bt.banner_id
+    GROUP BY bf.feature_id, bt.tag_id
+    HAVING COUNT(*) > 1
+  ) pairs;
+
+  IF conflicts IS NOT NULL THEN
+    RAISE EXCEPTION 'banners share feature and tag pairs: %', conflicts
+      USING HINT = 'Move the banners to distinct pairs or delete them, then apply the migration again.';
+  END IF;
+END;
+$$;
+
+CREATE TABLE "banner_feature_tag" (
+  "banner_id" bigint NOT NULL,
+  "feature_id" bigint NOT NULL,
+  "tag_id" bigint NOT NULL,
+  PRIMARY KEY ("banner_id", "tag_id"),
+  UNIQUE ("feature_id", "tag_id")
+);
+
+ALTER TABLE "banner_feature_tag" ADD FOREIGN KEY ("banner_id") REFERENCES "banners" ("id") ON DELETE CASCADE;
+
+INSERT INTO banner_feature_tag (banner_id, feature_id, tag_id)
+SELECT bt.banner_id, bf.feature_id, bt.tag_id
+FROM banner_tag bt
+  JOIN banner_feature bf ON bf.banner_id = bt.banner_id;
@@ -0,0 +1,6 @@
+ALTER TABLE "banners" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+
+-- banner_versions keeps the states that were replaced, so the current state
+-- of a banner is one version ahead of its latest archived one.
+UPDATE banners b
+SET version = COALESCE((SELECT MAX(v.version) FROM banner_versions v WHERE v.banner_id = b.id), 0) + 1;
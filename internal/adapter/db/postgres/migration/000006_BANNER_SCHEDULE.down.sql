@@ -0,0 +1,2 @@
+ALTER TABLE "banner_versions" DROP COLUMN IF EXISTS "starts_at", DROP COLUMN IF EXISTS "ends_at";
+ALTER TABLE "banners" DROP COLUMN IF EXISTS "starts_at", DROP COLUMN IF EXISTS "ends_at";
@@ -0,0 +1,8 @@
+ALTER TABLE "banners"
+  ADD COLUMN "starts_at" timestamptz,
+  ADD COLUMN "ends_at" timestamptz,
+  ADD CONSTRAINT "banners_schedule_check" CHECK ("starts_at" < "ends_at");
+
+ALTER TABLE "banner_versions"
+  ADD COLUMN "starts_at" timestamptz,
+  ADD COLUMN "ends_at" timestamptz;
@@ -0,0 +1,9 @@
+CREATE TABLE "banner_stats" (
+  "banner_id" bigint NOT NULL,
+  "day" date NOT NULL,
+  "impressions" bigint NOT NULL DEFAULT 0,
+  "clicks" bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY ("banner_id", "day")
+);
+
+ALTER TABLE "banner_stats" ADD FOREIGN KEY ("banner_id") REFERENCES "banners" ("id") ON DELETE CASCADE;
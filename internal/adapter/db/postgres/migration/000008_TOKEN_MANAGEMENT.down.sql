@@ -0,0 +1,5 @@
+ALTER TABLE "tokens"
+  DROP COLUMN IF EXISTS "label",
+  DROP COLUMN IF EXISTS "expires_at",
+  DROP COLUMN IF EXISTS "revoked_at",
+  DROP COLUMN IF EXISTS "last_used_at";
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE "tokens"
+  ADD COLUMN "label" varchar NOT NULL DEFAULT '',
+  ADD COLUMN "expires_at" timestamptz,
+  ADD COLUMN "revoked_at" timestamptz,
+  ADD COLUMN "last_used_at" timestamptz;
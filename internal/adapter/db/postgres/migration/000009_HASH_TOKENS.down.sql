@@ -0,0 +1,8 @@
+-- the plain text tokens cannot be recovered, they have to be issued again
+DELETE FROM "tokens";
+
+ALTER TABLE "tokens"
+  ADD COLUMN "token" varchar UNIQUE,
+  DROP COLUMN IF EXISTS "prefix",
+  DROP COLUMN IF EXISTS "salt",
+  DROP COLUMN IF EXISTS "digest";
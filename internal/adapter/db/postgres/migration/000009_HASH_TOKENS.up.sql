@@ -0,0 +1,22 @@
+ALTER TABLE "tokens"
+  ADD COLUMN "prefix" varchar NOT NULL DEFAULT '',
+  ADD COLUMN "salt" bytea,
+  ADD COLUMN "digest" bytea;
+
+DELETE FROM "tokens" WHERE "token" IS NULL;
+
+-- must match tokenPrefix and hashToken in token.go
+UPDATE "tokens"
+SET
+  "prefix" = CASE WHEN length("token") >= 32 THEN left("token", 8) ELSE '' END,
+  "salt" = uuid_send(gen_random_uuid());
+
+UPDATE "tokens"
+SET "digest" = sha256("salt" || convert_to("token", 'UTF8'));
+
+ALTER TABLE "tokens"
+  ALTER COLUMN "salt" SET NOT NULL,
+  ALTER COLUMN "digest" SET NOT NULL,
+  DROP COLUMN "token";
+
+CREATE INDEX ON "tokens" ("prefix");
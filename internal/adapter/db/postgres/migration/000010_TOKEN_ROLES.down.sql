@@ -0,0 +1,5 @@
+ALTER TABLE "tokens" ADD COLUMN "is_admin" boolean;
+
+UPDATE "tokens" SET "is_admin" = ("role" = 'admin');
+
+ALTER TABLE "tokens" DROP COLUMN IF EXISTS "role";
@@ -0,0 +1,7 @@
+ALTER TABLE "tokens"
+  ADD COLUMN "role" varchar NOT NULL DEFAULT 'user'
+  CHECK ("role" IN ('user', 'viewer', 'editor', 'publisher', 'admin'));
+
+UPDATE "tokens" SET "role" = 'admin' WHERE "is_admin";
+
+ALTER TABLE "tokens" DROP COLUMN "is_admin";
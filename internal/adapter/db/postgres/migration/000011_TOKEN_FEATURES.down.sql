@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "token_features";
\ No newline at end of file
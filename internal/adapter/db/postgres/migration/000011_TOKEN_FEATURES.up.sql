@@ -0,0 +1,9 @@
+CREATE TABLE "token_features" (
+  "token_id" bigint NOT NULL,
+  "feature_id" bigint NOT NULL,
+  PRIMARY KEY ("token_id", "feature_id")
+);
+
+ALTER TABLE "token_features" ADD FOREIGN KEY ("token_id") REFERENCES "tokens" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "token_features" ADD FOREIGN KEY ("feature_id") REFERENCES "features" ("id") ON DELETE CASCADE;
@@ -0,0 +1,31 @@
+CREATE TABLE "audit_log" (
+  "id" bigserial PRIMARY KEY,
+  "banner_id" bigint NOT NULL,
+  "action" varchar NOT NULL,
+  "actor" varchar NOT NULL,
+  "token_id" bigint,
+  "request_id" varchar,
+  "before" jsonb,
+  "after" jsonb,
+  "created_at" timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ON "audit_log" ("banner_id", "created_at");
+
+CREATE INDEX ON "audit_log" ("actor", "created_at");
+
+CREATE INDEX ON "audit_log" ("created_at");
+
+CREATE FUNCTION "audit_log_append_only"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_log_no_change"
+  BEFORE UPDATE OR DELETE ON "audit_log"
+  FOR EACH ROW EXECUTE FUNCTION "audit_log_append_only"();
+
+CREATE TRIGGER "audit_log_no_truncate"
+  BEFORE TRUNCATE ON "audit_log"
+  FOR EACH STATEMENT EXECUTE FUNCTION "audit_log_append_only"();
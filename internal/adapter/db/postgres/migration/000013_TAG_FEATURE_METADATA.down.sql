@@ -0,0 +1,13 @@
+ALTER TABLE "tags"
+  DROP COLUMN IF EXISTS "name",
+  DROP COLUMN IF EXISTS "description",
+  DROP COLUMN IF EXISTS "owner_team",
+  DROP COLUMN IF EXISTS "created_at",
+  DROP COLUMN IF EXISTS "updated_at";
+
+ALTER TABLE "features"
+  DROP COLUMN IF EXISTS "name",
+  DROP COLUMN IF EXISTS "description",
+  DROP COLUMN IF EXISTS "owner_team",
+  DROP COLUMN IF EXISTS "created_at",
+  DROP COLUMN IF EXISTS "updated_at";
\ No newline at end of file
@@ -0,0 +1,29 @@
+ALTER TABLE "tags"
+  ADD COLUMN "name" varchar NOT NULL DEFAULT '',
+  ADD COLUMN "description" text NOT NULL DEFAULT '',
+  ADD COLUMN "owner_team" varchar NOT NULL DEFAULT '',
+  ADD COLUMN "created_at" timestamptz NOT NULL DEFAULT NOW(),
+  ADD COLUMN "updated_at" timestamptz;
+
+ALTER TABLE "features"
+  ADD COLUMN "name" varchar NOT NULL DEFAULT '',
+  ADD COLUMN "description" text NOT NULL DEFAULT '',
+  ADD COLUMN "owner_team" varchar NOT NULL DEFAULT '',
+  ADD COLUMN "created_at" timestamptz NOT NULL DEFAULT NOW(),
+  ADD COLUMN "updated_at" timestamptz;
+
+-- Rows that were inserted by hand have no name yet, only named ones must be
+-- unique.
+CREATE UNIQUE INDEX ON "tags" ("name") WHERE "name" <> '';
+
+CREATE UNIQUE INDEX ON "features" ("name") WHERE "name" <> '';
+
+CREATE INDEX ON "tags" ("owner_team");
+
+CREATE INDEX ON "features" ("owner_team");
+
+-- Rows inserted by hand came with explicit IDs, so the sequences may lag
+-- behind them.
+SELECT setval(pg_get_serial_sequence('tags', 'id'), COALESCE(MAX("id"), 0) + 1, false) FROM "tags";
+
+SELECT setval(pg_get_serial_sequence('features', 'id'), COALESCE(MAX("id"), 0) + 1, false) FROM "features";
@@ -0,0 +1,4 @@
+ALTER TABLE "delete_jobs"
+  DROP COLUMN IF EXISTS "token_id",
+  DROP COLUMN IF EXISTS "subject",
+  DROP COLUMN IF EXISTS "request_id";
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- The job deletes banners on behalf of whoever created it, so the audit
+-- records it writes need to name them.
+ALTER TABLE "delete_jobs"
+  ADD COLUMN "token_id" bigint,
+  ADD COLUMN "subject" varchar,
+  ADD COLUMN "request_id" varchar;
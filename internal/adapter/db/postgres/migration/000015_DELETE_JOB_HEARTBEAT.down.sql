@@ -0,0 +1 @@
+ALTER TABLE "delete_jobs" DROP COLUMN IF EXISTS "heartbeat_at";
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- A running job whose heartbeat is older than the lease was left by a worker
+-- that died, and is claimed again.
+ALTER TABLE "delete_jobs" ADD COLUMN "heartbeat_at" timestamptz;
@@ -0,0 +1,3 @@
+ALTER TABLE "banner_feature_tag"
+  DROP CONSTRAINT IF EXISTS "banner_feature_tag_feature_id_fkey",
+  DROP CONSTRAINT IF EXISTS "banner_feature_tag_tag_id_fkey";
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- Pairs of deleted tags and features go away with them, like the pairs of
+-- deleted banners.
+ALTER TABLE "banner_feature_tag" ADD FOREIGN KEY ("feature_id") REFERENCES "features" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "banner_feature_tag" ADD FOREIGN KEY ("tag_id") REFERENCES "tags" ("id") ON DELETE CASCADE;
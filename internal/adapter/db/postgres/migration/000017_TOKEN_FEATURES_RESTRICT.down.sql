@@ -0,0 +1,3 @@
+ALTER TABLE "token_features"
+  DROP CONSTRAINT IF EXISTS "token_features_feature_id_fkey",
+  ADD CONSTRAINT "token_features_feature_id_fkey" FOREIGN KEY ("feature_id") REFERENCES "features" ("id") ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+-- A token scoped to nothing but a deleted feature would lose its limit, so
+-- features that tokens are scoped to cannot be deleted.
+ALTER TABLE "token_features"
+  DROP CONSTRAINT IF EXISTS "token_features_feature_id_fkey",
+  ADD CONSTRAINT "token_features_feature_id_fkey" FOREIGN KEY ("feature_id") REFERENCES "features" ("id") ON DELETE RESTRICT;
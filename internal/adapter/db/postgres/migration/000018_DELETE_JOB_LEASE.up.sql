@@ -0,0 +1,3 @@
+-- Every claim of a job starts a new lease. A worker whose job was taken over
+-- holds an older lease, which keeps it from writing to the job.
+ALTER TABLE "delete_jobs" ADD COLUMN "lease" bigint NOT NULL DEFAULT 0;
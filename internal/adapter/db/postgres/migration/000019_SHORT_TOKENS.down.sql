@@ -0,0 +1,6 @@
+UPDATE "tokens"
+SET "expires_at" = "legacy_expires_at"
+WHERE "prefix" = '';
+
+ALTER TABLE "tokens"
+  DROP COLUMN IF EXISTS "legacy_expires_at";
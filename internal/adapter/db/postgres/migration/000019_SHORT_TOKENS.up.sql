@@ -0,0 +1,12 @@
+-- Tokens shorter than 32 characters have no prefix to be looked up by. They
+-- keep working for 90 days (legacyTokenWindow in token.go) and a warning is
+-- logged whenever one is used, so that they can be issued again with a
+-- longer secret meanwhile. The expiry they had is kept to undo this.
+ALTER TABLE "tokens"
+  ADD COLUMN "legacy_expires_at" timestamptz;
+
+UPDATE "tokens"
+SET
+  "legacy_expires_at" = "expires_at",
+  "expires_at" = LEAST(COALESCE("expires_at", 'infinity'), NOW() + INTERVAL '90 days')
+WHERE "prefix" = '';
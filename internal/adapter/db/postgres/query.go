@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// queryBuilder assembles a statement with $n placeholders. Values never end
+// up in the SQL text, only in the argument list.
+type queryBuilder struct {
+	sql  strings.Builder
+	args []any
+}
+
+// Write appends raw SQL. It must only be given trusted text.
+func (b *queryBuilder) Write(sql string) *queryBuilder {
+	b.sql.WriteString(sql)
+	return b
+}
+
+// Arg stores v as the next argument and returns its placeholder.
+func (b *queryBuilder) Arg(v any) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *queryBuilder) String() string {
+	return b.sql.String()
+}
+
+func (b *queryBuilder) Args() []any {
+	return b.args
+}
+
+// querier is implemented by both the pool and pgx.Tx.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// copier is implemented by both the pool and pgx.Tx.
+type copier interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+func insertBannerTags(ctx context.Context, c copier, bannerID int64, tagIDs []int64) error {
+	_, err := c.CopyFrom(
+		ctx,
+		pgx.Identifier{"banner_tag"},
+		[]string{"banner_id", "tag_id"},
+		pgx.CopyFromSlice(len(tagIDs), func(i int) ([]any, error) {
+			return []any{bannerID, tagIDs[i]}, nil
+		}),
+	)
+	return err
+}
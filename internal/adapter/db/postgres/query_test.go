@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_queryBuilder(t *testing.T) {
+	tests := []struct {
+		name string
+		// build writes the statement
+		build    func(q *queryBuilder)
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name: "positive, no arguments",
+			build: func(q *queryBuilder) {
+				q.Write("SELECT 1;")
+			},
+			wantSQL: "SELECT 1;",
+		},
+		{
+			name: "positive, placeholders numbered in order",
+			build: func(q *queryBuilder) {
+				q.Write("UPDATE banners SET title = " + q.Arg("title"))
+				q.Write(", is_active = " + q.Arg(true))
+				q.Write(" WHERE id = " + q.Arg(int64(3)) + ";")
+			},
+			wantSQL:  "UPDATE banners SET title = $1, is_active = $2 WHERE id = $3;",
+			wantArgs: []any{"title", true, int64(3)},
+		},
+		{
+			name: "positive, placeholders past nine",
+			build: func(q *queryBuilder) {
+				q.Write("SELECT")
+				for i := 1; i <= 10; i++ {
+					q.Write(" " + q.Arg(i))
+				}
+			},
+			wantSQL:  "SELECT $1 $2 $3 $4 $5 $6 $7 $8 $9 $10",
+			wantArgs: []any{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+		{
+			name: "positive, quote kept out of the SQL",
+			build: func(q *queryBuilder) {
+				q.Write("UPDATE banners SET title = " + q.Arg("Don't miss") + ";")
+			},
+			wantSQL:  "UPDATE banners SET title = $1;",
+			wantArgs: []any{"Don't miss"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q queryBuilder
+			tt.build(&q)
+
+			require.Equal(t, tt.wantSQL, q.String())
+			require.Equal(t, tt.wantArgs, q.Args())
+		})
+	}
+}
+
+// queryClient records the statements queried through it and answers them
+// with no rows.
+type queryClient struct {
+	postgresql.Client
+
+	sql  []string
+	args [][]any
+}
+
+func (c *queryClient) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	c.sql = append(c.sql, sql)
+	c.args = append(c.args, args)
+	return &fakeRows{}, nil
+}
+
+func Test_bannerStorage_GetBanners_Filters(t *testing.T) {
+	tests := []struct {
+		name     string
+		dto      entity.GetBannersDTO
+		wantSQL  []string
+		wantArgs []any
+		wantErr  errors.ErrorCode
+	}{
+		{
+			name:     "positive, no filters",
+			dto:      entity.GetBannersDTO{Limit: 10},
+			wantSQL:  []string{"LIMIT $1", "OFFSET $2;"},
+			wantArgs: []any{10, 0},
+		},
+		{
+			name: "positive, filters numbered in key order",
+			dto: entity.GetBannersDTO{
+				Filters: map[string]int64{
+					entity.BannerFilterTag:     2,
+					entity.BannerFilterFeature: 1,
+				},
+				FeatureIDs: []int64{1},
+				Limit:      10,
+				Offset:     5,
+			},
+			wantSQL: []string{
+				"AND bf.feature_id = $1",
+				"bt.tag_id = $2)",
+				"AND bf.feature_id = ANY($3)",
+				"LIMIT $4",
+				"OFFSET $5;",
+			},
+			wantArgs: []any{int64(1), int64(2), []int64{1}, 10, 5},
+		},
+		{
+			name: "negative, unknown filter",
+			dto: entity.GetBannersDTO{
+				Filters: map[string]int64{"id = 1 OR TRUE; --": 1},
+				Limit:   10,
+			},
+			wantErr: errors.ErrInvalidInput,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &queryClient{}
+			storage := NewBannerStorage(client)
+
+			_, err := storage.GetBanners(context.Background(), tt.dto)
+			if tt.wantErr != "" {
+				require.Equal(t, tt.wantErr, errors.Code(err))
+				require.Empty(t, client.sql)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, client.sql, 1)
+
+			sql := client.sql[0]
+			for _, part := range tt.wantSQL {
+				require.Contains(t, sql, part)
+			}
+			// every argument has a placeholder and no more
+			require.Equal(t, len(tt.wantArgs), strings.Count(sql, "$"))
+			require.Equal(t, tt.wantArgs, client.args[0])
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/service"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ service.StatsStorage = new(statsStorage)
+
+type statsStorage struct {
+	client postgresql.Client
+}
+
+func NewStatsStorage(client postgresql.Client) *statsStorage {
+	return &statsStorage{client: client}
+}
+
+// AddBannerStats adds the counters to the stored ones. Counters of banners
+// that were deleted in the meantime are dropped.
+func (s *statsStorage) AddBannerStats(ctx context.Context, stats []entity.BannerStats) error {
+
+	bannerIDs := make([]int64, len(stats))
+	days := make([]time.Time, len(stats))
+	impressions := make([]int64, len(stats))
+	clicks := make([]int64, len(stats))
+	for i, st := range stats {
+		bannerIDs[i] = st.BannerID
+		days[i] = st.Day
+		impressions[i] = st.Impressions
+		clicks[i] = st.Clicks
+	}
+
+	_, err := s.client.Exec(
+		ctx,
+		`INSERT INTO banner_stats (banner_id, day, impressions, clicks)
+		SELECT s.banner_id, s.day, s.impressions, s.clicks
+		FROM UNNEST($1::bigint[], $2::date[], $3::bigint[], $4::bigint[])
+			AS s(banner_id, day, impressions, clicks)
+		WHERE EXISTS (SELECT 1 FROM banners b WHERE b.id = s.banner_id)
+		ON CONFLICT (banner_id, day) DO UPDATE
+		SET
+			impressions = banner_stats.impressions + EXCLUDED.impressions,
+			clicks = banner_stats.clicks + EXCLUDED.clicks;`,
+		bannerIDs, days, impressions, clicks,
+	)
+	if err != nil {
+		slog.Error("error inserting in banner_stats",
+			"error", err,
+		)
+		return errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	return nil
+}
+
+// GetBannerStats returns one entry per day of the range, including the days
+// without any impressions.
+func (s *statsStorage) GetBannerStats(ctx context.Context, dto entity.GetBannerStatsDTO) ([]entity.BannerStats, error) {
+
+	row := s.client.QueryRow(
+		ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM banners WHERE id = $1
+		);`,
+		dto.BannerID,
+	)
+
+	var exists bool
+	err := row.Scan(&exists)
+	if err != nil {
+		slog.Error("error scanning row",
+			"error", err,
+		)
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+	if !exists {
+		return nil, errors.NewDomainError(errors.ErrNoDataFound, "")
+	}
+
+	rows, err := s.client.Query(
+		ctx,
+		`SELECT d::date, COALESCE(s.impressions, 0), COALESCE(s.clicks, 0)
+		FROM generate_series($2::date, $3::date, interval '1 day') d
+			LEFT JOIN banner_stats s ON s.banner_id = $1 AND s.day = d::date
+		ORDER BY d;`,
+		dto.BannerID, dto.From, dto.To,
+	)
+	if err != nil {
+		slog.Error("error selecting from banner_stats",
+			"error", err,
+		)
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	stats, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.BannerStats, error) {
+		st := entity.BannerStats{BannerID: dto.BannerID}
+		err := row.Scan(&st.Day, &st.Impressions, &st.Clicks)
+		return st, err
+	})
+	if err != nil {
+		slog.Error("error collecting rows",
+			"error", err,
+		)
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/service"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ service.TagStorage = new(tagStorage)
+
+type tagStorage struct {
+	client  postgresql.Client
+	catalog catalog
+}
+
+func NewTagStorage(client postgresql.Client) *tagStorage {
+	return &tagStorage{
+		client:  client,
+		catalog: catalog{client: client, table: "tags", noun: "tag"},
+	}
+}
+
+func (s *tagStorage) CreateTag(ctx context.Context, dto entity.CreateTagDTO) (entity.Tag, error) {
+	entry, err := s.catalog.create(ctx, dto.Name, dto.Description, dto.OwnerTeam)
+	return entity.Tag(entry), err
+}
+
+func (s *tagStorage) GetTags(ctx context.Context, dto entity.GetTagsDTO) ([]entity.Tag, error) {
+	entries, err := s.catalog.list(ctx, dto.OwnerTeam, dto.Limit, dto.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]entity.Tag, 0, len(entries))
+	for _, entry := range entries {
+		tags = append(tags, entity.Tag(entry))
+	}
+
+	return tags, nil
+}
+
+func (s *tagStorage) UpdateTag(ctx context.Context, dto entity.UpdateTagDTO) (entity.Tag, error) {
+	entry, err := s.catalog.update(ctx, dto.TagID, dto.Name, dto.Description, dto.OwnerTeam)
+	return entity.Tag(entry), err
+}
+
+// DeleteTag deletes the tag and returns the banners it was removed from, as
+// they are without it, and the IDs of the banners deleted with it. Each of the
+// banners it was removed from gets a new version and an audit record. Banners
+// that have no other tag are deleted, as they could not be served anymore.
+func (s *tagStorage) DeleteTag(ctx context.Context, dto entity.DeleteTagDTO) ([]entity.Banner, []int64, error) {
+
+	var afters []entity.Banner
+	var deletedIDs []int64
+	err := inTx(ctx, s.client, func(tx pgx.Tx) error {
+		// the lock keeps banners from picking the tag up while it is deleted
+		err := s.catalog.lockRow(ctx, tx, dto.TagID)
+		if err != nil {
+			return err
+		}
+
+		befores, err := lockBannersUsing(
+			ctx, tx,
+			`SELECT id
+			FROM banners b
+			WHERE EXISTS (
+				SELECT 1 FROM banner_tag bt
+				WHERE bt.banner_id = b.id AND bt.tag_id = $1
+			)
+			ORDER BY id
+			FOR UPDATE;`,
+			dto.TagID,
+		)
+		if err != nil {
+			return err
+		}
+		if len(befores) > 0 && !dto.Cascade {
+			return errors.NewDomainError(errors.ErrInUse, "tag is used by %d banners", len(befores))
+		}
+
+		// a banner must keep at least one tag to be served
+		var deleted, updated []entity.Banner
+		for _, before := range befores {
+			if len(before.TagIDs) == 1 {
+				deleted = append(deleted, before)
+			} else {
+				updated = append(updated, before)
+			}
+		}
+
+		deletedIDs = make([]int64, 0, len(deleted))
+		for _, before := range deleted {
+			deletedIDs = append(deletedIDs, before.BannerID)
+		}
+
+		_, err = deleteBanners(ctx, tx, deletedIDs)
+		if err != nil {
+			return err
+		}
+
+		for i := range deleted {
+			err = insertAuditRecord(ctx, tx, deleted[i].BannerID, bannerChange{
+				action:    entity.AuditDelete,
+				principal: dto.Principal,
+				requestID: dto.RequestID,
+				before:    &deleted[i],
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		bannerIDs := make([]int64, 0, len(updated))
+		for _, before := range updated {
+			err = saveBannerVersion(ctx, tx, before.BannerID)
+			if err != nil {
+				return err
+			}
+			bannerIDs = append(bannerIDs, before.BannerID)
+		}
+
+		for _, query := range []string{
+			`DELETE FROM banner_tag WHERE tag_id = $1;`,
+			`DELETE FROM banner_feature_tag WHERE tag_id = $1;`,
+		} {
+			_, err = tx.Exec(ctx, query, dto.TagID)
+			if err != nil {
+				slog.Error("error removing tag from banners",
+					"error", err,
+				)
+				return dbError(err)
+			}
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`UPDATE banners
+			SET updated_at = NOW(), version = version + 1
+			WHERE id = ANY($1);`,
+			bannerIDs,
+		)
+		if err != nil {
+			slog.Error("error updating banners",
+				"error", err,
+			)
+			return dbError(err)
+		}
+
+		afters = make([]entity.Banner, 0, len(updated))
+		for i := range updated {
+			after, err := selectBanner(ctx, tx, updated[i].BannerID)
+			if err != nil {
+				return err
+			}
+			afters = append(afters, after)
+
+			err = insertAuditRecord(ctx, tx, updated[i].BannerID, bannerChange{
+				action:    entity.AuditUpdate,
+				principal: dto.Principal,
+				requestID: dto.RequestID,
+				before:    &updated[i],
+				after:     &after,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`DELETE FROM tags WHERE id = $1;`,
+			dto.TagID,
+		)
+		if err != nil {
+			slog.Error("error deleting from tags",
+				"error", err,
+			)
+			return dbError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return afters, deletedIDs, nil
+}
+
+// lockBannersUsing locks the banners whose IDs query selects and returns
+// their current state.
+func lockBannersUsing(ctx context.Context, tx pgx.Tx, query string, id int64) ([]entity.Banner, error) {
+
+	rows, err := tx.Query(ctx, query, id)
+	if err != nil {
+		slog.Error("error selecting banners",
+			"error", err,
+		)
+		return nil, dbError(err)
+	}
+
+	bannerIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		slog.Error("error collecting rows",
+			"error", err,
+		)
+		return nil, dbError(err)
+	}
+
+	banners := make([]entity.Banner, 0, len(bannerIDs))
+	for _, bannerID := range bannerIDs {
+		banner, err := selectBanner(ctx, tx, bannerID)
+		if err != nil {
+			return nil, err
+		}
+		banners = append(banners, banner)
+	}
+
+	return banners, nil
+}
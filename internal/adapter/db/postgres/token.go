@@ -2,13 +2,21 @@ package db
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	stdErrors "errors"
 	"log/slog"
+	"strings"
+	"time"
 
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
 	"github.com/The-Gleb/banner_service/internal/domain/service"
 	"github.com/The-Gleb/banner_service/internal/errors"
 	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 var _ service.TokenStorage = new(tokenStorage)
@@ -21,24 +29,399 @@ func NewTokenStorage(c postgresql.Client) *tokenStorage {
 	return &tokenStorage{c}
 }
 
-func (s *tokenStorage) CheckToken(ctx context.Context, token string) (bool, error) {
-	row := s.client.QueryRow(
+// CheckToken accepts tokens that are neither revoked nor expired. It also
+// records when the token was last used, at most once a minute, so that busy
+// clients do not turn every request into a write. Short legacy tokens are
+// compared against every legacy digest, as they have no prefix.
+func (s *tokenStorage) CheckToken(ctx context.Context, token string) (entity.Principal, error) {
+	prefix, ok := tokenPrefix(token)
+
+	rows, err := s.client.Query(
+		ctx,
+		`SELECT
+			id, salt, digest, role, expires_at, last_used_at,
+			ARRAY(SELECT feature_id FROM token_features WHERE token_id = tokens.id ORDER BY feature_id)
+		FROM tokens
+		WHERE prefix = $1
+			AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW());`,
+		prefix,
+	)
+	if err != nil {
+		slog.Error("error selecting from tokens", "error", err)
+		return entity.Principal{}, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	type candidate struct {
+		id         int64
+		salt       []byte
+		digest     []byte
+		role       entity.Role
+		expiresAt  *time.Time
+		lastUsedAt *time.Time
+		featureIDs []int64
+	}
+	candidates, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (candidate, error) {
+		var c candidate
+		err := row.Scan(&c.id, &c.salt, &c.digest, &c.role, &c.expiresAt, &c.lastUsedAt, &c.featureIDs)
+		return c, err
+	})
+	if err != nil {
+		slog.Error("error collecting rows", "error", err)
+		return entity.Principal{}, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	for _, c := range candidates {
+		if subtle.ConstantTimeCompare(hashToken(c.salt, token), c.digest) != 1 {
+			continue
+		}
+
+		if !ok {
+			slog.Warn("short legacy token used, issue a token of at least 32 characters before it expires",
+				"token_id", c.id, "expires_at", c.expiresAt,
+			)
+		}
+
+		if c.lastUsedAt == nil || time.Since(*c.lastUsedAt) > time.Minute {
+			_, err := s.client.Exec(
+				ctx,
+				`UPDATE tokens SET last_used_at = NOW() WHERE id = $1;`,
+				c.id,
+			)
+			if err != nil {
+				slog.Error("error updating last_used_at of token", "token_id", c.id, "error", err)
+			}
+		}
+
+		return entity.Principal{
+			TokenID:    c.id,
+			Role:       c.role,
+			FeatureIDs: c.featureIDs,
+			ExpiresAt:  c.expiresAt,
+		}, nil
+	}
+
+	slog.Debug("token not found", "prefix", prefix)
+	return entity.Principal{}, errors.NewDomainError(errors.ErrUnauthorized, "")
+}
+
+const tokenColumns = `id, prefix, label, role,
+	ARRAY(SELECT feature_id FROM token_features WHERE token_id = tokens.id ORDER BY feature_id),
+	created_at, expires_at, revoked_at, last_used_at`
+
+func scanToken(row pgx.Row) (entity.Token, error) {
+	var token entity.Token
+	err := row.Scan(
+		&token.ID, &token.Prefix, &token.Label, &token.Role, &token.FeatureIDs, &token.CreatedAt,
+		&token.ExpiresAt, &token.RevokedAt, &token.LastUsedAt,
+	)
+	return token, err
+}
+
+func (s *tokenStorage) CreateToken(ctx context.Context, dto entity.IssueTokenDTO) (entity.Token, error) {
+	prefix, ok := tokenPrefix(dto.Token)
+	if !ok {
+		return entity.Token{}, errShortToken()
+	}
+
+	var token entity.Token
+	err := inTx(ctx, s.client, func(tx pgx.Tx) error {
+		var err error
+		token, err = insertToken(ctx, tx, prefix, dto)
+		if err != nil {
+			slog.Error("error inserting in tokens", "error", err)
+			return dbError(err)
+		}
+
+		if len(dto.FeatureIDs) == 0 {
+			return nil
+		}
+
+		err = setTokenFeatures(ctx, tx, token.ID, dto.FeatureIDs)
+		if err != nil {
+			return err
+		}
+
+		token, err = selectToken(ctx, tx, token.ID)
+		return err
+	})
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	return token, nil
+}
+
+// EnsureToken creates the token unless a token with the same secret exists.
+// A short secret is still accepted, so that a legacy ADMIN_TOKEN does not
+// stop the service from starting, but it expires after legacyTokenWindow.
+func (s *tokenStorage) EnsureToken(ctx context.Context, dto entity.IssueTokenDTO) error {
+	prefix, ok := tokenPrefix(dto.Token)
+	if !ok {
+		slog.Warn("short legacy token ensured, it expires after the deprecation window",
+			"min_length", minTokenLen, "window", legacyTokenWindow,
+		)
+		expiresAt := time.Now().Add(legacyTokenWindow)
+		if dto.ExpiresAt == nil || dto.ExpiresAt.After(expiresAt) {
+			dto.ExpiresAt = &expiresAt
+		}
+	}
+
+	return inTx(ctx, s.client, func(tx pgx.Tx) error {
+		// replicas starting at the same time must not create it twice
+		_, err := tx.Exec(ctx, `LOCK TABLE tokens IN SHARE ROW EXCLUSIVE MODE;`)
+		if err != nil {
+			slog.Error("error locking tokens", "error", err)
+			return dbError(err)
+		}
+
+		rows, err := tx.Query(
+			ctx,
+			`SELECT salt, digest
+			FROM tokens
+			WHERE prefix = $1;`,
+			prefix,
+		)
+		if err != nil {
+			slog.Error("error selecting from tokens", "error", err)
+			return dbError(err)
+		}
+
+		var salt, digest []byte
+		_, err = pgx.ForEachRow(rows, []any{&salt, &digest}, func() error {
+			if subtle.ConstantTimeCompare(hashToken(salt, dto.Token), digest) == 1 {
+				return errTokenExists
+			}
+			return nil
+		})
+		if stdErrors.Is(err, errTokenExists) {
+			return nil
+		}
+		if err != nil {
+			slog.Error("error scanning row", "error", err)
+			return dbError(err)
+		}
+
+		_, err = insertToken(ctx, tx, prefix, dto)
+		if err != nil {
+			slog.Error("error inserting in tokens", "error", err)
+			return dbError(err)
+		}
+
+		return nil
+	})
+}
+
+var errTokenExists = stdErrors.New("token exists")
+
+func insertToken(ctx context.Context, q querier, prefix string, dto entity.IssueTokenDTO) (entity.Token, error) {
+	salt := make([]byte, tokenSaltSize)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	row := q.QueryRow(
+		ctx,
+		`INSERT INTO tokens (prefix, salt, digest, label, role, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING `+tokenColumns+`;`,
+		prefix, salt, hashToken(salt, dto.Token), dto.Label, dto.Role, dto.ExpiresAt,
+	)
+
+	return scanToken(row)
+}
+
+func (s *tokenStorage) GetTokens(ctx context.Context, dto entity.GetTokensDTO) ([]entity.Token, error) {
+	rows, err := s.client.Query(
+		ctx,
+		`SELECT `+tokenColumns+`
+		FROM tokens
+		ORDER BY id
+		LIMIT $1
+		OFFSET $2;`,
+		dto.Limit, dto.Offset,
+	)
+	if err != nil {
+		slog.Error("error selecting from tokens", "error", err)
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	tokens, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Token, error) {
+		return scanToken(row)
+	})
+	if err != nil {
+		slog.Error("error collecting rows", "error", err)
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	return tokens, nil
+}
+
+func (s *tokenStorage) UpdateToken(ctx context.Context, dto entity.UpdateTokenDTO) (entity.Token, error) {
+	var q queryBuilder
+	sets := make([]string, 0, 2)
+
+	if dto.Label != nil {
+		sets = append(sets, "label = "+q.Arg(*dto.Label))
+	}
+	if dto.ExpiresAt.Set {
+		sets = append(sets, "expires_at = "+q.Arg(dto.ExpiresAt.Time))
+	}
+
+	if len(sets) == 0 && dto.FeatureIDs == nil {
+		return entity.Token{}, errors.NewDomainError(errors.ErrInvalidInput, "nothing to update")
+	}
+
+	var token entity.Token
+	err := inTx(ctx, s.client, func(tx pgx.Tx) error {
+		var err error
+		token, err = selectToken(ctx, tx, dto.TokenID)
+		if err != nil {
+			return err
+		}
+
+		if dto.FeatureIDs != nil {
+			err = setTokenFeatures(ctx, tx, dto.TokenID, dto.FeatureIDs)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(sets) > 0 {
+			q.Write("UPDATE tokens SET " + strings.Join(sets, ", "))
+			q.Write(" WHERE id = " + q.Arg(dto.TokenID) + ";")
+
+			_, err = tx.Exec(ctx, q.String(), q.Args()...)
+			if err != nil {
+				slog.Error("error updating tokens", "error", err)
+				return dbError(err)
+			}
+		}
+
+		token, err = selectToken(ctx, tx, dto.TokenID)
+		return err
+	})
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	return token, nil
+}
+
+// selectToken reads the token, locking it for the rest of the transaction.
+func selectToken(ctx context.Context, tx pgx.Tx, tokenID int64) (entity.Token, error) {
+	row := tx.QueryRow(
 		ctx,
-		`SELECT is_admin
+		`SELECT `+tokenColumns+`
 		FROM tokens
-		WHERE "token" = $1;`,
-		token,
+		WHERE id = $1
+		FOR UPDATE;`,
+		tokenID,
 	)
 
-	var isAdmin bool
-	err := row.Scan(&isAdmin)
+	token, err := scanToken(row)
 	if err != nil {
 		slog.Error("error scanning row", "error", err)
 		if stdErrors.Is(err, pgx.ErrNoRows) {
-			return false, errors.NewDomainError(errors.ErrUnauthorized, "")
+			return entity.Token{}, errors.NewDomainError(errors.ErrNoDataFound, "")
 		}
-		return false, errors.NewDomainError(errors.ErrDB, "")
+		return entity.Token{}, dbError(err)
 	}
 
-	return isAdmin, nil
+	return token, nil
+}
+
+// setTokenFeatures limits the token to the features. An empty list lifts the
+// limit.
+func setTokenFeatures(ctx context.Context, tx pgx.Tx, tokenID int64, featureIDs []int64) error {
+	_, err := tx.Exec(
+		ctx,
+		`DELETE FROM token_features
+		WHERE token_id = $1;`,
+		tokenID,
+	)
+	if err != nil {
+		slog.Error("error deleting from token_features", "error", err)
+		return dbError(err)
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO token_features (token_id, feature_id)
+		SELECT DISTINCT $1::bigint, UNNEST($2::bigint[]);`,
+		tokenID, featureIDs,
+	)
+	if err != nil {
+		slog.Error("error inserting in token_features", "error", err)
+		var pgErr *pgconn.PgError
+		if stdErrors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return errors.NewDomainError(errors.ErrFeatureNotFound, "")
+		}
+		return dbError(err)
+	}
+
+	return nil
+}
+
+// RevokeToken revokes the token. Revoking a token twice keeps the time of
+// the first revocation.
+func (s *tokenStorage) RevokeToken(ctx context.Context, dto entity.RevokeTokenDTO) (entity.Token, error) {
+	row := s.client.QueryRow(
+		ctx,
+		`UPDATE tokens
+		SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1
+		RETURNING `+tokenColumns+`;`,
+		dto.TokenID,
+	)
+
+	token, err := scanToken(row)
+	if err != nil {
+		slog.Error("error updating tokens", "error", err)
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return entity.Token{}, errors.NewDomainError(errors.ErrNoDataFound, "")
+		}
+		return entity.Token{}, errors.NewDomainError(errors.ErrDB, "")
+	}
+
+	return token, nil
+}
+
+const (
+	tokenSaltSize = 16
+
+	// tokenPrefixLen characters of a token are kept in plain text to look it
+	// up. Tokens shorter than minTokenLen are not issued, so that what is
+	// left of them is still hard to guess and every lookup is indexed.
+	tokenPrefixLen = 8
+	minTokenLen    = 32
+
+	// legacyTokenWindow is how long the short tokens issued before
+	// minTokenLen keep working. The 000019 migration uses it too.
+	legacyTokenWindow = 90 * 24 * time.Hour
+)
+
+// tokenPrefix returns the prefix to look the token up by. It reports false
+// for tokens too short to have one; the legacy ones are stored with the
+// empty prefix returned then.
+func tokenPrefix(token string) (string, bool) {
+	runes := []rune(token)
+	if len(runes) < minTokenLen {
+		return "", false
+	}
+	return string(runes[:tokenPrefixLen]), true
+}
+
+func errShortToken() error {
+	return errors.NewDomainError(errors.ErrInvalidInput, "token must be at least %d characters long", minTokenLen)
+}
+
+// hashToken returns sha256(salt || token), the digest stored in place of
+// the token.
+func hashToken(salt []byte, token string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(token))
+	return h.Sum(nil)
 }
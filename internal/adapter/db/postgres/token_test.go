@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tokenPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "positive, shortest token",
+			token:  strings.Repeat("a", minTokenLen),
+			want:   strings.Repeat("a", tokenPrefixLen),
+			wantOK: true,
+		},
+		{
+			name:   "positive, prefix counted in runes",
+			token:  "жжжжжжжжж" + strings.Repeat("a", minTokenLen),
+			want:   "жжжжжжжж",
+			wantOK: true,
+		},
+		{
+			name:  "negative, one rune short",
+			token: strings.Repeat("a", minTokenLen-1),
+		},
+		{
+			name:  "negative, long in bytes, short in runes",
+			token: strings.Repeat("ж", minTokenLen-1),
+		},
+		{
+			name:  "negative, empty",
+			token: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tokenPrefix(tt.token)
+			require.Equal(t, tt.wantOK, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_hashToken(t *testing.T) {
+	token := strings.Repeat("a", minTokenLen)
+	salt := []byte("salt")
+
+	// must match the digest computed by the 000009 migration:
+	// sha256(salt || convert_to(token, 'UTF8'))
+	want := sha256.Sum256(append([]byte("salt"), token...))
+	require.Equal(t, want[:], hashToken(salt, token))
+
+	require.NotEqual(t, hashToken(salt, token), hashToken([]byte("other"), token))
+	require.NotEqual(t, hashToken(salt, token), hashToken(salt, token+"b"))
+}
+
+// tokenRow is a row of tokens as CheckToken selects it.
+type tokenRow struct {
+	id     int64
+	salt   []byte
+	digest []byte
+	role   entity.Role
+}
+
+// fakeRows returns rows from a query.
+type fakeRows struct {
+	pgx.Rows
+
+	rows []tokenRow
+	next int
+}
+
+func (r *fakeRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	row := r.rows[r.next-1]
+	*dest[0].(*int64) = row.id
+	*dest[1].(*[]byte) = row.salt
+	*dest[2].(*[]byte) = row.digest
+	*dest[3].(*entity.Role) = row.role
+	return nil
+}
+
+func (r *fakeRows) Close() {}
+
+func (r *fakeRows) Err() error {
+	return nil
+}
+
+func (r *fakeRows) CommandTag() pgconn.CommandTag {
+	return pgconn.CommandTag{}
+}
+
+// tokenClient answers the queries of CheckToken with rows, and records the
+// arguments of the queries.
+type tokenClient struct {
+	postgresql.Client
+
+	rows []tokenRow
+	args [][]any
+}
+
+func (c *tokenClient) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	c.args = append(c.args, args)
+	return &fakeRows{rows: c.rows}, nil
+}
+
+func (c *tokenClient) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func Test_tokenStorage_CheckToken(t *testing.T) {
+	secret := "abcdefgh" + strings.Repeat("x", minTokenLen)
+	legacy := "legacy"
+	salt := []byte("salt")
+
+	tests := []struct {
+		name  string
+		token string
+		// rows are the tokens with the prefix of the token
+		rows       []tokenRow
+		wantPrefix string
+		wantID     int64
+		wantErr    bool
+	}{
+		{
+			name:       "positive",
+			token:      secret,
+			rows:       []tokenRow{{id: 1, salt: salt, digest: hashToken(salt, secret), role: entity.RoleAdmin}},
+			wantPrefix: "abcdefgh",
+			wantID:     1,
+		},
+		{
+			name:       "positive, short legacy token looked up by its digest",
+			token:      legacy,
+			rows:       []tokenRow{{id: 2, salt: salt, digest: hashToken(salt, legacy), role: entity.RoleAdmin}},
+			wantPrefix: "",
+			wantID:     2,
+		},
+		{
+			name:       "negative, right prefix, wrong secret",
+			token:      "abcdefgh" + strings.Repeat("y", minTokenLen),
+			rows:       []tokenRow{{id: 1, salt: salt, digest: hashToken(salt, secret), role: entity.RoleAdmin}},
+			wantPrefix: "abcdefgh",
+			wantErr:    true,
+		},
+		{
+			name:       "negative, wrong short secret",
+			token:      "abcdefgh",
+			rows:       []tokenRow{{id: 2, salt: salt, digest: hashToken(salt, legacy), role: entity.RoleAdmin}},
+			wantPrefix: "",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tokenClient{rows: tt.rows}
+			storage := NewTokenStorage(client)
+
+			principal, err := storage.CheckToken(context.Background(), tt.token)
+			require.Equal(t, [][]any{{tt.wantPrefix}}, client.args)
+			if tt.wantErr {
+				require.Equal(t, errors.ErrUnauthorized, errors.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantID, principal.TokenID)
+		})
+	}
+}
+
+// ensureTx answers the statements of EnsureToken as if no token existed,
+// and records the arguments of the insert.
+type ensureTx struct {
+	fakeTx
+
+	insertArgs []any
+}
+
+func (tx *ensureTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *ensureTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+func (tx *ensureTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	tx.insertArgs = args
+	return errRow{}
+}
+
+// ensureClient begins tx.
+type ensureClient struct {
+	postgresql.Client
+
+	tx *ensureTx
+}
+
+func (c *ensureClient) Begin(ctx context.Context) (pgx.Tx, error) {
+	return c.tx, nil
+}
+
+func Test_tokenStorage_EnsureToken_Short(t *testing.T) {
+	client := &ensureClient{tx: &ensureTx{}}
+	storage := NewTokenStorage(client)
+
+	err := storage.EnsureToken(context.Background(), entity.IssueTokenDTO{Role: entity.RoleAdmin, Token: "admin_token"})
+	require.NoError(t, err)
+
+	// the short token is kept as a legacy one, which expires after the
+	// deprecation window
+	args := client.tx.insertArgs
+	require.Equal(t, "", args[0])
+	expiresAt := args[5].(*time.Time)
+	require.WithinDuration(t, time.Now().Add(legacyTokenWindow), *expiresAt, time.Minute)
+}
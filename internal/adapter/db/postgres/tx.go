@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	stdErrors "errors"
+	"log/slog"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	txMaxAttempts = 3
+	txRetryDelay  = 20 * time.Millisecond
+)
+
+// errTxConflict is returned by dbError for serialization failures and
+// deadlocks. inTx retries the transaction when fn returns it. Transactions
+// run at the isolation level the server defaults to, so serialization
+// failures are possible even though the queries are written for READ
+// COMMITTED.
+var errTxConflict = errors.NewDomainError(errors.ErrDB, "transaction conflict")
+
+// dbError converts a driver error into ErrDB, keeping conflicts that are
+// worth retrying recognisable for inTx.
+func dbError(err error) error {
+	var pgErr *pgconn.PgError
+	if stdErrors.As(err, &pgErr) &&
+		(pgErr.Code == pgerrcode.SerializationFailure || pgErr.Code == pgerrcode.DeadlockDetected) {
+		return errTxConflict
+	}
+	return errors.NewDomainError(errors.ErrDB, "")
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return stdErrors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation
+}
+
+// inTx runs fn inside a transaction and commits it. If fn or the commit
+// fails with a conflict, the whole transaction is run again, up to
+// txMaxAttempts times.
+func inTx(ctx context.Context, client postgresql.Client, fn func(tx pgx.Tx) error) error {
+	var err error
+	for attempt := 1; attempt <= txMaxAttempts; attempt++ {
+		err = runTx(ctx, client, fn)
+		if !stdErrors.Is(err, errTxConflict) {
+			return err
+		}
+
+		slog.Warn("transaction conflict, retrying", "attempt", attempt)
+
+		select {
+		case <-ctx.Done():
+			return errors.NewDomainError(errors.ErrDB, "")
+		case <-time.After(txRetryDelay * time.Duration(attempt)):
+		}
+	}
+
+	slog.Error("transaction failed after retries", "attempts", txMaxAttempts)
+	return errors.NewDomainError(errors.ErrDB, "transaction failed after %d attempts", txMaxAttempts)
+}
+
+func runTx(ctx context.Context, client postgresql.Client, fn func(tx pgx.Tx) error) error {
+	tx, err := client.Begin(ctx)
+	if err != nil {
+		slog.Error("error beginnig transaction",
+			"error", err,
+		)
+		return dbError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		slog.Error("error commiting transaction",
+			"error", err,
+		)
+		return dbError(err)
+	}
+
+	return nil
+}
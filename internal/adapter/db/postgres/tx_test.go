@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTx fails its commit with commitErr.
+type fakeTx struct {
+	pgx.Tx
+
+	commitErr error
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	return tx.commitErr
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	return nil
+}
+
+// fakeClient counts the transactions it begins. The commits of the first
+// ones fail with commitErrs.
+type fakeClient struct {
+	postgresql.Client
+
+	begins     int
+	commitErrs []error
+}
+
+func (c *fakeClient) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx := &fakeTx{}
+	if c.begins < len(c.commitErrs) {
+		tx.commitErr = c.commitErrs[c.begins]
+	}
+	c.begins++
+	return tx, nil
+}
+
+func pgError(code string) error {
+	return &pgconn.PgError{Code: code}
+}
+
+func Test_inTx(t *testing.T) {
+	serialization := dbError(pgError(pgerrcode.SerializationFailure))
+	deadlock := dbError(pgError(pgerrcode.DeadlockDetected))
+
+	type want struct {
+		attempts int
+		err      bool
+	}
+	tests := []struct {
+		name string
+		// errs are returned by fn on the attempts in turn, nil after them
+		errs       []error
+		commitErrs []error
+		want       want
+	}{
+		{
+			name: "positive, first attempt",
+			want: want{attempts: 1},
+		},
+		{
+			name: "positive, retried after serialization failures",
+			errs: []error{serialization, serialization},
+			want: want{attempts: 3},
+		},
+		{
+			name: "positive, retried after a deadlock",
+			errs: []error{deadlock},
+			want: want{attempts: 2},
+		},
+		{
+			name:       "positive, retried after a failed commit",
+			commitErrs: []error{pgError(pgerrcode.SerializationFailure)},
+			want:       want{attempts: 2},
+		},
+		{
+			name: "negative, conflicts on every attempt",
+			errs: []error{deadlock, serialization, deadlock},
+			want: want{attempts: txMaxAttempts, err: true},
+		},
+		{
+			name: "negative, other errors are not retried",
+			errs: []error{dbError(pgError(pgerrcode.UniqueViolation))},
+			want: want{attempts: 1, err: true},
+		},
+		{
+			name:       "negative, failed commit that is not a conflict",
+			commitErrs: []error{pgError(pgerrcode.ConnectionFailure)},
+			want:       want{attempts: 1, err: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{commitErrs: tt.commitErrs}
+
+			attempt := 0
+			err := inTx(context.Background(), client, func(tx pgx.Tx) error {
+				attempt++
+				if attempt <= len(tt.errs) {
+					return tt.errs[attempt-1]
+				}
+				return nil
+			})
+
+			require.Equal(t, tt.want.attempts, client.begins)
+			if tt.want.err {
+				require.Equal(t, errors.ErrDB, errors.Code(err))
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("negative, cancelled while waiting to retry", func(t *testing.T) {
+		client := &fakeClient{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := inTx(ctx, client, func(tx pgx.Tx) error {
+			return deadlock
+		})
+
+		require.Equal(t, errors.ErrDB, errors.Code(err))
+		require.Equal(t, 1, client.begins)
+	})
+}
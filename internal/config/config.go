@@ -1,31 +1,90 @@
-package config
-
-import (
-	"github.com/num30/config"
-)
-
-type Config struct {
-	RunAddress  string   `default:":8080" envvar:"RUN_ADDR"`
-	LogLevel    string   `default:"info" flag:"loglevel" envvar:"LOGLEVEL"`
-	DB          Database `default:"{}"`
-	RedisURL    string   `envvar:"REDIS_URL"`
-	CacheExpiry int      `default:"3600" envvar:"CACHE_EXPIRY"`
-}
-
-type Database struct {
-	Host     string `default:"localhost" validate:"required" envvar:"DB_HOST"`
-	Port     int    `default:"5434" envvar:"DB_PORT"`
-	Password string `default:"banner_db" validate:"required" envvar:"DB_PASS"`
-	DbName   string `default:"banner_db" envvar:"DB_NAME"`
-	Username string `default:"banner_db" envvar:"DB_USERNAME"`
-}
-
-func MustBuild(cfgFile string) *Config {
-	var conf Config
-	err := config.NewConfReader(cfgFile).Read(&conf)
-	if err != nil {
-		panic(err)
-	}
-
-	return &conf
-}
+package config
+
+import (
+	"log/slog"
+
+	"github.com/num30/config"
+)
+
+type Config struct {
+	RunAddress  string     `default:":8080" envvar:"RUN_ADDR"`
+	LogLevel    string     `default:"info" flag:"loglevel" envvar:"LOGLEVEL"`
+	DB          Database   `default:"{}"`
+	RedisURL    string     `envvar:"REDIS_URL"` // a single primary, Redis Cluster is not supported
+	CacheExpiry int        `default:"3600" envvar:"CACHE_EXPIRY"`
+	AdminToken  string     `envvar:"ADMIN_TOKEN"` // one shorter than 32 characters expires after 90 days
+	JWT         JWT        `default:"{}"`
+	TokenCache  TokenCache `default:"{}"`
+	LocalCache  LocalCache `default:"{}"`
+	// seconds an expired banner is still served while a single replica
+	// refreshes it under a Redis lock, 0 disables the lock
+	CacheStaleWindow int          `default:"0" envvar:"CACHE_STALE_WINDOW"`
+	CacheBreaker     CacheBreaker `default:"{}"`
+}
+
+type Database struct {
+	Host     string `default:"localhost" validate:"required" envvar:"DB_HOST"`
+	Port     int    `default:"5434" envvar:"DB_PORT"`
+	Password string `default:"banner_db" validate:"required" envvar:"DB_PASS"`
+	DbName   string `default:"banner_db" envvar:"DB_NAME"`
+	Username string `default:"banner_db" envvar:"DB_USERNAME"`
+}
+
+type JWT struct {
+	// comma-separated HS256 secrets
+	HMACSecrets string `envvar:"JWT_HMAC_SECRETS"`
+	// JWKS file with RS256 and ES256, ES384 or ES512 public keys
+	JWKSFile string `envvar:"JWT_JWKS_FILE"`
+	Issuer   string `envvar:"JWT_ISSUER"`
+	Audience string `envvar:"JWT_AUDIENCE"`
+}
+
+type TokenCache struct {
+	Size int `default:"10000" envvar:"TOKEN_CACHE_SIZE"`
+	// seconds a change missed by a replica, for example while Redis was
+	// unavailable, and last_used_at of a token may lag behind
+	TTL int `default:"30" envvar:"TOKEN_CACHE_TTL"`
+}
+
+// LocalCache is the in-process banner cache in front of Redis.
+type LocalCache struct {
+	Size int `default:"10000" envvar:"LOCAL_CACHE_SIZE"`
+	// seconds
+	TTL int `default:"5" envvar:"LOCAL_CACHE_TTL"`
+}
+
+// CacheBreaker stops calling Redis for CoolDown seconds after Threshold
+// consecutive failures.
+type CacheBreaker struct {
+	Threshold int `default:"5" envvar:"CACHE_BREAKER_THRESHOLD"`
+	// seconds
+	CoolDown int `default:"10" envvar:"CACHE_BREAKER_COOLDOWN"`
+}
+
+// plainConfig has the fields of Config without its LogValue method.
+type plainConfig Config
+
+// LogValue masks the secrets, so that the config can be logged.
+func (c Config) LogValue() slog.Value {
+	c.AdminToken = redact(c.AdminToken)
+	c.JWT.HMACSecrets = redact(c.JWT.HMACSecrets)
+	c.DB.Password = redact(c.DB.Password)
+	return slog.AnyValue(plainConfig(c))
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+func MustBuild(cfgFile string) *Config {
+	var conf Config
+	err := config.NewConfReader(cfgFile).Read(&conf)
+	if err != nil {
+		panic(err)
+	}
+
+	return &conf
+}
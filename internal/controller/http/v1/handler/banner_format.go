@@ -0,0 +1,194 @@
+package v1
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+// Banners are imported and exported either as NDJSON, one POST /banner body
+// per line, or as CSV with bannerCSVHeader. In CSV, tag IDs are separated by
+// spaces, times are RFC 3339 and an empty starts_at or ends_at leaves the
+// bound unset.
+const (
+	bannerFormatNDJSON = "ndjson"
+	bannerFormatCSV    = "csv"
+
+	ndjsonContentType = "application/x-ndjson"
+	csvContentType    = "text/csv"
+
+	// maxNDJSONLineSize limits a single banner in an NDJSON import.
+	maxNDJSONLineSize = 1 << 20
+)
+
+var bannerCSVHeader = []string{"feature_id", "tag_ids", "title", "text", "url", "is_active", "starts_at", "ends_at"}
+
+// bannerLine is a banner read from an import file. err is set if the line
+// could not be parsed.
+type bannerLine struct {
+	line   int
+	banner entity.CreateBannerDTO
+	err    error
+}
+
+// readBannersNDJSON parses every non-empty line. Only errors that keep the
+// rest of the file from being read are returned.
+func readBannersNDJSON(r io.Reader) ([]bannerLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLineSize)
+
+	var lines []bannerLine
+	for n := 1; scanner.Scan(); n++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		l := bannerLine{line: n}
+		err := json.Unmarshal(raw, &l.banner)
+		if err != nil {
+			l.err = fmt.Errorf("invalid json: %w", err)
+		}
+		lines = append(lines, l)
+	}
+
+	return lines, scanner.Err()
+}
+
+// readBannersCSV parses every record after the header. Only errors that keep
+// the rest of the file from being read are returned.
+func readBannersCSV(r io.Reader) ([]bannerLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(bannerCSVHeader)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading csv header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	if !slices.Equal(header, bannerCSVHeader) {
+		return nil, fmt.Errorf("csv header must be %s", strings.Join(bannerCSVHeader, ","))
+	}
+
+	var lines []bannerLine
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if err != nil {
+			if !stdErrors.As(err, &parseErr) {
+				return nil, err
+			}
+			lines = append(lines, bannerLine{line: parseErr.StartLine, err: parseErr.Err})
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		l := bannerLine{line: line}
+		l.banner, l.err = parseBannerCSVRecord(record)
+		lines = append(lines, l)
+	}
+
+	return lines, nil
+}
+
+func parseBannerCSVRecord(record []string) (entity.CreateBannerDTO, error) {
+	var dto entity.CreateBannerDTO
+	var err error
+
+	dto.FeatureID, err = strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64)
+	if err != nil {
+		return dto, fmt.Errorf("invalid feature_id")
+	}
+
+	for _, field := range strings.Fields(record[1]) {
+		tagID, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return dto, fmt.Errorf("invalid tag_ids")
+		}
+		dto.TagIDs = append(dto.TagIDs, tagID)
+	}
+
+	dto.Content = entity.BannerContent{Title: record[2], Text: record[3], URL: record[4]}
+
+	if s := strings.TrimSpace(record[5]); s != "" {
+		dto.IsActive, err = strconv.ParseBool(s)
+		if err != nil {
+			return dto, fmt.Errorf("invalid is_active")
+		}
+	}
+
+	dto.StartsAt, err = parseCSVTime(record[6])
+	if err != nil {
+		return dto, fmt.Errorf("invalid starts_at")
+	}
+	dto.EndsAt, err = parseCSVTime(record[7])
+	if err != nil {
+		return dto, fmt.Errorf("invalid ends_at")
+	}
+
+	return dto, nil
+}
+
+func parseCSVTime(s string) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// exportedBanner is the banner in the shape it is imported in.
+func exportedBanner(banner entity.Banner) entity.CreateBannerDTO {
+	return entity.CreateBannerDTO{
+		TagIDs:    banner.TagIDs,
+		FeatureID: banner.FeatureID,
+		Content:   banner.Content,
+		IsActive:  banner.IsActive,
+		Schedule:  banner.Schedule,
+	}
+}
+
+func bannerCSVRecord(banner entity.Banner) []string {
+	tagIDs := make([]string, 0, len(banner.TagIDs))
+	for _, tagID := range banner.TagIDs {
+		tagIDs = append(tagIDs, strconv.FormatInt(tagID, 10))
+	}
+
+	return []string{
+		strconv.FormatInt(banner.FeatureID, 10),
+		strings.Join(tagIDs, " "),
+		banner.Content.Title,
+		banner.Content.Text,
+		banner.Content.URL,
+		strconv.FormatBool(banner.IsActive),
+		formatCSVTime(banner.StartsAt),
+		formatCSVTime(banner.EndsAt),
+	}
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,286 @@
+package v1
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/stretchr/testify/require"
+)
+
+// wantLine is what a test expects of a bannerLine: the banner is only
+// compared if the line has no error.
+type wantLine struct {
+	line   int
+	banner entity.CreateBannerDTO
+	err    bool
+}
+
+func requireLines(t *testing.T, want []wantLine, got []bannerLine) {
+	t.Helper()
+
+	require.Len(t, got, len(want))
+	for i := range want {
+		require.Equal(t, want[i].line, got[i].line, "line %d", i)
+		if want[i].err {
+			require.Error(t, got[i].err, "line %d", want[i].line)
+			continue
+		}
+		require.NoError(t, got[i].err, "line %d", want[i].line)
+		require.Equal(t, want[i].banner, got[i].banner, "line %d", want[i].line)
+	}
+}
+
+func Test_readBannersNDJSON(t *testing.T) {
+	startsAt := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	content := entity.BannerContent{Title: "t", Text: "x", URL: "u"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []wantLine
+	}{
+		{
+			name: "positive, banners",
+			input: `{"feature_id":1,"tag_ids":[1,2],"content":{"title":"t","text":"x","url":"u"},"is_active":true}
+{"feature_id":2,"tag_ids":[3],"content":{"title":"t","text":"x","url":"u"},"starts_at":"2024-04-01T00:00:00Z"}
+`,
+			want: []wantLine{
+				{line: 1, banner: entity.CreateBannerDTO{FeatureID: 1, TagIDs: []int64{1, 2}, Content: content, IsActive: true}},
+				{line: 2, banner: entity.CreateBannerDTO{FeatureID: 2, TagIDs: []int64{3}, Content: content, Schedule: entity.Schedule{StartsAt: &startsAt}}},
+			},
+		},
+		{
+			name:  "positive, blank lines are skipped but counted",
+			input: "\n  \n" + `{"feature_id":1,"tag_ids":[1],"content":{"title":"t","text":"x","url":"u"}}` + "\r\n\n",
+			want: []wantLine{
+				{line: 3, banner: entity.CreateBannerDTO{FeatureID: 1, TagIDs: []int64{1}, Content: content}},
+			},
+		},
+		{
+			name: "negative, invalid lines do not stop the import",
+			input: `{"feature_id":"one"}
+not json
+{"feature_id":1,"tag_ids":[1],"content":{"title":"t","text":"x","url":"u"}}
+`,
+			want: []wantLine{
+				{line: 1, err: true},
+				{line: 2, err: true},
+				{line: 3, banner: entity.CreateBannerDTO{FeatureID: 1, TagIDs: []int64{1}, Content: content}},
+			},
+		},
+		{
+			name:  "positive, empty input",
+			input: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readBannersNDJSON(strings.NewReader(tt.input))
+			require.NoError(t, err)
+
+			requireLines(t, tt.want, got)
+		})
+	}
+
+	t.Run("negative, line too long", func(t *testing.T) {
+		_, err := readBannersNDJSON(strings.NewReader(strings.Repeat(" ", maxNDJSONLineSize+1) + "{}"))
+		require.Error(t, err)
+	})
+}
+
+func Test_readBannersCSV(t *testing.T) {
+	startsAt := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	endsAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	content := entity.BannerContent{Title: "t", Text: "x", URL: "u"}
+
+	const header = "feature_id,tag_ids,title,text,url,is_active,starts_at,ends_at\n"
+
+	type want struct {
+		lines []wantLine
+		err   bool
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  want
+	}{
+		{
+			name: "positive, banners",
+			input: header +
+				"1,1 2,t,x,u,true,,\n" +
+				"2,3,t,x,u,,2024-04-01T00:00:00Z,2024-05-01T12:30:00Z\n",
+			want: want{
+				lines: []wantLine{
+					{line: 2, banner: entity.CreateBannerDTO{FeatureID: 1, TagIDs: []int64{1, 2}, Content: content, IsActive: true}},
+					{line: 3, banner: entity.CreateBannerDTO{FeatureID: 2, TagIDs: []int64{3}, Content: content, Schedule: entity.Schedule{StartsAt: &startsAt, EndsAt: &endsAt}}},
+				},
+			},
+		},
+		{
+			name: "positive, quoted fields keep commas and newlines",
+			input: header +
+				"1,1,\"a, b\",\"line\nbreak\",u,false,,\n" +
+				"2,2,t,x,u,false,,\n",
+			want: want{
+				lines: []wantLine{
+					{line: 2, banner: entity.CreateBannerDTO{FeatureID: 1, TagIDs: []int64{1}, Content: entity.BannerContent{Title: "a, b", Text: "line\nbreak", URL: "u"}}},
+					{line: 4, banner: entity.CreateBannerDTO{FeatureID: 2, TagIDs: []int64{2}, Content: content}},
+				},
+			},
+		},
+		{
+			name:  "positive, header is case and space insensitive",
+			input: " Feature_ID ,TAG_IDS,title,text,url,is_active,starts_at,ends_at\n1,1,t,x,u,,,\n",
+			want: want{
+				lines: []wantLine{
+					{line: 2, banner: entity.CreateBannerDTO{FeatureID: 1, TagIDs: []int64{1}, Content: content}},
+				},
+			},
+		},
+		{
+			name: "negative, invalid records do not stop the import",
+			input: header +
+				"one,1,t,x,u,,,\n" +
+				"1,1 two,t,x,u,,,\n" +
+				"1,1,t,x,u,maybe,,\n" +
+				"1,1,t,x,u,,tomorrow,\n" +
+				"1,1,t,x,u,,,2024-05-01\n" +
+				"1,1,t,x,u\n" +
+				"1,1,t,x,u,,,\n",
+			want: want{
+				lines: []wantLine{
+					{line: 2, err: true},
+					{line: 3, err: true},
+					{line: 4, err: true},
+					{line: 5, err: true},
+					{line: 6, err: true},
+					{line: 7, err: true},
+					{line: 8, banner: entity.CreateBannerDTO{FeatureID: 1, TagIDs: []int64{1}, Content: content}},
+				},
+			},
+		},
+		{
+			name:  "negative, wrong header",
+			input: "feature_id,tag_ids,title\n1,1,t\n",
+			want:  want{err: true},
+		},
+		{
+			name:  "negative, no header",
+			input: "",
+			want:  want{err: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readBannersCSV(strings.NewReader(tt.input))
+			if tt.want.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			requireLines(t, tt.want.lines, got)
+		})
+	}
+}
+
+func Test_bannerCSVRecord(t *testing.T) {
+	startsAt := time.Date(2024, 4, 1, 0, 0, 0, 123, time.UTC)
+
+	tests := []struct {
+		name   string
+		banner entity.Banner
+	}{
+		{
+			name: "without schedule",
+			banner: entity.Banner{
+				FeatureID: 1,
+				TagIDs:    []int64{1, 2},
+				Content:   entity.BannerContent{Title: "a, \"b\"", Text: "line\nbreak", URL: "u"},
+				IsActive:  true,
+			},
+		},
+		{
+			name: "with schedule",
+			banner: entity.Banner{
+				FeatureID: 2,
+				TagIDs:    []int64{3},
+				Content:   entity.BannerContent{Title: "t", Text: "x", URL: "u"},
+				Schedule:  entity.Schedule{StartsAt: &startsAt},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBannerCSVRecord(bannerCSVRecord(tt.banner))
+			require.NoError(t, err)
+
+			require.Equal(t, exportedBanner(tt.banner), got)
+		})
+	}
+}
+
+func Test_validateCreateBannerDTO(t *testing.T) {
+	valid := entity.CreateBannerDTO{
+		FeatureID: 1,
+		TagIDs:    []int64{1},
+		Content:   entity.BannerContent{Title: "t", Text: "x", URL: "u"},
+	}
+	startsAt := time.Now()
+	endsAt := startsAt.Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		modify func(dto *entity.CreateBannerDTO)
+		want   string
+	}{
+		{
+			name:   "positive",
+			modify: func(dto *entity.CreateBannerDTO) {},
+			want:   "",
+		},
+		{
+			name:   "negative, invalid feature",
+			modify: func(dto *entity.CreateBannerDTO) { dto.FeatureID = 0 },
+			want:   "invalid feature ID",
+		},
+		{
+			name:   "negative, no tags",
+			modify: func(dto *entity.CreateBannerDTO) { dto.TagIDs = nil },
+			want:   "tag_ids must not be empty",
+		},
+		{
+			name:   "negative, invalid tag",
+			modify: func(dto *entity.CreateBannerDTO) { dto.TagIDs = []int64{1, -1} },
+			want:   "invalid tag ID",
+		},
+		{
+			name:   "negative, no title",
+			modify: func(dto *entity.CreateBannerDTO) { dto.Content.Title = "" },
+			want:   "content must have title, text and url",
+		},
+		{
+			name:   "negative, no content",
+			modify: func(dto *entity.CreateBannerDTO) { dto.Content = entity.BannerContent{} },
+			want:   "content must have title, text and url",
+		},
+		{
+			name: "negative, empty schedule",
+			modify: func(dto *entity.CreateBannerDTO) {
+				dto.Schedule = entity.Schedule{StartsAt: &startsAt, EndsAt: &endsAt}
+			},
+			want: "starts_at must be before ends_at",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dto := valid
+			dto.TagIDs = append([]int64(nil), valid.TagIDs...)
+			tt.modify(&dto)
+
+			require.Equal(t, tt.want, validateCreateBannerDTO(dto))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// catalogDefaultLimit is how many tags or features are listed if the
+	// request does not say.
+	catalogDefaultLimit = 100
+	// catalogMaxLimit keeps a single request from reading the whole catalog.
+	catalogMaxLimit = 1000
+)
+
+// catalogPage holds the query parameters of the tag and feature listings.
+type catalogPage struct {
+	ownerTeam string
+	limit     int
+	offset    int
+}
+
+// parseCatalogPage returns the listing parameters of the request, or a
+// description of the first invalid one.
+func parseCatalogPage(r *http.Request) (catalogPage, string) {
+	page := catalogPage{
+		ownerTeam: r.URL.Query().Get("owner_team"),
+		limit:     catalogDefaultLimit,
+	}
+
+	var err error
+	if strLimit := r.URL.Query().Get("limit"); strLimit != "" {
+		page.limit, err = strconv.Atoi(strLimit)
+		if err != nil || page.limit < 0 {
+			return catalogPage{}, "invalid limit"
+		}
+		if page.limit > catalogMaxLimit {
+			return catalogPage{}, "limit must not exceed " + strconv.Itoa(catalogMaxLimit)
+		}
+	}
+	if strOffset := r.URL.Query().Get("offset"); strOffset != "" {
+		page.offset, err = strconv.Atoi(strOffset)
+		if err != nil || page.offset < 0 {
+			return catalogPage{}, "invalid offset"
+		}
+	}
+
+	return page, ""
+}
+
+// parseCascade reads the cascade query parameter of a tag or feature
+// deletion, false if it is absent.
+func parseCascade(r *http.Request) (bool, error) {
+	strCascade := r.URL.Query().Get("cascade")
+	if strCascade == "" {
+		return false, nil
+	}
+
+	return strconv.ParseBool(strCascade)
+}
+
+// parseCatalogID returns the ID in the path of a tag or feature request, or a
+// description of why it is invalid.
+func parseCatalogID(r *http.Request, noun string) (int64, string) {
+	ID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || ID < 1 {
+		return 0, "invalid " + noun + " ID"
+	}
+
+	return ID, ""
+}
+
+// decodeCatalogCreation decodes the body of a tag or feature creation into
+// dto and trims its name, or returns a description of the first problem.
+func decodeCatalogCreation(r *http.Request, dto any, name *string) string {
+	err := json.NewDecoder(r.Body).Decode(dto)
+	if err != nil {
+		return "error decoding json request body"
+	}
+
+	*name = strings.TrimSpace(*name)
+	if *name == "" {
+		return "name is required"
+	}
+
+	return ""
+}
+
+// decodeCatalogUpdate decodes the body of a tag or feature update into dto
+// and trims its name if it is set, or returns a description of the first
+// problem. The fields point into dto, so they are read after the decoding.
+func decodeCatalogUpdate(r *http.Request, dto any, name, description, ownerTeam **string) string {
+	err := json.NewDecoder(r.Body).Decode(dto)
+	if err != nil {
+		return "error decoding json request body"
+	}
+
+	if *name == nil && *description == nil && *ownerTeam == nil {
+		return "nothing to update"
+	}
+
+	if *name != nil {
+		trimmed := strings.TrimSpace(**name)
+		if trimmed == "" {
+			return "name must not be empty"
+		}
+		*name = &trimmed
+	}
+
+	return ""
+}
+
+// catalogErrorStatus returns the status code of an error of the tag and
+// feature usecases.
+func catalogErrorStatus(err error) int {
+	switch errors.Code(err) {
+	case errors.ErrNoDataFound:
+		return http.StatusNotFound
+	case errors.ErrAlreadyExists, errors.ErrInUse:
+		return http.StatusConflict
+	case errors.ErrInvalidInput:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
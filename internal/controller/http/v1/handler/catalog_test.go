@@ -0,0 +1,405 @@
+package v1
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/The-Gleb/banner_service/internal/adapter/cache/redis"
+	db "github.com/The-Gleb/banner_service/internal/adapter/db/postgres"
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/service"
+	"github.com/The-Gleb/banner_service/internal/domain/usecase"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// newCatalogTestServer seeds tags and features with their banners and tokens
+// and serves the tag and feature deleting handlers to the adminToken:
+//   - banner 1 has feature 1 and tags 1 and 2, banner 2 has feature 1 and
+//     tag 2, banner 3 has feature 2 and tag 3;
+//   - token 2 is scoped to feature 2, revoked token 3 to feature 3.
+func newCatalogTestServer(t *testing.T) (*httptest.Server, postgresql.Client) {
+	t.Helper()
+
+	c, err := postgresql.NewClient(context.Background(), dsn)
+	require.NoError(t, err)
+
+	err = db.RunMigrations(dsn)
+	require.NoError(t, err)
+
+	cleanTables(
+		t, dsn,
+		"banners", "banner_tag", "banner_feature", "tags", "features", "tokens",
+	)
+
+	_, err = c.Exec(
+		context.Background(),
+		`INSERT INTO tags (id)
+		VALUES (1),(2),(3);
+
+		INSERT INTO features (id)
+		VALUES (1),(2),(3);
+
+		INSERT INTO banners
+		(id, title, text, url, is_active, created_at)
+		VALUES
+			(1, 'title1', 'text1', 'url1', true, NOW()),
+			(2, 'title2', 'text2', 'url2', true, NOW()),
+			(3, 'title3', 'text3', 'url3', true, NOW());
+
+		INSERT INTO banner_tag (banner_id, tag_id)
+		VALUES
+			(1, 1), (1, 2), (2, 2), (3, 3);
+
+		INSERT INTO banner_feature (banner_id, feature_id)
+		VALUES
+			(1, 1), (2, 1), (3, 2);
+
+		INSERT INTO banner_feature_tag (banner_id, feature_id, tag_id)
+		VALUES
+			(1, 1, 1), (1, 1, 2), (2, 1, 2), (3, 2, 3);
+
+		INSERT INTO tokens (id, prefix, salt, digest, role, revoked_at, created_at)
+		SELECT t.id, left(t.token, 8), 'salt'::bytea, sha256('salt'::bytea || convert_to(t.token, 'UTF8')), t.role, t.revoked_at, NOW()
+		FROM (VALUES
+			(1, 'admin_token_for_the_handler_tests', 'admin', NULL::timestamptz),
+			(2, 'scoped_token_for_the_handler_tests', 'publisher', NULL),
+			(3, 'revoked_token_for_the_handler_tests', 'publisher', NOW())
+		) AS t(id, token, role, revoked_at);
+
+		INSERT INTO token_features (token_id, feature_id)
+		VALUES
+			(2, 2), (3, 3);`,
+	)
+	require.NoError(t, err)
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     redisAddr,
+		Password: "",
+		DB:       0,
+	})
+	bannerCache := cache.NewRedisCache(redisClient, 3600)
+
+	tokenService := service.NewTokenService(db.NewTokenStorage(c), cache.NewTokenInvalidations(redisClient, bannerCache), 100, time.Minute)
+	jwtService := service.NewJWTService(nil, nil, "", "")
+	checkTokenHandler := v1.NewAuthMiddleware(usecase.NewCheckTokenUsecase(tokenService, jwtService))
+
+	tagService := service.NewTagService(db.NewTagStorage(c), bannerCache)
+	featureService := service.NewFeatureService(db.NewFeatureStorage(c), bannerCache)
+
+	r := chi.NewRouter()
+	NewDeleteTagHandler(usecase.NewDeleteTagUsecase(tagService)).
+		Middlewares(checkTokenHandler.Do).AddToRouter(r)
+	NewDeleteFeatureHandler(usecase.NewDeleteFeatureUsecase(featureService)).
+		Middlewares(checkTokenHandler.Do).AddToRouter(r)
+
+	s := httptest.NewServer(r)
+	t.Cleanup(s.Close)
+
+	return s, c
+}
+
+func Test_deleteFeatureHandler_ServeHTTP_ScopedTokens(t *testing.T) {
+
+	s, c := newCatalogTestServer(t)
+
+	type want struct {
+		code int
+		body string
+	}
+	tests := []struct {
+		name string
+		path string
+		want want
+	}{
+		{
+			name: "negative, feature a token is scoped to",
+			path: "/feature/2?cascade=true",
+			want: want{
+				code: 409,
+				body: "feature is used by 1 tokens",
+			},
+		},
+		{
+			name: "positive, feature only a revoked token is scoped to",
+			path: "/feature/3",
+			want: want{
+				code: 204,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := testRequest(t, s, "DELETE", tt.path, nil, adminToken)
+
+			require.Equal(t, tt.want.code, resp.StatusCode)
+			require.Contains(t, body, tt.want.body)
+		})
+	}
+
+	var featureIDs []int64
+	err := c.QueryRow(
+		context.Background(),
+		`SELECT ARRAY(SELECT feature_id FROM token_features WHERE token_id = 2);`,
+	).Scan(&featureIDs)
+	require.NoError(t, err)
+	require.Equal(t, []int64{2}, featureIDs)
+}
+
+func Test_deleteTagHandler_ServeHTTP(t *testing.T) {
+
+	s, c := newCatalogTestServer(t)
+
+	type want struct {
+		code int
+		body string
+	}
+	tests := []struct {
+		name string
+		path string
+		want want
+	}{
+		{
+			name: "negative, tag used by banners",
+			path: "/tag/2",
+			want: want{
+				code: 409,
+				body: "tag is used by 2 banners",
+			},
+		},
+		{
+			name: "positive, cascade",
+			path: "/tag/2?cascade=true",
+			want: want{
+				code: 204,
+			},
+		},
+		{
+			name: "negative, missing tag",
+			path: "/tag/2",
+			want: want{
+				code: 404,
+			},
+		},
+		{
+			name: "negative, invalid cascade",
+			path: "/tag/1?cascade=maybe",
+			want: want{
+				code: 400,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := testRequest(t, s, "DELETE", tt.path, nil, adminToken)
+
+			require.Equal(t, tt.want.code, resp.StatusCode)
+			require.Contains(t, body, tt.want.body)
+		})
+	}
+
+	// banner 1 lost tag 2 in a new version, banner 2 had no other tag and was
+	// deleted, banner 3 was left alone
+	rows, err := c.Query(
+		context.Background(),
+		`SELECT id, version, ARRAY(SELECT tag_id FROM banner_tag WHERE banner_id = banners.id ORDER BY tag_id)
+		FROM banners
+		ORDER BY id;`,
+	)
+	require.NoError(t, err)
+
+	type banner struct {
+		id      int64
+		version int64
+		tagIDs  []int64
+	}
+	var banners []banner
+	for rows.Next() {
+		var b banner
+		err = rows.Scan(&b.id, &b.version, &b.tagIDs)
+		require.NoError(t, err)
+		banners = append(banners, b)
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []banner{
+		{id: 1, version: 2, tagIDs: []int64{1}},
+		{id: 3, version: 1, tagIDs: []int64{3}},
+	}, banners)
+
+	var actions []string
+	err = c.QueryRow(
+		context.Background(),
+		`SELECT ARRAY(SELECT action FROM audit_log WHERE banner_id IN (1, 2) ORDER BY banner_id);`,
+	).Scan(&actions)
+	require.NoError(t, err)
+	require.Equal(t, []string{"update", "delete"}, actions)
+}
+
+func Test_deleteFeatureHandler_ServeHTTP(t *testing.T) {
+
+	s, c := newCatalogTestServer(t)
+
+	countAudit := func() int {
+		var n int
+		err := c.QueryRow(
+			context.Background(),
+			`SELECT COUNT(*) FROM audit_log WHERE action = 'delete' AND banner_id IN (1, 2);`,
+		).Scan(&n)
+		require.NoError(t, err)
+		return n
+	}
+	audited := countAudit()
+
+	type want struct {
+		code int
+		body string
+	}
+	tests := []struct {
+		name string
+		path string
+		want want
+	}{
+		{
+			name: "negative, feature used by banners",
+			path: "/feature/1",
+			want: want{
+				code: 409,
+				body: "feature is used by 2 banners",
+			},
+		},
+		{
+			name: "positive, cascade",
+			path: "/feature/1?cascade=true",
+			want: want{
+				code: 204,
+			},
+		},
+		{
+			name: "negative, missing feature",
+			path: "/feature/1",
+			want: want{
+				code: 404,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := testRequest(t, s, "DELETE", tt.path, nil, adminToken)
+
+			require.Equal(t, tt.want.code, resp.StatusCode)
+			require.Contains(t, body, tt.want.body)
+		})
+	}
+
+	var bannerIDs []int64
+	err := c.QueryRow(
+		context.Background(),
+		`SELECT ARRAY(SELECT id FROM banners ORDER BY id);`,
+	).Scan(&bannerIDs)
+	require.NoError(t, err)
+	require.Equal(t, []int64{3}, bannerIDs)
+	require.Equal(t, audited+2, countAudit())
+}
+
+func Test_parseCatalogPage(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    catalogPage
+		wantErr string
+	}{
+		{
+			name:  "positive, defaults",
+			query: "",
+			want:  catalogPage{limit: catalogDefaultLimit},
+		},
+		{
+			name:  "positive, all parameters",
+			query: "?owner_team=ads&limit=10&offset=20",
+			want:  catalogPage{ownerTeam: "ads", limit: 10, offset: 20},
+		},
+		{
+			name:  "positive, max limit",
+			query: "?limit=1000",
+			want:  catalogPage{limit: catalogMaxLimit},
+		},
+		{
+			name:    "negative, limit above the max",
+			query:   "?limit=1001",
+			wantErr: "limit must not exceed 1000",
+		},
+		{
+			name:    "negative, negative limit",
+			query:   "?limit=-1",
+			wantErr: "invalid limit",
+		},
+		{
+			name:    "negative, invalid offset",
+			query:   "?offset=a",
+			wantErr: "invalid offset",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/tags"+tt.query, nil)
+
+			page, errText := parseCatalogPage(r)
+			require.Equal(t, tt.wantErr, errText)
+			require.Equal(t, tt.want, page)
+		})
+	}
+}
+
+func Test_decodeCatalogUpdate(t *testing.T) {
+	name := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		body     string
+		wantName *string
+		wantErr  string
+	}{
+		{
+			name:     "positive, name trimmed",
+			body:     `{"name":" ads "}`,
+			wantName: name("ads"),
+		},
+		{
+			name: "positive, only the owner team",
+			body: `{"owner_team":"growth"}`,
+		},
+		{
+			name:    "negative, nothing to update",
+			body:    `{}`,
+			wantErr: "nothing to update",
+		},
+		{
+			name:    "negative, blank name",
+			body:    `{"name":"  "}`,
+			wantErr: "name must not be empty",
+		},
+		{
+			name:    "negative, invalid json",
+			body:    `{"name":`,
+			wantErr: "error decoding json request body",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PATCH", "/tag/1", strings.NewReader(tt.body))
+
+			var dto entity.UpdateTagDTO
+			errText := decodeCatalogUpdate(r, &dto, &dto.Name, &dto.Description, &dto.OwnerTeam)
+			require.Equal(t, tt.wantErr, errText)
+			if tt.wantErr == "" {
+				require.Equal(t, tt.wantName, dto.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	clickBannerURL = "/click/{banner_id}"
+)
+
+type ClickBannerUsecase interface {
+	ClickBanner(ctx context.Context, dto entity.ClickBannerDTO) (string, error)
+}
+
+type clickBannerHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     ClickBannerUsecase
+}
+
+func NewClickBannerHandler(usecase ClickBannerUsecase) *clickBannerHandler {
+	return &clickBannerHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+// AddToRouter adds a public route: banner links are followed by browsers,
+// which send no credentials.
+func (h *clickBannerHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = h
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Get(clickBannerURL, handler.ServeHTTP)
+}
+
+func (h *clickBannerHandler) Middlewares(md ...func(http.Handler) http.Handler) *clickBannerHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *clickBannerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ID, err := strconv.ParseInt(chi.URLParam(r, "banner_id"), 10, 64)
+	if err != nil || ID < 1 {
+		http.Error(w, "invalid banner ID", http.StatusBadRequest)
+		return
+	}
+
+	// anonymous clicks are counted on active banners only
+	principal, _ := v1.PrincipalFromContext(r.Context())
+
+	url, err := h.usecase.ClickBanner(r.Context(), entity.ClickBannerDTO{
+		BannerID: ID,
+		IsAdmin:  principal.Can(entity.PermReadBanners),
+	})
+	if err != nil {
+		switch errors.Code(err) {
+		case errors.ErrForbidden:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.ErrNoDataFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	http.Redirect(w, r, url, http.StatusFound)
+
+}
@@ -3,15 +3,20 @@ package v1
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
 	"github.com/The-Gleb/banner_service/internal/domain/entity"
 	"github.com/The-Gleb/banner_service/internal/errors"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 const (
 	createBannerURL = "/banner"
+
+	errActiveBannerForbidden = "only publishers can create active banners"
 )
 
 type CreateBannerUsecase interface {
@@ -32,9 +37,9 @@ func NewCreateBannerHandler(usecase CreateBannerUsecase) *createBannerHandler {
 
 func (h *createBannerHandler) AddToRouter(r *chi.Mux) {
 	var handler http.Handler
-	handler = h
+	handler = v1.RequirePermission(entity.PermEditBanners)(h)
 	for _, md := range h.middlewares {
-		handler = md(h)
+		handler = md(handler)
 	}
 
 	r.Post(createBannerURL, handler.ServeHTTP)
@@ -56,14 +61,33 @@ func (h *createBannerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// TODO: check if dto is valid
+	if msg := validateCreateBannerDTO(dto); msg != "" {
+		slog.Debug("bad request", "error", msg)
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := v1.PrincipalFromContext(r.Context())
+	dto.Principal = principal
+	dto.RequestID = middleware.GetReqID(r.Context())
+
+	if dto.IsActive && !principal.Can(entity.PermPublishBanners) {
+		http.Error(w, errActiveBannerForbidden, http.StatusForbidden)
+		return
+	}
 
 	id, err := h.usecase.CreateBanner(r.Context(), dto)
 	if err != nil {
 		switch errors.Code(err) {
-		case errors.ErrAlreadyExists, errors.ErrFeatureNotFound, errors.ErrTagNotFound:
+		case errors.ErrAlreadyExists:
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case errors.ErrFeatureNotFound, errors.ErrTagNotFound, errors.ErrInvalidInput:
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
+		case errors.ErrForbidden:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
 		default:
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -78,8 +102,34 @@ func (h *createBannerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	w.WriteHeader(http.StatusCreated)
 	w.Write(b)
 
-	w.WriteHeader(http.StatusCreated)
+}
+
+// validateCreateBannerDTO returns a description of the first problem found
+// in the banner, or an empty string if it can be created.
+func validateCreateBannerDTO(dto entity.CreateBannerDTO) string {
+	if dto.FeatureID < 1 {
+		return "invalid feature ID"
+	}
+
+	if len(dto.TagIDs) == 0 {
+		return "tag_ids must not be empty"
+	}
+	for _, tagID := range dto.TagIDs {
+		if tagID < 1 {
+			return "invalid tag ID"
+		}
+	}
+
+	if !dto.Content.Valid() {
+		return "content must have title, text and url"
+	}
+
+	if !dto.Schedule.Valid() {
+		return "starts_at must be before ends_at"
+	}
 
+	return ""
 }
@@ -0,0 +1,264 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/The-Gleb/banner_service/internal/adapter/cache/redis"
+	db "github.com/The-Gleb/banner_service/internal/adapter/db/postgres"
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/service"
+	"github.com/The-Gleb/banner_service/internal/domain/usecase"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCreateBannerUsecase creates banner 5, or fails with err.
+type fakeCreateBannerUsecase struct {
+	err error
+}
+
+func (u fakeCreateBannerUsecase) CreateBanner(ctx context.Context, dto entity.CreateBannerDTO) (int64, error) {
+	return 5, u.err
+}
+
+func Test_createBannerHandler_ServeHTTP(t *testing.T) {
+	const body = `{"tag_ids":[1],"feature_id":1,"content":{"title":"t","text":"x","url":"u"},"is_active":true}`
+
+	type want struct {
+		code int
+		body string
+	}
+	tests := []struct {
+		name      string
+		principal entity.Principal
+		err       error
+		want      want
+	}{
+		{
+			name:      "positive",
+			principal: entity.Principal{TokenID: 1, Role: entity.RolePublisher},
+			want: want{
+				code: 201,
+				body: `{"banner_id":5}`,
+			},
+		},
+		{
+			name:      "negative, editor cannot create active banners",
+			principal: entity.Principal{TokenID: 1, Role: entity.RoleEditor},
+			want: want{
+				code: 403,
+				body: errActiveBannerForbidden,
+			},
+		},
+		{
+			name:      "negative, pair taken",
+			principal: entity.Principal{TokenID: 1, Role: entity.RolePublisher},
+			err:       errors.NewDomainError(errors.ErrAlreadyExists, "banner 1 already has feature 1 and tag 1"),
+			want: want{
+				code: 409,
+				body: "banner 1 already has feature 1 and tag 1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewCreateBannerHandler(fakeCreateBannerUsecase{err: tt.err})
+
+			r := httptest.NewRequest("POST", "/banner", strings.NewReader(body))
+			r = r.WithContext(v1.WithPrincipal(r.Context(), tt.principal))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			require.Equal(t, tt.want.code, w.Code)
+			require.Contains(t, w.Body.String(), tt.want.body)
+		})
+	}
+}
+
+// newAdminTestServer seeds the banner tables and serves the banner editing
+// handlers to the adminToken, with an empty cache. Banner 1 has feature 1, tags 1 and 2 and is at
+// version 1; tag 3 and feature 2 are unused.
+func newAdminTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	c, err := postgresql.NewClient(context.Background(), dsn)
+	require.NoError(t, err)
+
+	err = db.RunMigrations(dsn)
+	require.NoError(t, err)
+
+	cleanTables(
+		t, dsn,
+		"banners", "banner_tag", "banner_feature", "tags", "features", "tokens",
+	)
+
+	_, err = c.Exec(
+		context.Background(),
+		`INSERT INTO tags (id)
+		VALUES (1),(2),(3);
+
+		INSERT INTO features (id)
+		VALUES (1),(2);
+
+		INSERT INTO banners
+		(id, title, text, url, is_active, created_at)
+		VALUES
+			(1, 'title1', 'text1', 'url1', true, NOW());
+
+		INSERT INTO banner_tag (banner_id, tag_id)
+		VALUES
+			(1, 1), (1, 2);
+
+		INSERT INTO banner_feature (banner_id, feature_id)
+		VALUES
+			(1, 1);
+
+		INSERT INTO banner_feature_tag (banner_id, feature_id, tag_id)
+		VALUES
+			(1, 1, 1), (1, 1, 2);
+
+		SELECT setval(pg_get_serial_sequence('banners', 'id'), 1);
+
+		INSERT INTO tokens (prefix, salt, digest, role, created_at)
+		SELECT left(t.token, 8), 'salt'::bytea, sha256('salt'::bytea || convert_to(t.token, 'UTF8')), t.role, NOW()
+		FROM (VALUES
+			('admin_token_for_the_handler_tests', 'admin')
+		) AS t(token, role);`,
+	)
+	require.NoError(t, err)
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     redisAddr,
+		Password: "",
+		DB:       0,
+	})
+	require.NoError(t, redisClient.FlushAll(context.Background()).Err())
+	bannerStorage := db.NewBannerStorage(c)
+	bannerCache := cache.NewRedisCache(redisClient, 3600)
+	bannerService := service.NewBannerService(bannerStorage, bannerCache)
+	statsService := service.NewStatsService(db.NewStatsStorage(c), bannerStorage)
+
+	tokenService := service.NewTokenService(db.NewTokenStorage(c), cache.NewTokenInvalidations(redisClient, bannerCache), 100, time.Minute)
+	jwtService := service.NewJWTService(nil, nil, "", "")
+	checkTokenHandler := v1.NewAuthMiddleware(usecase.NewCheckTokenUsecase(tokenService, jwtService))
+
+	r := chi.NewRouter()
+	NewCreateBannerHandler(usecase.NewCreateBannerUsecase(bannerService)).
+		Middlewares(checkTokenHandler.Do).AddToRouter(r)
+	NewUpdateBannerHandler(usecase.NewUpdateBannerUsecase(bannerService)).
+		Middlewares(checkTokenHandler.Do).AddToRouter(r)
+	NewGetBannerVersionsHandler(usecase.NewGetBannerVersionsUsecase(bannerService)).
+		Middlewares(checkTokenHandler.Do).AddToRouter(r)
+	NewRestoreBannerHandler(usecase.NewRestoreBannerUsecase(bannerService)).
+		Middlewares(checkTokenHandler.Do).AddToRouter(r)
+	NewGetBannerHandler(usecase.NewGetBannerUsecase(bannerService)).
+		Middlewares(checkTokenHandler.Do).AddToRouter(r)
+	NewGetUserBannerHandler(usecase.NewGetUserBannerUsecase(bannerService, statsService)).
+		Middlewares(checkTokenHandler.Do).AddToRouter(r)
+
+	s := httptest.NewServer(r)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func Test_createBannerHandler_ServeHTTP_FeatureTagConflict(t *testing.T) {
+
+	s := newAdminTestServer(t)
+
+	type want struct {
+		code int
+		body string
+	}
+	tests := []struct {
+		name string
+		body string
+		want want
+	}{
+		{
+			name: "negative, pair taken by another banner",
+			body: `{"tag_ids":[3,2],"feature_id":1,"content":{"title":"t","text":"x","url":"u"}}`,
+			want: want{
+				code: 409,
+				body: "banner 1 already has feature 1 and tag 2",
+			},
+		},
+		{
+			name: "positive, same tags with another feature",
+			body: `{"tag_ids":[1,2],"feature_id":2,"content":{"title":"t","text":"x","url":"u"}}`,
+			want: want{
+				code: 201,
+			},
+		},
+		{
+			name: "negative, pair taken by the banner created above",
+			body: `{"tag_ids":[1],"feature_id":2,"content":{"title":"t","text":"x","url":"u"}}`,
+			want: want{
+				code: 409,
+				body: "banner 2 already has feature 2 and tag 1",
+			},
+		},
+		{
+			name: "negative, pair of a missing tag",
+			body: `{"tag_ids":[10],"feature_id":1,"content":{"title":"t","text":"x","url":"u"}}`,
+			want: want{
+				code: 400,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := testRequest(t, s, "POST", "/banner", []byte(tt.body), adminToken)
+
+			require.Equal(t, tt.want.code, resp.StatusCode)
+			require.Contains(t, body, tt.want.body)
+		})
+	}
+
+	t.Run("concurrent creates of the same pair", func(t *testing.T) {
+		const creates = 10
+
+		codes := make(chan int, creates)
+		for i := 0; i < creates; i++ {
+			go func() {
+				req, err := http.NewRequest(
+					"POST", s.URL+"/banner",
+					bytes.NewReader([]byte(`{"tag_ids":[3],"feature_id":2,"content":{"title":"t","text":"x","url":"u"}}`)),
+				)
+				if err != nil {
+					codes <- 0
+					return
+				}
+				req.Header.Set("token", adminToken)
+
+				resp, err := s.Client().Do(req)
+				if err != nil {
+					codes <- 0
+					return
+				}
+				resp.Body.Close()
+				codes <- resp.StatusCode
+			}()
+		}
+
+		created := 0
+		for i := 0; i < creates; i++ {
+			code := <-codes
+			require.Contains(t, []int{201, 409}, code)
+			if code == 201 {
+				created++
+			}
+		}
+		require.Equal(t, 1, created)
+	})
+}
@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	createFeatureURL = "/feature"
+)
+
+type CreateFeatureUsecase interface {
+	CreateFeature(ctx context.Context, dto entity.CreateFeatureDTO) (entity.Feature, error)
+}
+
+type createFeatureHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     CreateFeatureUsecase
+}
+
+func NewCreateFeatureHandler(usecase CreateFeatureUsecase) *createFeatureHandler {
+	return &createFeatureHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *createFeatureHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermManageCatalog)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Post(createFeatureURL, handler.ServeHTTP)
+}
+
+func (h *createFeatureHandler) Middlewares(md ...func(http.Handler) http.Handler) *createFeatureHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *createFeatureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	var dto entity.CreateFeatureDTO
+
+	msg := decodeCatalogCreation(r, &dto, &dto.Name)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	feature, err := h.usecase.CreateFeature(r.Context(), dto)
+	if err != nil {
+		http.Error(w, err.Error(), catalogErrorStatus(err))
+		return
+	}
+
+	b, err := json.Marshal(feature)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+
+}
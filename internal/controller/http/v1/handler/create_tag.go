@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	createTagURL = "/tag"
+)
+
+type CreateTagUsecase interface {
+	CreateTag(ctx context.Context, dto entity.CreateTagDTO) (entity.Tag, error)
+}
+
+type createTagHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     CreateTagUsecase
+}
+
+func NewCreateTagHandler(usecase CreateTagUsecase) *createTagHandler {
+	return &createTagHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *createTagHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermManageCatalog)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Post(createTagURL, handler.ServeHTTP)
+}
+
+func (h *createTagHandler) Middlewares(md ...func(http.Handler) http.Handler) *createTagHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *createTagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	var dto entity.CreateTagDTO
+
+	msg := decodeCatalogCreation(r, &dto, &dto.Name)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.usecase.CreateTag(r.Context(), dto)
+	if err != nil {
+		http.Error(w, err.Error(), catalogErrorStatus(err))
+		return
+	}
+
+	b, err := json.Marshal(tag)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+
+}
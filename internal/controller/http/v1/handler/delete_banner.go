@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"strconv"
 
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
 	"github.com/The-Gleb/banner_service/internal/domain/entity"
 	"github.com/The-Gleb/banner_service/internal/errors"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 const (
@@ -32,9 +34,9 @@ func NewDeleteBannerHandler(usecase DeleteBannerUsecase) *deleteBannerHandler {
 
 func (h *deleteBannerHandler) AddToRouter(r *chi.Mux) {
 	var handler http.Handler
-	handler = h
+	handler = v1.RequirePermission(entity.PermPublishBanners)(h)
 	for _, md := range h.middlewares {
-		handler = md(h)
+		handler = md(handler)
 	}
 
 	r.Delete(deleteBannerURL, handler.ServeHTTP)
@@ -55,12 +57,31 @@ func (h *deleteBannerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = h.usecase.DeleteBanner(r.Context(), entity.DeleteBannerDTO{BannerID: ID})
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		http.Error(w, string(errors.ErrPreconditionFailed), http.StatusPreconditionFailed)
+		return
+	}
+
+	principal, _ := v1.PrincipalFromContext(r.Context())
+
+	err = h.usecase.DeleteBanner(r.Context(), entity.DeleteBannerDTO{
+		BannerID:        ID,
+		ExpectedVersion: expectedVersion,
+		Principal:       principal,
+		RequestID:       middleware.GetReqID(r.Context()),
+	})
 	if err != nil {
 		switch errors.Code(err) {
 		case errors.ErrNoDataFound:
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
+		case errors.ErrPreconditionFailed:
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		case errors.ErrForbidden:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
 		default:
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
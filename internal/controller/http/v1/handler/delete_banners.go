@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	deleteBannersURL = "/banner"
+)
+
+type DeleteBannersUsecase interface {
+	DeleteBanners(ctx context.Context, dto entity.DeleteBannersDTO) (entity.DeleteJob, error)
+}
+
+type deleteBannersHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     DeleteBannersUsecase
+}
+
+func NewDeleteBannersHandler(usecase DeleteBannersUsecase) *deleteBannersHandler {
+	return &deleteBannersHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *deleteBannersHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermPublishBanners)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Delete(deleteBannersURL, handler.ServeHTTP)
+}
+
+func (h *deleteBannersHandler) Middlewares(md ...func(http.Handler) http.Handler) *deleteBannersHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *deleteBannersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	strTagID := r.URL.Query().Get("tag_id")
+	strFeatureID := r.URL.Query().Get("feature_id")
+
+	slog.Debug("query", "tag_id", strTagID, "feature_id", strFeatureID)
+
+	var dto entity.DeleteBannersDTO
+	var err error
+	if strTagID != "" {
+		dto.TagID, err = strconv.ParseInt(strTagID, 10, 64)
+		if err != nil || dto.TagID < 1 {
+			http.Error(w, "invalid tag ID", http.StatusBadRequest)
+			return
+		}
+	}
+	if strFeatureID != "" {
+		dto.FeatureID, err = strconv.ParseInt(strFeatureID, 10, 64)
+		if err != nil || dto.FeatureID < 1 {
+			http.Error(w, "invalid feature ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if dto.TagID == 0 && dto.FeatureID == 0 {
+		http.Error(w, "there must be at least one filter", http.StatusBadRequest)
+		return
+	}
+
+	dto.Principal, _ = v1.PrincipalFromContext(r.Context())
+	dto.RequestID = middleware.GetReqID(r.Context())
+
+	job, err := h.usecase.DeleteBanners(r.Context(), dto)
+	if err != nil {
+		switch errors.Code(err) {
+		case errors.ErrForbidden:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	b, err := json.Marshal(job)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/banner/delete_jobs/%d", job.ID))
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(b)
+
+}
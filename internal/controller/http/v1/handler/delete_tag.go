@@ -0,0 +1,76 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	deleteTagURL = "/tag/{id}"
+)
+
+type DeleteTagUsecase interface {
+	DeleteTag(ctx context.Context, dto entity.DeleteTagDTO) error
+}
+
+type deleteTagHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     DeleteTagUsecase
+}
+
+func NewDeleteTagHandler(usecase DeleteTagUsecase) *deleteTagHandler {
+	return &deleteTagHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *deleteTagHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermManageCatalog)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Delete(deleteTagURL, handler.ServeHTTP)
+}
+
+func (h *deleteTagHandler) Middlewares(md ...func(http.Handler) http.Handler) *deleteTagHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *deleteTagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ID, msg := parseCatalogID(r, "tag")
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	cascade, err := parseCascade(r)
+	if err != nil {
+		http.Error(w, "invalid cascade", http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := v1.PrincipalFromContext(r.Context())
+
+	err = h.usecase.DeleteTag(r.Context(), entity.DeleteTagDTO{
+		TagID:     ID,
+		Cascade:   cascade,
+		Principal: principal,
+		RequestID: middleware.GetReqID(r.Context()),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), catalogErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+}
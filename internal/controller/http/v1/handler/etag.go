@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag formats a banner version as a strong entity tag.
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseIfMatch returns the banner version required by the If-Match header,
+// or nil if the request is unconditional. ok is false if the header can
+// never match a banner version.
+func parseIfMatch(r *http.Request) (version *int64, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return nil, false
+	}
+
+	v, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	return &v, true
+}
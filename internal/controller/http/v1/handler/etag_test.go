@@ -0,0 +1,78 @@
+package v1
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseIfMatch(t *testing.T) {
+	version := func(v int64) *int64 { return &v }
+
+	type want struct {
+		version *int64
+		ok      bool
+	}
+	tests := []struct {
+		name   string
+		header string
+		want   want
+	}{
+		{
+			name:   "positive, no header",
+			header: "",
+			want:   want{ok: true},
+		},
+		{
+			name:   "positive, any version",
+			header: "*",
+			want:   want{ok: true},
+		},
+		{
+			name:   "positive, version",
+			header: `"3"`,
+			want:   want{version: version(3), ok: true},
+		},
+		{
+			name:   "positive, surrounding spaces",
+			header: ` "3" `,
+			want:   want{version: version(3), ok: true},
+		},
+		{
+			name:   "positive, round trip",
+			header: etag(42),
+			want:   want{version: version(42), ok: true},
+		},
+		{
+			name:   "negative, unquoted",
+			header: "3",
+		},
+		{
+			name:   "negative, weak tag",
+			header: `W/"3"`,
+		},
+		{
+			name:   "negative, not a number",
+			header: `"abc"`,
+		},
+		{
+			name:   "negative, list of tags",
+			header: `"3", "4"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := http.NewRequest(http.MethodPatch, "/banner/1", nil)
+			require.NoError(t, err)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+
+			got, ok := parseIfMatch(r)
+
+			require.Equal(t, tt.want.ok, ok)
+			require.Equal(t, tt.want.version, got)
+		})
+	}
+}
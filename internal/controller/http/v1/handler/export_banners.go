@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	exportBannersURL = "/banner/export"
+)
+
+type ExportBannersUsecase interface {
+	ExportBanners(ctx context.Context, dto entity.ExportBannersDTO, fn func(entity.Banner) error) error
+}
+
+type exportBannersHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     ExportBannersUsecase
+}
+
+func NewExportBannersHandler(usecase ExportBannersUsecase) *exportBannersHandler {
+	return &exportBannersHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *exportBannersHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermReadBanners)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Get(exportBannersURL, handler.ServeHTTP)
+}
+
+func (h *exportBannersHandler) Middlewares(md ...func(http.Handler) http.Handler) *exportBannersHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+// ServeHTTP streams the banners in the format POST /banner/import accepts,
+// chosen with ?format=ndjson (the default) or ?format=csv.
+func (h *exportBannersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = bannerFormatNDJSON
+	}
+	if format != bannerFormatNDJSON && format != bannerFormatCSV {
+		http.Error(w, "format must be ndjson or csv", http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := v1.PrincipalFromContext(r.Context())
+	dto := entity.ExportBannersDTO{Principal: principal}
+
+	var (
+		exported int
+		write    func(entity.Banner) error
+		flush    func() error
+	)
+	switch format {
+	case bannerFormatCSV:
+		cw := csv.NewWriter(w)
+		w.Header().Set("Content-Type", csvContentType)
+		write = func(banner entity.Banner) error {
+			if exported == 0 {
+				err := cw.Write(bannerCSVHeader)
+				if err != nil {
+					return err
+				}
+			}
+			return cw.Write(bannerCSVRecord(banner))
+		}
+		flush = func() error {
+			if exported == 0 {
+				cw.Write(bannerCSVHeader)
+			}
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", ndjsonContentType)
+		write = func(banner entity.Banner) error {
+			return enc.Encode(exportedBanner(banner))
+		}
+		flush = func() error { return nil }
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="banners.`+format+`"`)
+
+	err := h.usecase.ExportBanners(r.Context(), dto, func(banner entity.Banner) error {
+		err := write(banner)
+		if err != nil {
+			return err
+		}
+		exported++
+		return nil
+	})
+	if err != nil {
+		slog.Error("error exporting banners", "exported", exported, "error", err)
+		if exported == 0 {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		// the response has started, the client sees a truncated file
+		return
+	}
+
+	err = flush()
+	if err != nil {
+		slog.Error("error writing exported banners", "error", err)
+	}
+
+}
@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	getAuditRecordsURL = "/audit"
+
+	getAuditRecordsDefaultLimit = 100
+	// getAuditRecordsMaxLimit keeps a single request from reading the whole
+	// audit log, which is never trimmed.
+	getAuditRecordsMaxLimit = 1000
+)
+
+type GetAuditRecordsUsecase interface {
+	GetAuditRecords(ctx context.Context, dto entity.GetAuditRecordsDTO) ([]entity.AuditRecord, error)
+}
+
+type getAuditRecordsHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     GetAuditRecordsUsecase
+}
+
+func NewGetAuditRecordsHandler(usecase GetAuditRecordsUsecase) *getAuditRecordsHandler {
+	return &getAuditRecordsHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *getAuditRecordsHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermReadAudit)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Get(getAuditRecordsURL, handler.ServeHTTP)
+}
+
+func (h *getAuditRecordsHandler) Middlewares(md ...func(http.Handler) http.Handler) *getAuditRecordsHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *getAuditRecordsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+	dto := entity.GetAuditRecordsDTO{
+		Actor: query.Get("actor"),
+		Limit: getAuditRecordsDefaultLimit,
+	}
+
+	var err error
+	if strBannerID := query.Get("banner_id"); strBannerID != "" {
+		dto.BannerID, err = strconv.ParseInt(strBannerID, 10, 64)
+		if err != nil || dto.BannerID < 1 {
+			http.Error(w, "invalid banner ID", http.StatusBadRequest)
+			return
+		}
+	}
+	if strFrom := query.Get("from"); strFrom != "" {
+		from, err := parseAuditTime(strFrom)
+		if err != nil {
+			http.Error(w, "invalid from, expected RFC 3339 time or YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		dto.From = &from
+	}
+	if strTo := query.Get("to"); strTo != "" {
+		to, err := parseAuditTime(strTo)
+		if err != nil {
+			http.Error(w, "invalid to, expected RFC 3339 time or YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		dto.To = &to
+	}
+	if strLimit := query.Get("limit"); strLimit != "" {
+		dto.Limit, err = strconv.Atoi(strLimit)
+		if err != nil || dto.Limit < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		if dto.Limit > getAuditRecordsMaxLimit {
+			http.Error(w, "limit must not exceed "+strconv.Itoa(getAuditRecordsMaxLimit), http.StatusBadRequest)
+			return
+		}
+	}
+	if strOffset := query.Get("offset"); strOffset != "" {
+		dto.Offset, err = strconv.Atoi(strOffset)
+		if err != nil || dto.Offset < 0 {
+			http.Error(w, "invalid offset", http.StatusBadRequest)
+			return
+		}
+	}
+
+	records, err := h.usecase.GetAuditRecords(r.Context(), dto)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(records)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+}
+
+// parseAuditTime accepts a point in time or a day, which stands for its
+// midnight in UTC. to is exclusive, so to=2024-04-10 ends before that day.
+func parseAuditTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse(statsDayLayout, s)
+}
@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	getBannerURL = "/banner/{id}"
+)
+
+type GetBannerUsecase interface {
+	GetBanner(ctx context.Context, dto entity.GetBannerDTO) (entity.Banner, error)
+}
+
+type getBannerHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     GetBannerUsecase
+}
+
+func NewGetBannerHandler(usecase GetBannerUsecase) *getBannerHandler {
+	return &getBannerHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *getBannerHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermReadBanners)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Get(getBannerURL, handler.ServeHTTP)
+}
+
+func (h *getBannerHandler) Middlewares(md ...func(http.Handler) http.Handler) *getBannerHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *getBannerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := v1.PrincipalFromContext(r.Context())
+	banner, err := h.usecase.GetBanner(r.Context(), entity.GetBannerDTO{BannerID: ID, Principal: principal})
+	if err != nil {
+		switch errors.Code(err) {
+		case errors.ErrNoDataFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.ErrForbidden:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	b, err := json.Marshal(banner)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", etag(banner.Version))
+	w.Write(b)
+
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	getBannerStatsURL = "/banner/{id}/stats"
+
+	statsDayLayout    = "2006-01-02"
+	statsDefaultDays  = 30
+	statsMaxRangeDays = 366
+)
+
+type GetBannerStatsUsecase interface {
+	GetBannerStats(ctx context.Context, dto entity.GetBannerStatsDTO) ([]entity.BannerStats, error)
+}
+
+type getBannerStatsHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     GetBannerStatsUsecase
+}
+
+func NewGetBannerStatsHandler(usecase GetBannerStatsUsecase) *getBannerStatsHandler {
+	return &getBannerStatsHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *getBannerStatsHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermReadBanners)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Get(getBannerStatsURL, handler.ServeHTTP)
+}
+
+func (h *getBannerStatsHandler) Middlewares(md ...func(http.Handler) http.Handler) *getBannerStatsHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+type bannerDayStats struct {
+	Day         string  `json:"day"`
+	Impressions int64   `json:"impressions"`
+	Clicks      int64   `json:"clicks"`
+	CTR         float64 `json:"ctr"`
+}
+
+func (h *getBannerStatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || ID < 1 {
+		http.Error(w, "invalid banner ID", http.StatusBadRequest)
+		return
+	}
+
+	dto := entity.GetBannerStatsDTO{
+		BannerID: ID,
+		To:       time.Now().UTC().Truncate(24 * time.Hour),
+	}
+
+	if strTo := r.URL.Query().Get("to"); strTo != "" {
+		dto.To, err = time.Parse(statsDayLayout, strTo)
+		if err != nil {
+			http.Error(w, "invalid to date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	dto.From = dto.To.AddDate(0, 0, 1-statsDefaultDays)
+	if strFrom := r.URL.Query().Get("from"); strFrom != "" {
+		dto.From, err = time.Parse(statsDayLayout, strFrom)
+		if err != nil {
+			http.Error(w, "invalid from date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if dto.From.After(dto.To) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+	if dto.To.Sub(dto.From) >= statsMaxRangeDays*24*time.Hour {
+		http.Error(w, "date range is too long", http.StatusBadRequest)
+		return
+	}
+
+	dto.Principal, _ = v1.PrincipalFromContext(r.Context())
+	stats, err := h.usecase.GetBannerStats(r.Context(), dto)
+	if err != nil {
+		switch errors.Code(err) {
+		case errors.ErrNoDataFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.ErrForbidden:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	days := make([]bannerDayStats, 0, len(stats))
+	for _, st := range stats {
+		days = append(days, bannerDayStats{
+			Day:         st.Day.Format(statsDayLayout),
+			Impressions: st.Impressions,
+			Clicks:      st.Clicks,
+			CTR:         st.CTR(),
+		})
+	}
+
+	b, err := json.Marshal(days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(b)
+
+}
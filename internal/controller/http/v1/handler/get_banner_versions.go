@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	getBannerVersionsURL = "/banner/{id}/versions"
+)
+
+type GetBannerVersionsUsecase interface {
+	GetBannerVersions(ctx context.Context, dto entity.GetBannerVersionsDTO) ([]entity.BannerVersion, error)
+}
+
+type getBannerVersionsHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     GetBannerVersionsUsecase
+}
+
+func NewGetBannerVersionsHandler(usecase GetBannerVersionsUsecase) *getBannerVersionsHandler {
+	return &getBannerVersionsHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *getBannerVersionsHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermReadBanners)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Get(getBannerVersionsURL, handler.ServeHTTP)
+}
+
+func (h *getBannerVersionsHandler) Middlewares(md ...func(http.Handler) http.Handler) *getBannerVersionsHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *getBannerVersionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	strID := chi.URLParam(r, "id")
+
+	ID, err := strconv.ParseInt(strID, 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := v1.PrincipalFromContext(r.Context())
+	versions, err := h.usecase.GetBannerVersions(r.Context(), entity.GetBannerVersionsDTO{BannerID: ID, Principal: principal})
+	if err != nil {
+		switch errors.Code(err) {
+		case errors.ErrNoDataFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.ErrForbidden:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = json.NewEncoder(w).Encode(versions)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+}
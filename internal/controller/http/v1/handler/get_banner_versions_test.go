@@ -0,0 +1,85 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_getBannerVersionsHandler_ServeHTTP(t *testing.T) {
+
+	s := newAdminTestServer(t)
+
+	updates := []string{
+		`{"content":{"title":"title2"},"tag_ids":[3],"feature_id":2,"is_active":false}`,
+		`{"content":{"title":"title3"}}`,
+	}
+	for _, body := range updates {
+		resp, _ := testRequest(t, s, "PATCH", "/banner/1", []byte(body), adminToken)
+		require.Equal(t, 200, resp.StatusCode)
+	}
+
+	type want struct {
+		code     int
+		versions []entity.BannerVersion
+	}
+	tests := []struct {
+		name string
+		path string
+		want want
+	}{
+		{
+			name: "positive, previous states newest first",
+			path: "/banner/1/versions",
+			want: want{
+				code: 200,
+				versions: []entity.BannerVersion{
+					{
+						Version:   2,
+						TagIDs:    []int64{3},
+						FeatureID: 2,
+						Content:   entity.BannerContent{Title: "title2", Text: "text1", URL: "url1"},
+						IsActive:  false,
+					},
+					{
+						Version:   1,
+						TagIDs:    []int64{1, 2},
+						FeatureID: 1,
+						Content:   entity.BannerContent{Title: "title1", Text: "text1", URL: "url1"},
+						IsActive:  true,
+					},
+				},
+			},
+		},
+		{
+			name: "negative, unknown banner",
+			path: "/banner/10/versions",
+			want: want{
+				code: 404,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := testRequest(t, s, "GET", tt.path, nil, adminToken)
+
+			require.Equal(t, tt.want.code, resp.StatusCode)
+			if tt.want.code != 200 {
+				return
+			}
+
+			var versions []entity.BannerVersion
+			err := json.Unmarshal([]byte(body), &versions)
+			require.NoError(t, err)
+			require.Len(t, versions, len(tt.want.versions))
+
+			for i := range versions {
+				require.False(t, versions[i].CreatedAt.IsZero())
+				versions[i].CreatedAt = tt.want.versions[i].CreatedAt
+			}
+			require.Equal(t, tt.want.versions, versions)
+		})
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"strconv"
 
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
 	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -15,16 +17,16 @@ const (
 	getBannersURL = "/banner"
 )
 
-type GetBannerUsecase interface {
+type GetBannersUsecase interface {
 	GetBanners(ctx context.Context, dto entity.GetBannersDTO) ([]entity.Banner, error)
 }
 
 type getBannersHandler struct {
 	middlewares []func(http.Handler) http.Handler
-	usecase     GetBannerUsecase
+	usecase     GetBannersUsecase
 }
 
-func NewGetBannersHandler(usecase GetBannerUsecase) *getBannersHandler {
+func NewGetBannersHandler(usecase GetBannersUsecase) *getBannersHandler {
 	return &getBannersHandler{
 		usecase:     usecase,
 		middlewares: make([]func(http.Handler) http.Handler, 0),
@@ -33,9 +35,9 @@ func NewGetBannersHandler(usecase GetBannerUsecase) *getBannersHandler {
 
 func (h *getBannersHandler) AddToRouter(r *chi.Mux) {
 	var handler http.Handler
-	handler = h
+	handler = v1.RequirePermission(entity.PermReadBanners)(h)
 	for _, md := range h.middlewares {
-		handler = md(h)
+		handler = md(handler)
 	}
 
 	r.Get(getBannersURL, handler.ServeHTTP)
@@ -65,7 +67,7 @@ func (h *getBannersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		filters["tag"] = tagID
+		filters[entity.BannerFilterTag] = tagID
 	}
 
 	var featureID int64
@@ -75,7 +77,7 @@ func (h *getBannersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		filters["feature"] = featureID
+		filters[entity.BannerFilterFeature] = featureID
 	}
 
 	if len(filters) < 1 {
@@ -94,14 +96,26 @@ func (h *getBannersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	principal, _ := v1.PrincipalFromContext(r.Context())
+
 	banners, err := h.usecase.GetBanners(r.Context(), entity.GetBannersDTO{
-		Filters: filters,
-		Limit:   limit,
-		Offset:  offset,
+		Filters:   filters,
+		Limit:     limit,
+		Offset:    offset,
+		Principal: principal,
 	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		switch errors.Code(err) {
+		case errors.ErrForbidden:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.ErrInvalidInput:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 	}
 
 	err = json.NewEncoder(w).Encode(banners)
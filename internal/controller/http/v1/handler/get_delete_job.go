@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	getDeleteJobURL = "/banner/delete_jobs/{id}"
+)
+
+type GetDeleteJobUsecase interface {
+	GetDeleteJob(ctx context.Context, dto entity.GetDeleteJobDTO) (entity.DeleteJob, error)
+}
+
+type getDeleteJobHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     GetDeleteJobUsecase
+}
+
+func NewGetDeleteJobHandler(usecase GetDeleteJobUsecase) *getDeleteJobHandler {
+	return &getDeleteJobHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *getDeleteJobHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermReadBanners)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Get(getDeleteJobURL, handler.ServeHTTP)
+}
+
+func (h *getDeleteJobHandler) Middlewares(md ...func(http.Handler) http.Handler) *getDeleteJobHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *getDeleteJobHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := v1.PrincipalFromContext(r.Context())
+	job, err := h.usecase.GetDeleteJob(r.Context(), entity.GetDeleteJobDTO{JobID: ID, Principal: principal})
+	if err != nil {
+		switch errors.Code(err) {
+		case errors.ErrNoDataFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.ErrForbidden:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = json.NewEncoder(w).Encode(job)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+}
@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	getFeaturesURL = "/feature"
+)
+
+type GetFeaturesUsecase interface {
+	GetFeatures(ctx context.Context, dto entity.GetFeaturesDTO) ([]entity.Feature, error)
+}
+
+type getFeaturesHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     GetFeaturesUsecase
+}
+
+func NewGetFeaturesHandler(usecase GetFeaturesUsecase) *getFeaturesHandler {
+	return &getFeaturesHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *getFeaturesHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermReadBanners)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Get(getFeaturesURL, handler.ServeHTTP)
+}
+
+func (h *getFeaturesHandler) Middlewares(md ...func(http.Handler) http.Handler) *getFeaturesHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *getFeaturesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	page, msg := parseCatalogPage(r)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	dto := entity.GetFeaturesDTO{
+		OwnerTeam: page.ownerTeam,
+		Limit:     page.limit,
+		Offset:    page.offset,
+	}
+
+	features, err := h.usecase.GetFeatures(r.Context(), dto)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(features)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+}
@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	getTagsURL = "/tag"
+)
+
+type GetTagsUsecase interface {
+	GetTags(ctx context.Context, dto entity.GetTagsDTO) ([]entity.Tag, error)
+}
+
+type getTagsHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     GetTagsUsecase
+}
+
+func NewGetTagsHandler(usecase GetTagsUsecase) *getTagsHandler {
+	return &getTagsHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *getTagsHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermReadBanners)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Get(getTagsURL, handler.ServeHTTP)
+}
+
+func (h *getTagsHandler) Middlewares(md ...func(http.Handler) http.Handler) *getTagsHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *getTagsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	page, msg := parseCatalogPage(r)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	dto := entity.GetTagsDTO{
+		OwnerTeam: page.ownerTeam,
+		Limit:     page.limit,
+		Offset:    page.offset,
+	}
+
+	tags, err := h.usecase.GetTags(r.Context(), dto)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+}
@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	getTokensURL = "/token"
+
+	getTokensDefaultLimit = 100
+)
+
+type GetTokensUsecase interface {
+	GetTokens(ctx context.Context, dto entity.GetTokensDTO) ([]entity.Token, error)
+}
+
+type getTokensHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     GetTokensUsecase
+}
+
+func NewGetTokensHandler(usecase GetTokensUsecase) *getTokensHandler {
+	return &getTokensHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *getTokensHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermManageTokens)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Get(getTokensURL, handler.ServeHTTP)
+}
+
+func (h *getTokensHandler) Middlewares(md ...func(http.Handler) http.Handler) *getTokensHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *getTokensHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	dto := entity.GetTokensDTO{Limit: getTokensDefaultLimit}
+
+	var err error
+	if strLimit := r.URL.Query().Get("limit"); strLimit != "" {
+		dto.Limit, err = strconv.Atoi(strLimit)
+		if err != nil || dto.Limit < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	if strOffset := r.URL.Query().Get("offset"); strOffset != "" {
+		dto.Offset, err = strconv.Atoi(strOffset)
+		if err != nil || dto.Offset < 0 {
+			http.Error(w, "invalid offset", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tokens, err := h.usecase.GetTokens(r.Context(), dto)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(tokens)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+}
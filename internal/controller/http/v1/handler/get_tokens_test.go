@@ -0,0 +1,63 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_getTokensHandler_ServeHTTP(t *testing.T) {
+
+	s := newTokenTestServer(t)
+
+	type want struct {
+		code int
+		ids  []int64
+	}
+	tests := []struct {
+		name  string
+		query string
+		want  want
+	}{
+		{
+			name: "positive, every token",
+			want: want{code: 200, ids: []int64{1, 2, 3}},
+		},
+		{
+			name:  "positive, page",
+			query: "?limit=1&offset=1",
+			want:  want{code: 200, ids: []int64{2}},
+		},
+		{
+			name:  "negative, invalid limit",
+			query: "?limit=-1",
+			want:  want{code: 400},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := testRequest(t, s, "GET", "/token"+tt.query, nil, adminToken)
+
+			require.Equal(t, tt.want.code, resp.StatusCode)
+			if tt.want.code != 200 {
+				return
+			}
+
+			// neither the secrets nor their digests are listed
+			require.NotContains(t, body, `"token"`)
+			require.NotContains(t, body, "digest")
+			require.NotContains(t, body, "salt")
+
+			var tokens []entity.Token
+			require.NoError(t, json.Unmarshal([]byte(body), &tokens))
+
+			ids := make([]int64, 0, len(tokens))
+			for _, token := range tokens {
+				ids = append(ids, token.ID)
+			}
+			require.Equal(t, tt.want.ids, ids)
+		})
+	}
+}
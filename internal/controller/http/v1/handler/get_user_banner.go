@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
 	"github.com/The-Gleb/banner_service/internal/domain/entity"
@@ -18,7 +19,7 @@ const (
 )
 
 type GetUserBannerUsecase interface {
-	GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.BannerContent, error)
+	GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UserBanner, error)
 }
 
 type getUserBannerHandler struct {
@@ -35,9 +36,9 @@ func NewGetUserBannerHandler(usecase GetUserBannerUsecase) *getUserBannerHandler
 
 func (h *getUserBannerHandler) AddToRouter(r *chi.Mux) {
 	var handler http.Handler
-	handler = h
+	handler = v1.RequirePermission(entity.PermServeBanners)(h)
 	for _, md := range h.middlewares {
-		handler = md(h)
+		handler = md(handler)
 	}
 
 	r.Get(getUserBannerURL, handler.ServeHTTP)
@@ -71,18 +72,18 @@ func (h *getUserBannerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	isAdmin, ok := r.Context().Value(v1.Key("isAdmin")).(bool)
+	principal, ok := v1.PrincipalFromContext(r.Context())
 	if !ok {
-		slog.Error("unable to conver isAdmin context value to bool", "value", r.Context().Value("isAdmin"))
+		slog.Error("no principal in request context")
 		http.Error(w, "", http.StatusInternalServerError)
 		return
 	}
 
-	content, err := h.usecase.GetUserBanner(r.Context(), entity.GetUserBannerDTO{
+	banner, err := h.usecase.GetUserBanner(r.Context(), entity.GetUserBannerDTO{
 		TagID:           tagID,
 		FeatureID:       featureID,
 		UseLastRevision: useLastRevision,
-		IsAdmin:         isAdmin,
+		IsAdmin:         principal.Can(entity.PermReadBanners),
 	})
 	if err != nil {
 		switch errors.Code(err) {
@@ -98,12 +99,19 @@ func (h *getUserBannerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	body, err := json.Marshal(content)
+	body, err := json.Marshal(banner.Content)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	var age time.Duration
+	if !banner.CachedAt.IsZero() {
+		age = max(time.Since(banner.CachedAt), 0)
+	}
+	w.Header().Set("X-Cache", string(banner.Cache))
+	w.Header().Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+
 	w.Write(body)
 
 }
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
@@ -41,6 +42,12 @@ func TestMain(m *testing.M) {
 var dsn string = "postgres://test_db:test_db@:5434/tcp/test_db?sslmode=disable"
 var redisAddr string
 
+// the tokens the fixtures insert
+const (
+	adminToken = "admin_token_for_the_handler_tests"
+	userToken  = "user_token_for_the_handler_tests"
+)
+
 func cleanTables(t *testing.T, dsn string, tableNames ...string) {
 	client, err := postgresql.NewClient(context.Background(), dsn)
 	require.NoError(t, err)
@@ -119,7 +126,7 @@ func createTestPostgresContainer(m *testing.M) (int, error) {
 
 	defer func() {
 		if err := pool.Purge(pg); err != nil {
-			slog.Error("failed to purge the postgres container: %v", err)
+			slog.Error("failed to purge the postgres container", "error", err)
 		}
 	}()
 
@@ -141,7 +148,7 @@ func createTestPostgresContainer(m *testing.M) (int, error) {
 	}
 	defer func() {
 		if err := conn.Close(context.Background()); err != nil {
-			slog.Error("failed to correctly close the connection: %v", err)
+			slog.Error("failed to correctly close the connection", "error", err)
 		}
 	}()
 
@@ -213,11 +220,18 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 		INSERT INTO banner_feature (banner_id, feature_id)
 		VALUES
 			(1, 1), (2, 3), (3, 3);
-			
-		INSERT INTO tokens (token, is_admin, created_at)
+
+		INSERT INTO banner_feature_tag (banner_id, feature_id, tag_id)
 		VALUES
-			('admin_token', true, NOW()),
-			('user_token', false, NOW());`,
+			(1, 1, 1), (1, 1, 2), (1, 1, 3),
+			(2, 3, 4), (3, 3, 5), (3, 3, 2);
+			
+		INSERT INTO tokens (prefix, salt, digest, role, created_at)
+		SELECT left(t.token, 8), 'salt'::bytea, sha256('salt'::bytea || convert_to(t.token, 'UTF8')), t.role, NOW()
+		FROM (VALUES
+			('admin_token_for_the_handler_tests', 'admin'),
+			('user_token_for_the_handler_tests', 'user')
+		) AS t(token, role);`,
 	)
 	require.NoError(t, err)
 
@@ -230,12 +244,14 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 	})
 	bannerCache := cache.NewRedisCache(redisClient, 3600)
 	bannerService := service.NewBannerService(bannerStorage, bannerCache)
-	getUserBannerUsecase := usecase.NewGetUserBannerUsecase(bannerService)
+	statsService := service.NewStatsService(db.NewStatsStorage(c), bannerStorage)
+	getUserBannerUsecase := usecase.NewGetUserBannerUsecase(bannerService, statsService)
 	getUserBannerHandler := NewGetUserBannerHandler(getUserBannerUsecase)
 
 	tokenStorage := db.NewTokenStorage(c)
-	tokenService := service.NewTokenService(tokenStorage)
-	checkTokenUsecase := usecase.NewCheckTokenUsecase(tokenService)
+	tokenService := service.NewTokenService(tokenStorage, cache.NewTokenInvalidations(redisClient, bannerCache), 100, time.Minute)
+	jwtService := service.NewJWTService(nil, nil, "", "")
+	checkTokenUsecase := usecase.NewCheckTokenUsecase(tokenService, jwtService)
 	checkTokenHandler := v1.NewAuthMiddleware(checkTokenUsecase)
 
 	r := chi.NewRouter()
@@ -245,6 +261,7 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 	type want struct {
 		code    int
 		content entity.BannerContent
+		cache   entity.CacheStatus
 	}
 	tests := []struct {
 		name            string
@@ -260,7 +277,7 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 			tagID:           1,
 			featureID:       1,
 			useLastRevision: true,
-			token:           "admin_token",
+			token:           adminToken,
 			sleepDur:        1,
 			want: want{
 				code: 200,
@@ -269,6 +286,7 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 					Text:  "text1",
 					URL:   "url1",
 				},
+				cache: entity.CacheBypass,
 			},
 		},
 		{
@@ -276,15 +294,16 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 			tagID:           1,
 			featureID:       1,
 			useLastRevision: false,
-			token:           "admin_token",
+			token:           adminToken,
 			sleepDur:        0,
 			want: want{
 				code: 200,
 				content: entity.BannerContent{
-					Title: "title1 from cache",
+					Title: "title1",
 					Text:  "text1",
 					URL:   "url1",
 				},
+				cache: entity.CacheHit,
 			},
 		},
 		{
@@ -292,7 +311,7 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 			tagID:           4,
 			featureID:       3,
 			useLastRevision: true,
-			token:           "admin_token",
+			token:           adminToken,
 			sleepDur:        0,
 			want: want{
 				code: 200,
@@ -301,6 +320,7 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 					Text:  "text2",
 					URL:   "url2",
 				},
+				cache: entity.CacheBypass,
 			},
 		},
 		{
@@ -308,7 +328,7 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 			tagID:           4,
 			featureID:       3,
 			useLastRevision: true,
-			token:           "user_token",
+			token:           userToken,
 			sleepDur:        0,
 			want: want{
 				code: 403,
@@ -319,15 +339,16 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 			tagID:           4,
 			featureID:       3,
 			useLastRevision: false,
-			token:           "admin_token",
+			token:           adminToken,
 			sleepDur:        0,
 			want: want{
 				code: 200,
 				content: entity.BannerContent{
-					Title: "title2 from cache",
+					Title: "title2",
 					Text:  "text2",
 					URL:   "url2",
 				},
+				cache: entity.CacheHit,
 			},
 		},
 		{
@@ -335,17 +356,49 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 			tagID:           4,
 			featureID:       3,
 			useLastRevision: false,
-			token:           "user_token",
+			token:           userToken,
 			sleepDur:        0,
 			want: want{
 				code: 403,
 			},
 		},
+		{
+			name:            "positive, from db, not active, admin, not cached yet",
+			tagID:           5,
+			featureID:       3,
+			useLastRevision: false,
+			token:           adminToken,
+			want: want{
+				code: 200,
+				content: entity.BannerContent{
+					Title: "title3",
+					Text:  "text3",
+					URL:   "url3",
+				},
+				cache: entity.CacheMiss,
+			},
+		},
+		{
+			name:            "positive, from cache after miss, not active, admin",
+			tagID:           5,
+			featureID:       3,
+			useLastRevision: false,
+			token:           adminToken,
+			want: want{
+				code: 200,
+				content: entity.BannerContent{
+					Title: "title3",
+					Text:  "text3",
+					URL:   "url3",
+				},
+				cache: entity.CacheHit,
+			},
+		},
 		{
 			name:      "negative, bad request",
 			tagID:     0,
 			featureID: -1,
-			token:     "user_token",
+			token:     userToken,
 			want: want{
 				code: 400,
 			},
@@ -354,7 +407,7 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 			name:      "negative, not found",
 			tagID:     5,
 			featureID: 10,
-			token:     "user_token",
+			token:     userToken,
 			want: want{
 				code: 404,
 			},
@@ -379,7 +432,7 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 			// require.NoError(t, err)
 			// r.Header.Set("token", tt.token)
 
-			// if tt.token == "admin_token" {
+			// if tt.token == adminToken {
 			// 	r = r.WithContext(context.WithValue(context.Background(), "isAdmin", true))
 			// } else {
 			// 	r = r.WithContext(context.WithValue(context.Background(), "isAdmin", false))
@@ -407,6 +460,14 @@ func Test_getUserBannerHandler_ServeHTTP(t *testing.T) {
 
 			require.EqualValues(t, tt.want.content, content)
 
+			require.Equal(t, string(tt.want.cache), resp.Header.Get("X-Cache"))
+			age, err := strconv.Atoi(resp.Header.Get("Age"))
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, age, 0)
+			if tt.want.cache != entity.CacheHit {
+				require.Zero(t, age)
+			}
+
 		})
 	}
 }
@@ -0,0 +1,150 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"mime"
+	"net/http"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	importBannersURL = "/banner/import"
+
+	importMaxBodySize = 32 << 20
+	importMaxLines    = 10000
+)
+
+type ImportBannersUsecase interface {
+	ImportBanners(ctx context.Context, dto entity.ImportBannersDTO) (entity.ImportResult, error)
+}
+
+type importBannersHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     ImportBannersUsecase
+}
+
+func NewImportBannersHandler(usecase ImportBannersUsecase) *importBannersHandler {
+	return &importBannersHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *importBannersHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermEditBanners)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Post(importBannersURL, handler.ServeHTTP)
+}
+
+func (h *importBannersHandler) Middlewares(md ...func(http.Handler) http.Handler) *importBannersHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+// ServeHTTP creates all banners of the file or none of them. Every line is
+// checked like a POST /banner body; if any line is invalid, the response is
+// 400 with the problems of all lines.
+func (h *importBannersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	body := http.MaxBytesReader(w, r.Body, importMaxBodySize)
+
+	mediaType := ndjsonContentType
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		var err error
+		mediaType, _, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			http.Error(w, "invalid Content-Type", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var (
+		lines []bannerLine
+		err   error
+	)
+	switch mediaType {
+	case ndjsonContentType:
+		lines, err = readBannersNDJSON(body)
+	case csvContentType:
+		lines, err = readBannersCSV(body)
+	default:
+		http.Error(w, "Content-Type must be "+ndjsonContentType+" or "+csvContentType, http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stdErrors.As(err, &maxBytesErr) {
+			http.Error(w, "import file is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(lines) == 0 {
+		http.Error(w, "nothing to import", http.StatusBadRequest)
+		return
+	}
+	if len(lines) > importMaxLines {
+		http.Error(w, "too many banners in one import", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	principal, _ := v1.PrincipalFromContext(r.Context())
+	requestID := middleware.GetReqID(r.Context())
+
+	var result entity.ImportResult
+	dto := entity.ImportBannersDTO{Rows: make([]entity.ImportRow, 0, len(lines))}
+	for _, l := range lines {
+		var msg string
+		switch {
+		case l.err != nil:
+			msg = l.err.Error()
+		case l.banner.IsActive && !principal.Can(entity.PermPublishBanners):
+			msg = errActiveBannerForbidden
+		default:
+			msg = validateCreateBannerDTO(l.banner)
+		}
+		if msg != "" {
+			result.Errors = append(result.Errors, entity.ImportError{Line: l.line, Error: msg})
+			continue
+		}
+
+		l.banner.Principal = principal
+		l.banner.RequestID = requestID
+		dto.Rows = append(dto.Rows, entity.ImportRow{Line: l.line, Banner: l.banner})
+	}
+
+	if len(result.Errors) == 0 {
+		result, err = h.usecase.ImportBanners(r.Context(), dto)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	status := http.StatusCreated
+	if len(result.Errors) > 0 {
+		status = http.StatusBadRequest
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+	w.Write(b)
+
+}
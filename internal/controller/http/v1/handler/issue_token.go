@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	issueTokenURL = "/token"
+)
+
+type IssueTokenUsecase interface {
+	IssueToken(ctx context.Context, dto entity.IssueTokenDTO) (entity.Token, error)
+}
+
+type issueTokenHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     IssueTokenUsecase
+}
+
+func NewIssueTokenHandler(usecase IssueTokenUsecase) *issueTokenHandler {
+	return &issueTokenHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *issueTokenHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermManageTokens)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Post(issueTokenURL, handler.ServeHTTP)
+}
+
+func (h *issueTokenHandler) Middlewares(md ...func(http.Handler) http.Handler) *issueTokenHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *issueTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	var dto entity.IssueTokenDTO
+
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		http.Error(w, "error decoding json request body", http.StatusBadRequest)
+		return
+	}
+
+	if dto.Role == "" {
+		dto.Role = entity.RoleUser
+	}
+	if !dto.Role.Valid() {
+		http.Error(w, "unknown role", http.StatusBadRequest)
+		return
+	}
+
+	for _, featureID := range dto.FeatureIDs {
+		if featureID < 1 {
+			http.Error(w, "invalid feature ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if dto.ExpiresAt != nil && !dto.ExpiresAt.After(time.Now()) {
+		http.Error(w, "expires_at must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.usecase.IssueToken(r.Context(), dto)
+	if err != nil {
+		switch errors.Code(err) {
+		case errors.ErrFeatureNotFound:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	b, err := json.Marshal(token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+
+}
@@ -0,0 +1,153 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cache "github.com/The-Gleb/banner_service/internal/adapter/cache/redis"
+	db "github.com/The-Gleb/banner_service/internal/adapter/db/postgres"
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/service"
+	"github.com/The-Gleb/banner_service/internal/domain/usecase"
+	"github.com/The-Gleb/banner_service/pkg/client/postgresql"
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// the tokens newTokenTestServer inserts besides the adminToken
+const (
+	expiredToken = "expired_token_for_the_handler_tests"
+	revokedToken = "revoked_token_for_the_handler_tests"
+)
+
+// newTokenTestServer seeds the tokens and serves the token management
+// handlers. Token 1 is the adminToken, token 2 the expiredToken and token 3
+// the revokedToken, all of them admins.
+func newTokenTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	c, err := postgresql.NewClient(context.Background(), dsn)
+	require.NoError(t, err)
+
+	err = db.RunMigrations(dsn)
+	require.NoError(t, err)
+
+	cleanTables(t, dsn, "tokens")
+
+	_, err = c.Exec(
+		context.Background(),
+		`INSERT INTO tokens (id, prefix, salt, digest, label, role, expires_at, revoked_at, created_at)
+		SELECT t.id, left(t.token, 8), 'salt'::bytea, sha256('salt'::bytea || convert_to(t.token, 'UTF8')),
+			t.label, 'admin', t.expires_at, t.revoked_at, NOW()
+		FROM (VALUES
+			(1, 'admin_token_for_the_handler_tests', 'admin', NULL::timestamptz, NULL::timestamptz),
+			(2, 'expired_token_for_the_handler_tests', 'expired', NOW() - INTERVAL '1 hour', NULL),
+			(3, 'revoked_token_for_the_handler_tests', 'revoked', NULL, NOW())
+		) AS t(id, token, label, expires_at, revoked_at);
+
+		SELECT setval(pg_get_serial_sequence('tokens', 'id'), 3);`,
+	)
+	require.NoError(t, err)
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     redisAddr,
+		Password: "",
+		DB:       0,
+	})
+	bannerCache := cache.NewRedisCache(redisClient, 3600)
+
+	tokenService := service.NewTokenService(db.NewTokenStorage(c), cache.NewTokenInvalidations(redisClient, bannerCache), 100, time.Minute)
+	jwtService := service.NewJWTService(nil, nil, "", "")
+	checkTokenHandler := v1.NewAuthMiddleware(usecase.NewCheckTokenUsecase(tokenService, jwtService))
+
+	r := chi.NewRouter()
+	NewIssueTokenHandler(usecase.NewIssueTokenUsecase(tokenService)).
+		Middlewares(checkTokenHandler.Do).AddToRouter(r)
+	NewGetTokensHandler(usecase.NewGetTokensUsecase(tokenService)).
+		Middlewares(checkTokenHandler.Do).AddToRouter(r)
+	NewUpdateTokenHandler(usecase.NewUpdateTokenUsecase(tokenService)).
+		Middlewares(checkTokenHandler.Do).AddToRouter(r)
+	NewRevokeTokenHandler(usecase.NewRevokeTokenUsecase(tokenService)).
+		Middlewares(checkTokenHandler.Do).AddToRouter(r)
+
+	s := httptest.NewServer(r)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func Test_issueTokenHandler_ServeHTTP(t *testing.T) {
+
+	s := newTokenTestServer(t)
+
+	type want struct {
+		code int
+		role entity.Role
+	}
+	tests := []struct {
+		name  string
+		token string
+		body  string
+		want  want
+	}{
+		{
+			name:  "positive",
+			token: adminToken,
+			body:  `{"label":"ci","role":"editor"}`,
+			want:  want{code: 201, role: entity.RoleEditor},
+		},
+		{
+			name:  "positive, user by default",
+			token: adminToken,
+			body:  `{"label":"ci"}`,
+			want:  want{code: 201, role: entity.RoleUser},
+		},
+		{
+			name:  "negative, unknown role",
+			token: adminToken,
+			body:  `{"label":"ci","role":"root"}`,
+			want:  want{code: 400},
+		},
+		{
+			name:  "negative, expired token",
+			token: expiredToken,
+			body:  `{"label":"ci"}`,
+			want:  want{code: 401},
+		},
+		{
+			name:  "negative, revoked token",
+			token: revokedToken,
+			body:  `{"label":"ci"}`,
+			want:  want{code: 401},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := testRequest(t, s, "POST", "/token", []byte(tt.body), tt.token)
+
+			require.Equal(t, tt.want.code, resp.StatusCode)
+			if tt.want.code != 201 {
+				return
+			}
+
+			var token entity.Token
+			require.NoError(t, json.Unmarshal([]byte(body), &token))
+			require.Equal(t, tt.want.role, token.Role)
+			require.Equal(t, "ci", token.Label)
+			require.GreaterOrEqual(t, len(token.Token), 32)
+			require.Equal(t, token.Token[:8], token.Prefix)
+
+			// the secret is accepted, and only ever returned on issue
+			resp, _ = testRequest(t, s, "GET", "/token", nil, token.Token)
+			require.Equal(t, 403, resp.StatusCode)
+
+			_, body = testRequest(t, s, "GET", "/token", nil, adminToken)
+			require.NotContains(t, body, token.Token)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	restoreBannerURL = "/banner/{id}/versions/{version}/restore"
+)
+
+type RestoreBannerUsecase interface {
+	RestoreBanner(ctx context.Context, dto entity.RestoreBannerDTO) (int64, error)
+}
+
+type restoreBannerHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     RestoreBannerUsecase
+}
+
+func NewRestoreBannerHandler(usecase RestoreBannerUsecase) *restoreBannerHandler {
+	return &restoreBannerHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *restoreBannerHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermPublishBanners)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Post(restoreBannerURL, handler.ServeHTTP)
+}
+
+func (h *restoreBannerHandler) Middlewares(md ...func(http.Handler) http.Handler) *restoreBannerHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *restoreBannerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, err := strconv.ParseInt(chi.URLParam(r, "version"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		http.Error(w, string(errors.ErrPreconditionFailed), http.StatusPreconditionFailed)
+		return
+	}
+
+	principal, _ := v1.PrincipalFromContext(r.Context())
+
+	newVersion, err := h.usecase.RestoreBanner(r.Context(), entity.RestoreBannerDTO{
+		BannerID:        ID,
+		Version:         version,
+		ExpectedVersion: expectedVersion,
+		Principal:       principal,
+		RequestID:       middleware.GetReqID(r.Context()),
+	})
+	if err != nil {
+		switch errors.Code(err) {
+		case errors.ErrNoDataFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.ErrPreconditionFailed:
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		case errors.ErrAlreadyExists:
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case errors.ErrFeatureNotFound, errors.ErrTagNotFound:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.ErrForbidden:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("ETag", etag(newVersion))
+	w.WriteHeader(http.StatusOK)
+
+}
@@ -0,0 +1,116 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_restoreBannerHandler_ServeHTTP(t *testing.T) {
+
+	s := newAdminTestServer(t)
+
+	// version 2 moves banner 1 to feature 2 and tag 3 and deactivates it
+	resp, _ := testRequest(
+		t, s, "PATCH", "/banner/1",
+		[]byte(`{"content":{"title":"title2"},"tag_ids":[3],"feature_id":2,"is_active":false}`), adminToken,
+	)
+	require.Equal(t, 200, resp.StatusCode)
+
+	// version 2 is cached under its feature and tag
+	for _, cacheStatus := range []entity.CacheStatus{entity.CacheMiss, entity.CacheHit} {
+		resp, _ = testRequest(t, s, "GET", "/user_banner?tag_id=3&feature_id=2&use_last_revision=false", nil, adminToken)
+		require.Equal(t, 200, resp.StatusCode)
+		require.Equal(t, string(cacheStatus), resp.Header.Get("X-Cache"))
+	}
+
+	type want struct {
+		code int
+		etag string
+	}
+	tests := []struct {
+		name string
+		// setup runs before the restore
+		setup func(t *testing.T)
+		path  string
+		want  want
+	}{
+		{
+			name: "negative, unknown version",
+			path: "/banner/1/versions/5/restore",
+			want: want{code: 404},
+		},
+		{
+			name: "negative, unknown banner",
+			path: "/banner/10/versions/1/restore",
+			want: want{code: 404},
+		},
+		{
+			name: "positive, version 1",
+			path: "/banner/1/versions/1/restore",
+			want: want{code: 200, etag: `"3"`},
+		},
+		{
+			name: "negative, pair of the version taken since",
+			setup: func(t *testing.T) {
+				resp, _ := testRequest(
+					t, s, "POST", "/banner",
+					[]byte(`{"tag_ids":[3],"feature_id":2,"content":{"title":"t","text":"x","url":"u"}}`), adminToken,
+				)
+				require.Equal(t, 201, resp.StatusCode)
+			},
+			path: "/banner/1/versions/2/restore",
+			want: want{code: 409},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(t)
+			}
+
+			resp, _ := testRequest(t, s, "POST", tt.path, nil, adminToken)
+
+			require.Equal(t, tt.want.code, resp.StatusCode)
+			require.Equal(t, tt.want.etag, resp.Header.Get("ETag"))
+		})
+	}
+
+	// the restore brought back the content, tags, feature and active flag of
+	// version 1 as version 3, and the failed restore changed nothing
+	resp, body := testRequest(t, s, "GET", "/banner/1", nil, adminToken)
+	require.Equal(t, 200, resp.StatusCode)
+
+	var banner entity.Banner
+	require.NoError(t, json.Unmarshal([]byte(body), &banner))
+	require.Equal(t, int64(3), banner.Version)
+	require.Equal(t, []int64{1, 2}, banner.TagIDs)
+	require.Equal(t, int64(1), banner.FeatureID)
+	require.Equal(t, entity.BannerContent{Title: "title1", Text: "text1", URL: "url1"}, banner.Content)
+	require.True(t, banner.IsActive)
+
+	// the state replaced by the restore is kept as a version too
+	resp, body = testRequest(t, s, "GET", "/banner/1/versions", nil, adminToken)
+	require.Equal(t, 200, resp.StatusCode)
+
+	var versions []entity.BannerVersion
+	require.NoError(t, json.Unmarshal([]byte(body), &versions))
+	require.Len(t, versions, 2)
+	require.Equal(t, int64(2), versions[0].Version)
+	require.Equal(t, "title2", versions[0].Content.Title)
+
+	// the banner cached for version 2 was invalidated: its old pair now
+	// serves the banner created above, and its restored pairs serve version 1
+	for _, tagID := range []int64{1, 2} {
+		resp, body = testRequest(t, s, "GET", fmt.Sprintf("/user_banner?tag_id=%d&feature_id=1&use_last_revision=false", tagID), nil, adminToken)
+		require.Equal(t, 200, resp.StatusCode)
+		require.Contains(t, body, "title1")
+	}
+	resp, body = testRequest(t, s, "GET", "/user_banner?tag_id=3&feature_id=2&use_last_revision=false", nil, adminToken)
+	require.Equal(t, 200, resp.StatusCode)
+	require.Equal(t, string(entity.CacheMiss), resp.Header.Get("X-Cache"))
+	require.NotContains(t, body, "title2")
+}
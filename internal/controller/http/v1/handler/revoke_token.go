@@ -0,0 +1,78 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	revokeTokenURL = "/token/{id}/revoke"
+)
+
+type RevokeTokenUsecase interface {
+	RevokeToken(ctx context.Context, dto entity.RevokeTokenDTO) (entity.Token, error)
+}
+
+type revokeTokenHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     RevokeTokenUsecase
+}
+
+func NewRevokeTokenHandler(usecase RevokeTokenUsecase) *revokeTokenHandler {
+	return &revokeTokenHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *revokeTokenHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermManageTokens)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Post(revokeTokenURL, handler.ServeHTTP)
+}
+
+func (h *revokeTokenHandler) Middlewares(md ...func(http.Handler) http.Handler) *revokeTokenHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *revokeTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || ID < 1 {
+		http.Error(w, "invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.usecase.RevokeToken(r.Context(), entity.RevokeTokenDTO{TokenID: ID})
+	if err != nil {
+		switch errors.Code(err) {
+		case errors.ErrNoDataFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	b, err := json.Marshal(token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(b)
+
+}
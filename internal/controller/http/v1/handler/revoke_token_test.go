@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_revokeTokenHandler_ServeHTTP(t *testing.T) {
+
+	s := newTokenTestServer(t)
+
+	resp, body := testRequest(t, s, "POST", "/token", []byte(`{"label":"ci","role":"admin"}`), adminToken)
+	require.Equal(t, 201, resp.StatusCode)
+
+	var issued entity.Token
+	require.NoError(t, json.Unmarshal([]byte(body), &issued))
+
+	resp, _ = testRequest(t, s, "GET", "/token", nil, issued.Token)
+	require.Equal(t, 200, resp.StatusCode)
+
+	type want struct {
+		code int
+	}
+	tests := []struct {
+		name string
+		path string
+		want want
+	}{
+		{
+			name: "positive",
+			path: fmt.Sprintf("/token/%d/revoke", issued.ID),
+			want: want{code: 200},
+		},
+		{
+			name: "positive, revoked twice",
+			path: fmt.Sprintf("/token/%d/revoke", issued.ID),
+			want: want{code: 200},
+		},
+		{
+			name: "negative, unknown token",
+			path: "/token/10/revoke",
+			want: want{code: 404},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := testRequest(t, s, "POST", tt.path, nil, adminToken)
+
+			require.Equal(t, tt.want.code, resp.StatusCode)
+			if tt.want.code != 200 {
+				return
+			}
+
+			var token entity.Token
+			require.NoError(t, json.Unmarshal([]byte(body), &token))
+			require.Equal(t, issued.ID, token.ID)
+			require.NotNil(t, token.RevokedAt)
+			require.Empty(t, token.Token)
+		})
+	}
+
+	// the revoked token is rejected from then on
+	resp, _ = testRequest(t, s, "GET", "/token", nil, issued.Token)
+	require.Equal(t, 401, resp.StatusCode)
+}
@@ -1,15 +1,18 @@
 package v1
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
 	"strconv"
 
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
 	"github.com/The-Gleb/banner_service/internal/domain/entity"
 	"github.com/The-Gleb/banner_service/internal/errors"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 const (
@@ -17,7 +20,7 @@ const (
 )
 
 type UpdateBannerUsecase interface {
-	UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) error
+	UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) (int64, error)
 }
 
 type updateBannerHandler struct {
@@ -34,9 +37,9 @@ func NewUpdateBannerHandler(usecase UpdateBannerUsecase) *updateBannerHandler {
 
 func (h *updateBannerHandler) AddToRouter(r *chi.Mux) {
 	var handler http.Handler
-	handler = h
+	handler = v1.RequirePermission(entity.PermEditBanners)(h)
 	for _, md := range h.middlewares {
-		handler = md(h)
+		handler = md(handler)
 	}
 
 	r.Patch(updateBannerURL, handler.ServeHTTP)
@@ -67,29 +70,95 @@ func (h *updateBannerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 
 	dto.BannerID = ID
 
-	// is_active will updated to false until otherwise stated
-	if dto.BannerID == 0 || dto.FeatureID == 0 || len(dto.TagIDs) == 0 ||
-		dto.Content.Title == "" || dto.Content.Text == "" || dto.Content.URL == "" {
-		slog.Debug("bad request", "error", "banner id must be specified")
-		http.Error(w, "banner id must be specified", http.StatusBadRequest)
+	var ok bool
+	dto.ExpectedVersion, ok = parseIfMatch(r)
+	if !ok {
+		http.Error(w, string(errors.ErrPreconditionFailed), http.StatusPreconditionFailed)
 		return
 	}
 
-	err = h.usecase.UpdateBanner(r.Context(), dto)
+	if msg := validateUpdateBannerDTO(dto); msg != "" {
+		slog.Debug("bad request", "error", msg)
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := v1.PrincipalFromContext(r.Context())
+	dto.Principal = principal
+	dto.RequestID = middleware.GetReqID(r.Context())
+
+	if (dto.IsActive != nil || dto.StartsAt.Set || dto.EndsAt.Set) && !principal.Can(entity.PermPublishBanners) {
+		http.Error(w, "only publishers can change is_active, starts_at and ends_at", http.StatusForbidden)
+		return
+	}
+
+	version, err := h.usecase.UpdateBanner(r.Context(), dto)
 	if err != nil {
 		switch errors.Code(err) {
 		case errors.ErrNoDataFound:
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
-		case errors.ErrAlreadyExists, errors.ErrFeatureNotFound, errors.ErrTagNotFound:
+		case errors.ErrPreconditionFailed:
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		case errors.ErrAlreadyExists:
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case errors.ErrFeatureNotFound, errors.ErrTagNotFound, errors.ErrInvalidInput:
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
+		case errors.ErrForbidden:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
 		default:
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 	}
 
+	w.Header().Set("ETag", etag(version))
 	w.WriteHeader(http.StatusOK)
 
 }
+
+// validateUpdateBannerDTO returns a description of the first problem found
+// in the patch, or an empty string if it can be applied.
+func validateUpdateBannerDTO(dto entity.UpdateBannerDTO) string {
+	if dto.BannerID < 1 {
+		return "invalid banner ID"
+	}
+
+	if dto.TagIDs == nil && dto.FeatureID == nil && dto.Content == nil && dto.IsActive == nil &&
+		!dto.StartsAt.Set && !dto.EndsAt.Set {
+		return "nothing to update"
+	}
+
+	if dto.TagIDs != nil {
+		if len(dto.TagIDs) == 0 {
+			return "tag_ids must not be empty"
+		}
+		for _, tagID := range dto.TagIDs {
+			if tagID < 1 {
+				return "invalid tag ID"
+			}
+		}
+	}
+
+	if dto.FeatureID != nil && *dto.FeatureID < 1 {
+		return "invalid feature ID"
+	}
+
+	if dto.Content != nil {
+		trimmed := bytes.TrimSpace(dto.Content)
+		if len(trimmed) == 0 || trimmed[0] != '{' {
+			return "content must be a JSON object"
+		}
+	}
+
+	schedule := entity.Schedule{StartsAt: dto.StartsAt.Time, EndsAt: dto.EndsAt.Time}
+	if !schedule.Valid() {
+		return "starts_at must be before ends_at"
+	}
+
+	return ""
+}
@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testRequestWithHeader(
+	t *testing.T, ts *httptest.Server,
+	method, path string, body []byte, token string, header http.Header,
+) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, ts.URL+path, bytes.NewReader(body))
+	require.NoError(t, err)
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if token != "" {
+		req.Header.Set("token", token)
+	}
+
+	resp, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp, string(respBody)
+}
+
+func Test_updateBannerHandler_ServeHTTP_IfMatch(t *testing.T) {
+
+	s := newAdminTestServer(t)
+
+	type want struct {
+		code int
+		etag string
+	}
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    want
+	}{
+		{
+			name:    "positive, current version",
+			ifMatch: `"1"`,
+			want: want{
+				code: 200,
+				etag: `"2"`,
+			},
+		},
+		{
+			name:    "negative, stale version",
+			ifMatch: `"1"`,
+			want: want{
+				code: 412,
+			},
+		},
+		{
+			name:    "negative, version from the future",
+			ifMatch: `"5"`,
+			want: want{
+				code: 412,
+			},
+		},
+		{
+			name:    "negative, malformed",
+			ifMatch: "2",
+			want: want{
+				code: 412,
+			},
+		},
+		{
+			name:    "positive, any version",
+			ifMatch: "*",
+			want: want{
+				code: 200,
+				etag: `"3"`,
+			},
+		},
+		{
+			name: "positive, unconditional",
+			want: want{
+				code: 200,
+				etag: `"4"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := make(http.Header)
+			if tt.ifMatch != "" {
+				header.Set("If-Match", tt.ifMatch)
+			}
+
+			body := []byte(`{"content":{"title":"` + tt.name + `"}}`)
+			resp, _ := testRequestWithHeader(t, s, "PATCH", "/banner/1", body, adminToken, header)
+
+			require.Equal(t, tt.want.code, resp.StatusCode)
+			require.Equal(t, tt.want.etag, resp.Header.Get("ETag"))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	updateFeatureURL = "/feature/{id}"
+)
+
+type UpdateFeatureUsecase interface {
+	UpdateFeature(ctx context.Context, dto entity.UpdateFeatureDTO) (entity.Feature, error)
+}
+
+type updateFeatureHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     UpdateFeatureUsecase
+}
+
+func NewUpdateFeatureHandler(usecase UpdateFeatureUsecase) *updateFeatureHandler {
+	return &updateFeatureHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *updateFeatureHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermManageCatalog)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Patch(updateFeatureURL, handler.ServeHTTP)
+}
+
+func (h *updateFeatureHandler) Middlewares(md ...func(http.Handler) http.Handler) *updateFeatureHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *updateFeatureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ID, msg := parseCatalogID(r, "feature")
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	var dto entity.UpdateFeatureDTO
+
+	msg = decodeCatalogUpdate(r, &dto, &dto.Name, &dto.Description, &dto.OwnerTeam)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	dto.FeatureID = ID
+
+	feature, err := h.usecase.UpdateFeature(r.Context(), dto)
+	if err != nil {
+		http.Error(w, err.Error(), catalogErrorStatus(err))
+		return
+	}
+
+	b, err := json.Marshal(feature)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(b)
+
+}
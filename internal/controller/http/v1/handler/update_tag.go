@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	updateTagURL = "/tag/{id}"
+)
+
+type UpdateTagUsecase interface {
+	UpdateTag(ctx context.Context, dto entity.UpdateTagDTO) (entity.Tag, error)
+}
+
+type updateTagHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     UpdateTagUsecase
+}
+
+func NewUpdateTagHandler(usecase UpdateTagUsecase) *updateTagHandler {
+	return &updateTagHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *updateTagHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermManageCatalog)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Patch(updateTagURL, handler.ServeHTTP)
+}
+
+func (h *updateTagHandler) Middlewares(md ...func(http.Handler) http.Handler) *updateTagHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *updateTagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ID, msg := parseCatalogID(r, "tag")
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	var dto entity.UpdateTagDTO
+
+	msg = decodeCatalogUpdate(r, &dto, &dto.Name, &dto.Description, &dto.OwnerTeam)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	dto.TagID = ID
+
+	tag, err := h.usecase.UpdateTag(r.Context(), dto)
+	if err != nil {
+		http.Error(w, err.Error(), catalogErrorStatus(err))
+		return
+	}
+
+	b, err := json.Marshal(tag)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(b)
+
+}
@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	v1 "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	updateTokenURL = "/token/{id}"
+)
+
+type UpdateTokenUsecase interface {
+	UpdateToken(ctx context.Context, dto entity.UpdateTokenDTO) (entity.Token, error)
+}
+
+type updateTokenHandler struct {
+	middlewares []func(http.Handler) http.Handler
+	usecase     UpdateTokenUsecase
+}
+
+func NewUpdateTokenHandler(usecase UpdateTokenUsecase) *updateTokenHandler {
+	return &updateTokenHandler{
+		usecase:     usecase,
+		middlewares: make([]func(http.Handler) http.Handler, 0),
+	}
+}
+
+func (h *updateTokenHandler) AddToRouter(r *chi.Mux) {
+	var handler http.Handler
+	handler = v1.RequirePermission(entity.PermManageTokens)(h)
+	for _, md := range h.middlewares {
+		handler = md(handler)
+	}
+
+	r.Patch(updateTokenURL, handler.ServeHTTP)
+}
+
+func (h *updateTokenHandler) Middlewares(md ...func(http.Handler) http.Handler) *updateTokenHandler {
+	h.middlewares = append(h.middlewares, md...)
+	return h
+}
+
+func (h *updateTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	ID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || ID < 1 {
+		http.Error(w, "invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	var dto entity.UpdateTokenDTO
+
+	err = json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		http.Error(w, "error decoding json request body", http.StatusBadRequest)
+		return
+	}
+
+	dto.TokenID = ID
+
+	if dto.Label == nil && dto.FeatureIDs == nil && !dto.ExpiresAt.Set {
+		http.Error(w, "nothing to update", http.StatusBadRequest)
+		return
+	}
+
+	for _, featureID := range dto.FeatureIDs {
+		if featureID < 1 {
+			http.Error(w, "invalid feature ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	token, err := h.usecase.UpdateToken(r.Context(), dto)
+	if err != nil {
+		switch errors.Code(err) {
+		case errors.ErrNoDataFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.ErrInvalidInput, errors.ErrFeatureNotFound:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	b, err := json.Marshal(token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(b)
+
+}
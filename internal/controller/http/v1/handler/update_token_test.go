@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_updateTokenHandler_ServeHTTP(t *testing.T) {
+
+	s := newTokenTestServer(t)
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	type want struct {
+		code  int
+		label string
+	}
+	tests := []struct {
+		name string
+		path string
+		body string
+		want want
+	}{
+		{
+			name: "positive, label and expiry",
+			path: "/token/2",
+			body: `{"label":"renewed","expires_at":"` + expiresAt.Format(time.RFC3339) + `"}`,
+			want: want{code: 200, label: "renewed"},
+		},
+		{
+			name: "negative, nothing to update",
+			path: "/token/2",
+			body: `{}`,
+			want: want{code: 400},
+		},
+		{
+			name: "negative, unknown token",
+			path: "/token/10",
+			body: `{"label":"renewed"}`,
+			want: want{code: 404},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := testRequest(t, s, "PATCH", tt.path, []byte(tt.body), adminToken)
+
+			require.Equal(t, tt.want.code, resp.StatusCode)
+			if tt.want.code != 200 {
+				return
+			}
+
+			var token entity.Token
+			require.NoError(t, json.Unmarshal([]byte(body), &token))
+			require.Equal(t, tt.want.label, token.Label)
+			require.NotNil(t, token.ExpiresAt)
+			require.True(t, expiresAt.Equal(*token.ExpiresAt))
+		})
+	}
+
+	// the expired token is accepted again once its expiry is moved
+	resp, _ := testRequest(t, s, "GET", "/token", nil, expiredToken)
+	require.Equal(t, 200, resp.StatusCode)
+}
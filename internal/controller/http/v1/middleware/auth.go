@@ -4,14 +4,28 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"strings"
 
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
 	"github.com/The-Gleb/banner_service/internal/errors"
 )
 
-type Key string
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx that carries the authenticated caller.
+func WithPrincipal(ctx context.Context, principal entity.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller set by the auth middleware.
+func PrincipalFromContext(ctx context.Context) (entity.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(entity.Principal)
+	return principal, ok
+}
 
 type CheckTokenUsecase interface {
-	CheckToken(ctx context.Context, token string) (bool, error)
+	CheckToken(ctx context.Context, token string) (entity.Principal, error)
+	CheckJWT(ctx context.Context, token string) (entity.Principal, error)
 }
 
 type authMiddleWare struct {
@@ -22,32 +36,69 @@ func NewAuthMiddleware(usecase CheckTokenUsecase) *authMiddleWare {
 	return &authMiddleWare{usecase}
 }
 
+// Do authenticates the request and puts the caller into its context. A JWT
+// in the Authorization header is preferred over a token in the token header.
+// A request without valid credentials goes on without a caller, and is
+// answered 401 by RequirePermission on every route but the public ones,
+// which ignore credentials that are stale or wrong. What the caller may do
+// is checked per route by RequirePermission.
 func (m *authMiddleWare) Do(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		slog.Debug("auth middleware working")
 
-		token := r.Header.Get("token")
-		if token == "" {
-			http.Error(w, string(errors.ErrUnauthorized), http.StatusUnauthorized)
+		var (
+			principal entity.Principal
+			err       error
+		)
+		if jwt, ok := bearerToken(r); ok {
+			principal, err = m.usecase.CheckJWT(r.Context(), jwt)
+		} else if token := r.Header.Get("token"); token != "" {
+			principal, err = m.usecase.CheckToken(r.Context(), token)
+		} else {
+			next.ServeHTTP(w, r)
 			return
 		}
-
-		isAdmin, err := m.usecase.CheckToken(r.Context(), token)
 		if err != nil {
-			http.Error(w, string(errors.ErrUnauthorized), http.StatusUnauthorized)
-			return
-		}
-
-		slog.Debug("url", "path", r.URL.Path)
-		if r.URL.Path != "/user_banner" && !isAdmin {
-			http.Error(w, string(errors.ErrForbidden), http.StatusForbidden)
+			slog.Debug("invalid credentials, going on without a caller", "error", err)
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), Key("isAdmin"), isAdmin)
+		slog.Debug("authenticated", "token_id", principal.TokenID, "sub", principal.Subject, "role", principal.Role)
 
-		r = r.WithContext(ctx)
+		r = r.WithContext(WithPrincipal(r.Context(), principal))
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// RequirePermission lets the request through only if the authenticated
+// caller has the permission.
+func RequirePermission(perm entity.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				http.Error(w, string(errors.ErrUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			if !principal.Can(perm) {
+				slog.Debug("permission denied", "token_id", principal.TokenID, "sub", principal.Subject, "role", principal.Role, "permission", perm)
+				http.Error(w, string(errors.ErrForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
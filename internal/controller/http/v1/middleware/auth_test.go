@@ -0,0 +1,91 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCheckToken accepts the "valid" token as an admin.
+type fakeCheckToken struct{}
+
+func (fakeCheckToken) CheckToken(ctx context.Context, token string) (entity.Principal, error) {
+	if token != "valid" {
+		return entity.Principal{}, errors.NewDomainError(errors.ErrUnauthorized, "")
+	}
+	return entity.Principal{TokenID: 1, Role: entity.RoleAdmin}, nil
+}
+
+func (fakeCheckToken) CheckJWT(ctx context.Context, token string) (entity.Principal, error) {
+	return entity.Principal{}, errors.NewDomainError(errors.ErrUnauthorized, "")
+}
+
+func Test_authMiddleWare_Do(t *testing.T) {
+	// the handler answers with the role of the caller, if there is one
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := PrincipalFromContext(r.Context())
+		w.Write([]byte(principal.Role))
+	})
+	public := NewAuthMiddleware(fakeCheckToken{}).Do(handler)
+	protected := NewAuthMiddleware(fakeCheckToken{}).Do(RequirePermission(entity.PermReadBanners)(handler))
+
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		token    string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "public, valid token",
+			handler:  public,
+			token:    "valid",
+			wantCode: http.StatusOK,
+			wantBody: string(entity.RoleAdmin),
+		},
+		{
+			name:     "public, stale token is ignored",
+			handler:  public,
+			token:    "stale",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "public, no token",
+			handler:  public,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "protected, valid token",
+			handler:  protected,
+			token:    "valid",
+			wantCode: http.StatusOK,
+			wantBody: string(entity.RoleAdmin),
+		},
+		{
+			name:     "protected, stale token",
+			handler:  protected,
+			token:    "stale",
+			wantCode: http.StatusUnauthorized,
+			wantBody: string(errors.ErrUnauthorized) + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.token != "" {
+				req.Header.Set("token", tt.token)
+			}
+			rec := httptest.NewRecorder()
+
+			tt.handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantCode, rec.Code)
+			require.Equal(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
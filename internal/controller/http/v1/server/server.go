@@ -2,11 +2,14 @@ package v1
 
 import (
 	"context"
+	"expvar"
 	"net/http"
 
 	handlers "github.com/The-Gleb/banner_service/internal/controller/http/v1/handler"
 	middleware "github.com/The-Gleb/banner_service/internal/controller/http/v1/middleware"
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
 	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 )
 
 type httpServer struct {
@@ -17,28 +20,98 @@ func NewServer(
 	address string,
 	createBannerUsecase handlers.CreateBannerUsecase,
 	deleteBannerUsecase handlers.DeleteBannerUsecase,
+	getBannersUsecase handlers.GetBannersUsecase,
 	getBannerUsecase handlers.GetBannerUsecase,
 	getUserBannerUsecase handlers.GetUserBannerUsecase,
 	updateBannerUsecase handlers.UpdateBannerUsecase,
+	getBannerVersionsUsecase handlers.GetBannerVersionsUsecase,
+	restoreBannerUsecase handlers.RestoreBannerUsecase,
+	deleteBannersUsecase handlers.DeleteBannersUsecase,
+	getDeleteJobUsecase handlers.GetDeleteJobUsecase,
+	clickBannerUsecase handlers.ClickBannerUsecase,
+	getBannerStatsUsecase handlers.GetBannerStatsUsecase,
+	issueTokenUsecase handlers.IssueTokenUsecase,
+	getTokensUsecase handlers.GetTokensUsecase,
+	updateTokenUsecase handlers.UpdateTokenUsecase,
+	revokeTokenUsecase handlers.RevokeTokenUsecase,
+	getAuditRecordsUsecase handlers.GetAuditRecordsUsecase,
+	createTagUsecase handlers.CreateTagUsecase,
+	getTagsUsecase handlers.GetTagsUsecase,
+	updateTagUsecase handlers.UpdateTagUsecase,
+	deleteTagUsecase handlers.DeleteTagUsecase,
+	createFeatureUsecase handlers.CreateFeatureUsecase,
+	getFeaturesUsecase handlers.GetFeaturesUsecase,
+	updateFeatureUsecase handlers.UpdateFeatureUsecase,
+	deleteFeatureUsecase handlers.DeleteFeatureUsecase,
+	importBannersUsecase handlers.ImportBannersUsecase,
+	exportBannersUsecase handlers.ExportBannersUsecase,
 	checkTokenUsecase middleware.CheckTokenUsecase,
 ) (*httpServer, error) {
 
 	createBannerHandler := handlers.NewCreateBannerHandler(createBannerUsecase)
 	deleteBannerHandler := handlers.NewDeleteBannerHandler(deleteBannerUsecase)
-	getBannerHandler := handlers.NewGetBannersHandler(getBannerUsecase)
+	getBannersHandler := handlers.NewGetBannersHandler(getBannersUsecase)
+	getBannerHandler := handlers.NewGetBannerHandler(getBannerUsecase)
 	getUserBannerHandler := handlers.NewGetUserBannerHandler(getUserBannerUsecase)
 	updateBannerHandler := handlers.NewUpdateBannerHandler(updateBannerUsecase)
+	getBannerVersionsHandler := handlers.NewGetBannerVersionsHandler(getBannerVersionsUsecase)
+	restoreBannerHandler := handlers.NewRestoreBannerHandler(restoreBannerUsecase)
+	deleteBannersHandler := handlers.NewDeleteBannersHandler(deleteBannersUsecase)
+	getDeleteJobHandler := handlers.NewGetDeleteJobHandler(getDeleteJobUsecase)
+	clickBannerHandler := handlers.NewClickBannerHandler(clickBannerUsecase)
+	getBannerStatsHandler := handlers.NewGetBannerStatsHandler(getBannerStatsUsecase)
+	issueTokenHandler := handlers.NewIssueTokenHandler(issueTokenUsecase)
+	getTokensHandler := handlers.NewGetTokensHandler(getTokensUsecase)
+	updateTokenHandler := handlers.NewUpdateTokenHandler(updateTokenUsecase)
+	revokeTokenHandler := handlers.NewRevokeTokenHandler(revokeTokenUsecase)
+	getAuditRecordsHandler := handlers.NewGetAuditRecordsHandler(getAuditRecordsUsecase)
+	createTagHandler := handlers.NewCreateTagHandler(createTagUsecase)
+	getTagsHandler := handlers.NewGetTagsHandler(getTagsUsecase)
+	updateTagHandler := handlers.NewUpdateTagHandler(updateTagUsecase)
+	deleteTagHandler := handlers.NewDeleteTagHandler(deleteTagUsecase)
+	createFeatureHandler := handlers.NewCreateFeatureHandler(createFeatureUsecase)
+	getFeaturesHandler := handlers.NewGetFeaturesHandler(getFeaturesUsecase)
+	updateFeatureHandler := handlers.NewUpdateFeatureHandler(updateFeatureUsecase)
+	deleteFeatureHandler := handlers.NewDeleteFeatureHandler(deleteFeatureUsecase)
+	importBannersHandler := handlers.NewImportBannersHandler(importBannersUsecase)
+	exportBannersHandler := handlers.NewExportBannersHandler(exportBannersUsecase)
 
 	checkTokenMiddleware := middleware.NewAuthMiddleware(checkTokenUsecase)
 
 	r := chi.NewMux()
+	r.Use(chimiddleware.RequestID)
 	r.Use(checkTokenMiddleware.Do)
 
 	createBannerHandler.AddToRouter(r)
 	deleteBannerHandler.AddToRouter(r)
+	getBannersHandler.AddToRouter(r)
 	getBannerHandler.AddToRouter(r)
 	getUserBannerHandler.AddToRouter(r)
 	updateBannerHandler.AddToRouter(r)
+	getBannerVersionsHandler.AddToRouter(r)
+	restoreBannerHandler.AddToRouter(r)
+	deleteBannersHandler.AddToRouter(r)
+	getDeleteJobHandler.AddToRouter(r)
+	clickBannerHandler.AddToRouter(r)
+	getBannerStatsHandler.AddToRouter(r)
+	issueTokenHandler.AddToRouter(r)
+	getTokensHandler.AddToRouter(r)
+	updateTokenHandler.AddToRouter(r)
+	revokeTokenHandler.AddToRouter(r)
+	getAuditRecordsHandler.AddToRouter(r)
+	createTagHandler.AddToRouter(r)
+	getTagsHandler.AddToRouter(r)
+	updateTagHandler.AddToRouter(r)
+	deleteTagHandler.AddToRouter(r)
+	createFeatureHandler.AddToRouter(r)
+	getFeaturesHandler.AddToRouter(r)
+	updateFeatureHandler.AddToRouter(r)
+	deleteFeatureHandler.AddToRouter(r)
+	importBannersHandler.AddToRouter(r)
+	exportBannersHandler.AddToRouter(r)
+
+	// process counters, such as the cache failures, for admins
+	r.With(middleware.RequirePermission(entity.PermReadMetrics)).Handle("/debug/vars", expvar.Handler())
 
 	server := &http.Server{
 		Addr:    address,
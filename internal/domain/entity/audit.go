@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+)
+
+// AuditRecord is a change made to a banner. Before and After are the banner
+// as GET /banner/{id} returns it; Before is null for created banners and
+// After is null for deleted ones.
+type AuditRecord struct {
+	ID        int64           `json:"id"`
+	BannerID  int64           `json:"banner_id"`
+	Action    AuditAction     `json:"action"`
+	Actor     string          `json:"actor"`
+	TokenID   *int64          `json:"token_id,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
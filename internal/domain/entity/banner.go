@@ -11,8 +11,52 @@ type Banner struct {
 	FeatureID int64         `json:"feature_id"`
 	Content   BannerContent `json:"content"`
 	IsActive  bool          `json:"is_active"`
+	Version   int64         `json:"version"`
 	CreatedAt time.Time     `json:"created_at"`
 	UpdatedAt time.Time     `json:"updated_at"`
+	Schedule
+}
+
+type BannerVersion struct {
+	Version   int64         `json:"version"`
+	TagIDs    []int64       `json:"tag_ids"`
+	FeatureID int64         `json:"feature_id"`
+	Content   BannerContent `json:"content"`
+	IsActive  bool          `json:"is_active"`
+	CreatedAt time.Time     `json:"created_at"`
+	Schedule
+}
+
+// UserBanner is a banner as it is served to users.
+type UserBanner struct {
+	BannerID int64
+	Content  BannerContent
+	IsActive bool
+	Schedule
+	// Stale is set by the cache when the banner is due for a refresh but may
+	// still be served meanwhile.
+	Stale bool
+	// CachedAt is when the banner was read from the storage into the cache,
+	// zero if it has just been read from the storage.
+	CachedAt time.Time
+	Cache    CacheStatus
+}
+
+// CacheStatus tells how the cache took part in serving a banner.
+type CacheStatus string
+
+const (
+	CacheHit  CacheStatus = "HIT"
+	CacheMiss CacheStatus = "MISS"
+	// CacheBypass means that the cache was not asked, because the caller
+	// wanted the last revision or the cache is unavailable.
+	CacheBypass CacheStatus = "BYPASS"
+)
+
+// VisibleTo reports whether the banner may be shown now to a user who is an
+// admin or not.
+func (b UserBanner) VisibleTo(isAdmin bool) bool {
+	return isAdmin || b.IsActive && b.Schedule.Contains(time.Now())
 }
 
 type BannerContent struct {
@@ -21,6 +65,11 @@ type BannerContent struct {
 	URL   string `json:"url" redis:"url"`
 }
 
+// Valid reports whether the content has all of its required fields.
+func (b BannerContent) Valid() bool {
+	return b.Title != "" && b.Text != "" && b.URL != ""
+}
+
 func (b BannerContent) MarshalBinary() ([]byte, error) {
 	return json.Marshal(b)
 }
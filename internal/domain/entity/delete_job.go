@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+type DeleteJobStatus string
+
+const (
+	DeleteJobPending DeleteJobStatus = "pending"
+	DeleteJobRunning DeleteJobStatus = "running"
+	DeleteJobDone    DeleteJobStatus = "done"
+	DeleteJobFailed  DeleteJobStatus = "failed"
+)
+
+type DeleteJob struct {
+	ID         int64           `json:"id"`
+	FeatureID  int64           `json:"feature_id,omitempty"`
+	TagID      int64           `json:"tag_id,omitempty"`
+	Status     DeleteJobStatus `json:"status"`
+	Deleted    int64           `json:"deleted"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	// Principal and RequestID are of the request that created the job, the
+	// banners it deletes are audited as deleted by them. Only the fields
+	// that identify the actor are kept.
+	Principal Principal `json:"-"`
+	RequestID string    `json:"-"`
+	// Lease identifies the claim of the job by a worker. Only the worker
+	// holding the latest lease may update the job.
+	Lease int64 `json:"-"`
+}
@@ -1,5 +1,10 @@
 package entity
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type GetUserBannerDTO struct {
 	TagID           int64
 	FeatureID       int64
@@ -7,10 +12,20 @@ type GetUserBannerDTO struct {
 	IsAdmin         bool
 }
 
+// Keys of GetBannersDTO.Filters.
+const (
+	BannerFilterFeature = "feature"
+	BannerFilterTag     = "tag"
+)
+
+// GetBannersDTO selects banners by Filters. If FeatureIDs is not nil, only
+// banners of those features are returned.
 type GetBannersDTO struct {
-	Filters map[string]int64
-	Limit   int
-	Offset  int
+	Filters    map[string]int64
+	FeatureIDs []int64
+	Limit      int
+	Offset     int
+	Principal  Principal
 }
 
 type CreateBannerDTO struct {
@@ -18,25 +33,210 @@ type CreateBannerDTO struct {
 	FeatureID int64         `json:"feature_id"`
 	Content   BannerContent `json:"content"`
 	IsActive  bool          `json:"is_active"`
+	Schedule
+	Principal Principal `json:"-"`
+	RequestID string    `json:"-"`
 }
 
+// UpdateBannerDTO describes a partial update: nil fields are left as they
+// are. Content is a JSON Merge Patch (RFC 7396) applied to the current
+// content, and an explicit null in starts_at or ends_at removes that bound.
+// If ExpectedVersion is set, the update only applies to that version of the
+// banner.
 type UpdateBannerDTO struct {
-	BannerID  int64
-	TagIDs    []int64       `json:"tag_ids"`
-	FeatureID int64         `json:"feature_id"`
-	Content   BannerContent `json:"content"`
-	IsActive  bool          `json:"is_active"`
+	BannerID        int64
+	TagIDs          []int64         `json:"tag_ids"`
+	FeatureID       *int64          `json:"feature_id"`
+	Content         json.RawMessage `json:"content"`
+	IsActive        *bool           `json:"is_active"`
+	StartsAt        OptionalTime    `json:"starts_at"`
+	EndsAt          OptionalTime    `json:"ends_at"`
+	ExpectedVersion *int64          `json:"-"`
+	Principal       Principal       `json:"-"`
+	RequestID       string          `json:"-"`
 }
 
 type DeleteBannerDTO struct {
+	BannerID        int64
+	ExpectedVersion *int64
+	Principal       Principal
+	RequestID       string
+}
+
+type GetBannerDTO struct {
+	BannerID  int64
+	Principal Principal
+}
+
+type GetBannerVersionsDTO struct {
+	BannerID  int64
+	Principal Principal
+}
+
+// RestoreBannerDTO makes Version the current state of the banner. If
+// ExpectedVersion is set, the banner must still be at that version.
+type RestoreBannerDTO struct {
+	BannerID        int64
+	Version         int64
+	ExpectedVersion *int64
+	Principal       Principal
+	RequestID       string
+}
+
+// DeleteBannersDTO selects banners for bulk deletion. A zero ID means the
+// filter is not set.
+type DeleteBannersDTO struct {
+	FeatureID int64
+	TagID     int64
+	Principal Principal
+	RequestID string
+}
+
+type GetDeleteJobDTO struct {
+	JobID     int64
+	Principal Principal
+}
+
+type ClickBannerDTO struct {
 	BannerID int64
+	IsAdmin  bool
+}
+
+// GetBannerStatsDTO selects the days from From to To, both inclusive.
+type GetBannerStatsDTO struct {
+	BannerID  int64
+	From      time.Time
+	To        time.Time
+	Principal Principal
 }
 
 type UpdateCacheDTO struct {
 	BannerID  int64
+	Version   int64
 	Content   BannerContent
 	TagID     int64
 	FeatureID int64
 	IsActive  bool
 	IsAdmin   bool
+	Schedule
+}
+
+type IssueTokenDTO struct {
+	Label      string     `json:"label"`
+	Role       Role       `json:"role"`
+	FeatureIDs []int64    `json:"feature_ids"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Token      string     `json:"-"`
+}
+
+type GetTokensDTO struct {
+	Limit  int
+	Offset int
+}
+
+// UpdateTokenDTO describes a partial update of a token. An explicit null in
+// expires_at makes the token never expire, and an empty feature_ids lifts
+// the feature scope.
+type UpdateTokenDTO struct {
+	TokenID    int64
+	Label      *string      `json:"label"`
+	FeatureIDs []int64      `json:"feature_ids"`
+	ExpiresAt  OptionalTime `json:"expires_at"`
+}
+
+type RevokeTokenDTO struct {
+	TokenID int64
+}
+
+// GetAuditRecordsDTO selects audit records, newest first. Zero fields are
+// not used as filters.
+type GetAuditRecordsDTO struct {
+	BannerID int64
+	Actor    string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+}
+
+type CreateTagDTO struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	OwnerTeam   string `json:"owner_team"`
+}
+
+// GetTagsDTO selects tags. An empty OwnerTeam is not used as a filter.
+type GetTagsDTO struct {
+	OwnerTeam string
+	Limit     int
+	Offset    int
+}
+
+// UpdateTagDTO describes a partial update: nil fields are left as they are.
+type UpdateTagDTO struct {
+	TagID       int64
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	OwnerTeam   *string `json:"owner_team"`
+}
+
+// DeleteTagDTO deletes a tag. A tag that banners still use is only deleted
+// if Cascade is set, and then it is removed from those banners. Banners that
+// have no other tag are deleted with it.
+type DeleteTagDTO struct {
+	TagID     int64
+	Cascade   bool
+	Principal Principal
+	RequestID string
+}
+
+type CreateFeatureDTO struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	OwnerTeam   string `json:"owner_team"`
+}
+
+// GetFeaturesDTO selects features. An empty OwnerTeam is not used as a
+// filter.
+type GetFeaturesDTO struct {
+	OwnerTeam string
+	Limit     int
+	Offset    int
+}
+
+// UpdateFeatureDTO describes a partial update: nil fields are left as they
+// are.
+type UpdateFeatureDTO struct {
+	FeatureID   int64
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	OwnerTeam   *string `json:"owner_team"`
+}
+
+// DeleteFeatureDTO deletes a feature. A feature that banners still use is
+// only deleted if Cascade is set, and then those banners are deleted too.
+type DeleteFeatureDTO struct {
+	FeatureID int64
+	Cascade   bool
+	Principal Principal
+	RequestID string
+}
+
+// ImportBannersDTO creates the banners of all rows or none of them.
+type ImportBannersDTO struct {
+	Rows []ImportRow
+}
+
+// ImportRow is a banner to import together with the line of the import file
+// it was read from.
+type ImportRow struct {
+	Line   int
+	Banner CreateBannerDTO
+}
+
+// ExportBannersDTO selects the banners to export. If FeatureIDs is not nil,
+// only banners of those features are exported.
+type ExportBannersDTO struct {
+	FeatureIDs []int64
+	Principal  Principal
 }
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// Feature is a part of the product that banners are shown in.
+type Feature struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	OwnerTeam   string     `json:"owner_team"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
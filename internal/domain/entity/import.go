@@ -0,0 +1,14 @@
+package entity
+
+// ImportResult lists the IDs of the created banners, or the problems that
+// kept the import from being applied.
+type ImportResult struct {
+	BannerIDs []int64       `json:"banner_ids,omitempty"`
+	Errors    []ImportError `json:"errors,omitempty"`
+}
+
+// ImportError is a problem with one line of an import file.
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to the content and
+// returns the result. Keys that BannerContent does not have are rejected.
+func (b BannerContent) MergePatch(patch []byte) (BannerContent, error) {
+	original, err := json.Marshal(b)
+	if err != nil {
+		return BannerContent{}, err
+	}
+
+	var target any
+	err = json.Unmarshal(original, &target)
+	if err != nil {
+		return BannerContent{}, err
+	}
+
+	var p any
+	err = json.Unmarshal(patch, &p)
+	if err != nil {
+		return BannerContent{}, err
+	}
+
+	merged, err := json.Marshal(mergePatch(target, p))
+	if err != nil {
+		return BannerContent{}, err
+	}
+
+	var result BannerContent
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&result)
+	if err != nil {
+		return BannerContent{}, err
+	}
+
+	return result, nil
+}
+
+// mergePatch implements the MergePatch function from RFC 7396, section 2.
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+
+	return targetObject
+}
@@ -0,0 +1,151 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBannerContent_MergePatch(t *testing.T) {
+	content := BannerContent{
+		Title: "title",
+		Text:  "text",
+		URL:   "url",
+	}
+
+	type want struct {
+		content BannerContent
+		err     bool
+		valid   bool
+	}
+	tests := []struct {
+		name  string
+		patch string
+		want  want
+	}{
+		{
+			name:  "positive, one field",
+			patch: `{"title":"new title"}`,
+			want: want{
+				content: BannerContent{Title: "new title", Text: "text", URL: "url"},
+				valid:   true,
+			},
+		},
+		{
+			name:  "positive, all fields",
+			patch: `{"title":"t","text":"x","url":"u"}`,
+			want: want{
+				content: BannerContent{Title: "t", Text: "x", URL: "u"},
+				valid:   true,
+			},
+		},
+		{
+			name:  "positive, empty patch",
+			patch: `{}`,
+			want: want{
+				content: content,
+				valid:   true,
+			},
+		},
+		{
+			name:  "positive, null removes the field",
+			patch: `{"title":null}`,
+			want: want{
+				content: BannerContent{Text: "text", URL: "url"},
+				valid:   false,
+			},
+		},
+		{
+			name:  "negative, unknown field",
+			patch: `{"color":"red"}`,
+			want: want{
+				err: true,
+			},
+		},
+		{
+			name:  "negative, wrong type",
+			patch: `{"title":1}`,
+			want: want{
+				err: true,
+			},
+		},
+		{
+			name:  "negative, not an object",
+			patch: `"title"`,
+			want: want{
+				err: true,
+			},
+		},
+		{
+			name:  "negative, invalid json",
+			patch: `{"title":`,
+			want: want{
+				err: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := content.MergePatch([]byte(tt.patch))
+			if tt.want.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, tt.want.content, got)
+			require.Equal(t, tt.want.valid, got.Valid())
+		})
+	}
+}
+
+func Test_mergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target any
+		patch  any
+		want   any
+	}{
+		{
+			name:   "replaces a value",
+			target: map[string]any{"a": "b"},
+			patch:  map[string]any{"a": "c"},
+			want:   map[string]any{"a": "c"},
+		},
+		{
+			name:   "adds a value",
+			target: map[string]any{"a": "b"},
+			patch:  map[string]any{"b": "c"},
+			want:   map[string]any{"a": "b", "b": "c"},
+		},
+		{
+			name:   "removes a value",
+			target: map[string]any{"a": "b", "b": "c"},
+			patch:  map[string]any{"a": nil},
+			want:   map[string]any{"b": "c"},
+		},
+		{
+			name:   "merges nested objects",
+			target: map[string]any{"a": map[string]any{"b": "c", "d": "e"}},
+			patch:  map[string]any{"a": map[string]any{"d": nil, "f": "g"}},
+			want:   map[string]any{"a": map[string]any{"b": "c", "f": "g"}},
+		},
+		{
+			name:   "replaces a non-object target",
+			target: "a",
+			patch:  map[string]any{"b": "c"},
+			want:   map[string]any{"b": "c"},
+		},
+		{
+			name:   "replaces the target with a non-object patch",
+			target: map[string]any{"a": "b"},
+			patch:  []any{"c"},
+			want:   []any{"c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, mergePatch(tt.target, tt.patch))
+		})
+	}
+}
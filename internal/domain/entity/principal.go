@@ -0,0 +1,121 @@
+package entity
+
+import (
+	"strconv"
+	"time"
+)
+
+// Role is the role granted to a token.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleViewer    Role = "viewer"
+	RoleEditor    Role = "editor"
+	RolePublisher Role = "publisher"
+	RoleAdmin     Role = "admin"
+)
+
+// Permission is an action a route requires.
+type Permission string
+
+const (
+	// PermServeBanners allows getting banners the way users see them.
+	PermServeBanners Permission = "banners:serve"
+	// PermReadBanners allows reading banners with their history and stats,
+	// including inactive ones.
+	PermReadBanners Permission = "banners:read"
+	// PermEditBanners allows creating banners and changing their content,
+	// tags and feature.
+	PermEditBanners Permission = "banners:edit"
+	// PermPublishBanners allows turning banners on and off, scheduling,
+	// restoring and deleting them.
+	PermPublishBanners Permission = "banners:publish"
+	// PermManageTokens allows issuing and revoking tokens.
+	PermManageTokens Permission = "tokens:manage"
+	// PermReadAudit allows reading the audit log.
+	PermReadAudit Permission = "audit:read"
+	// PermManageCatalog allows creating, changing and deleting tags and
+	// features.
+	PermManageCatalog Permission = "catalog:manage"
+	// PermReadMetrics allows reading the process counters.
+	PermReadMetrics Permission = "metrics:read"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleUser:      {PermServeBanners},
+	RoleViewer:    {PermServeBanners, PermReadBanners},
+	RoleEditor:    {PermServeBanners, PermReadBanners, PermEditBanners},
+	RolePublisher: {PermServeBanners, PermReadBanners, PermEditBanners, PermPublishBanners, PermManageCatalog},
+	RoleAdmin:     {PermServeBanners, PermReadBanners, PermEditBanners, PermPublishBanners, PermManageCatalog, PermManageTokens, PermReadAudit, PermReadMetrics},
+}
+
+// Valid reports whether r is a known role.
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can reports whether the role grants the permission.
+func (r Role) Can(perm Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Principal is the authenticated caller of a request. Callers with a DB token
+// have its TokenID, callers with a JWT have the Subject of its claims. If
+// FeatureIDs is not empty, the caller may only manage banners of those
+// features. The zero Principal stands for the service itself and is not
+// limited.
+type Principal struct {
+	TokenID    int64
+	Subject    string
+	Role       Role
+	FeatureIDs []int64
+	// ExpiresAt is when the credentials of the caller expire, if they do.
+	ExpiresAt *time.Time
+}
+
+func (p Principal) Can(perm Permission) bool {
+	// a token limited to some features must not be able to issue unlimited
+	// ones, read about changes to other features or delete them
+	if (perm == PermManageTokens || perm == PermReadAudit || perm == PermManageCatalog) && p.Scoped() {
+		return false
+	}
+	return p.Role.Can(perm)
+}
+
+// Actor names the principal in the audit log: "token:<id>" for DB tokens,
+// "jwt:<subject>" for JWTs and "system" for the service itself.
+func (p Principal) Actor() string {
+	switch {
+	case p.TokenID != 0:
+		return "token:" + strconv.FormatInt(p.TokenID, 10)
+	case p.Subject != "":
+		return "jwt:" + p.Subject
+	default:
+		return "system"
+	}
+}
+
+// Scoped reports whether the principal is limited to some features.
+func (p Principal) Scoped() bool {
+	return len(p.FeatureIDs) > 0
+}
+
+// InScope reports whether the principal may manage banners of the feature.
+func (p Principal) InScope(featureID int64) bool {
+	if !p.Scoped() {
+		return true
+	}
+	for _, id := range p.FeatureIDs {
+		if id == featureID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrincipal_Can(t *testing.T) {
+	admin := Principal{TokenID: 1, Role: RoleAdmin}
+	scopedAdmin := Principal{TokenID: 2, Role: RoleAdmin, FeatureIDs: []int64{1}}
+
+	tests := []struct {
+		name      string
+		principal Principal
+		perm      Permission
+		want      bool
+	}{
+		{
+			name:      "role grants the permission",
+			principal: Principal{Role: RoleEditor},
+			perm:      PermEditBanners,
+			want:      true,
+		},
+		{
+			name:      "role lacks the permission",
+			principal: Principal{Role: RoleEditor},
+			perm:      PermPublishBanners,
+			want:      false,
+		},
+		{
+			name:      "unknown role",
+			principal: Principal{Role: "root"},
+			perm:      PermServeBanners,
+			want:      false,
+		},
+		{
+			name:      "admin manages tokens",
+			principal: admin,
+			perm:      PermManageTokens,
+			want:      true,
+		},
+		{
+			name:      "scoped admin cannot manage tokens",
+			principal: scopedAdmin,
+			perm:      PermManageTokens,
+			want:      false,
+		},
+		{
+			name:      "scoped admin cannot read the audit log",
+			principal: scopedAdmin,
+			perm:      PermReadAudit,
+			want:      false,
+		},
+		{
+			name:      "admin reads metrics",
+			principal: admin,
+			perm:      PermReadMetrics,
+			want:      true,
+		},
+		{
+			name:      "publisher cannot read metrics",
+			principal: Principal{Role: RolePublisher},
+			perm:      PermReadMetrics,
+			want:      false,
+		},
+		{
+			name:      "scoped admin cannot manage the catalog",
+			principal: scopedAdmin,
+			perm:      PermManageCatalog,
+			want:      false,
+		},
+		{
+			name:      "scoped admin publishes banners",
+			principal: scopedAdmin,
+			perm:      PermPublishBanners,
+			want:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.principal.Can(tt.perm))
+		})
+	}
+}
+
+func TestPrincipal_InScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal Principal
+		featureID int64
+		want      bool
+	}{
+		{
+			name:      "unscoped",
+			principal: Principal{Role: RoleEditor},
+			featureID: 3,
+			want:      true,
+		},
+		{
+			name:      "feature in scope",
+			principal: Principal{Role: RoleEditor, FeatureIDs: []int64{1, 3}},
+			featureID: 3,
+			want:      true,
+		},
+		{
+			name:      "feature out of scope",
+			principal: Principal{Role: RoleEditor, FeatureIDs: []int64{1, 3}},
+			featureID: 2,
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.principal.InScope(tt.featureID))
+		})
+	}
+}
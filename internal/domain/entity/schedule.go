@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Schedule limits the time a banner is shown to users. A nil bound means
+// the window is open on that side.
+type Schedule struct {
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// Contains reports whether t falls into the window.
+func (s Schedule) Contains(t time.Time) bool {
+	if s.StartsAt != nil && t.Before(*s.StartsAt) {
+		return false
+	}
+	if s.EndsAt != nil && !t.Before(*s.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Valid reports whether the window is not empty.
+func (s Schedule) Valid() bool {
+	return s.StartsAt == nil || s.EndsAt == nil || s.StartsAt.Before(*s.EndsAt)
+}
+
+// OptionalTime is a time field of a partial update. Set is false if the
+// field was absent, and Time is nil if it was explicitly null.
+type OptionalTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+func (o *OptionalTime) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Time = nil
+		return nil
+	}
+
+	var t time.Time
+	err := json.Unmarshal(data, &t)
+	if err != nil {
+		return err
+	}
+	o.Time = &t
+
+	return nil
+}
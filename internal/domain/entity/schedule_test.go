@@ -0,0 +1,222 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Contains(t *testing.T) {
+	now := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-time.Hour)
+	after := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		want     bool
+	}{
+		{
+			name: "open window",
+			want: true,
+		},
+		{
+			name:     "started",
+			schedule: Schedule{StartsAt: &before},
+			want:     true,
+		},
+		{
+			name:     "starts now",
+			schedule: Schedule{StartsAt: &now},
+			want:     true,
+		},
+		{
+			name:     "not started",
+			schedule: Schedule{StartsAt: &after},
+			want:     false,
+		},
+		{
+			name:     "not ended",
+			schedule: Schedule{EndsAt: &after},
+			want:     true,
+		},
+		{
+			name:     "ends now",
+			schedule: Schedule{EndsAt: &now},
+			want:     false,
+		},
+		{
+			name:     "ended",
+			schedule: Schedule{EndsAt: &before},
+			want:     false,
+		},
+		{
+			name:     "within both bounds",
+			schedule: Schedule{StartsAt: &before, EndsAt: &after},
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.schedule.Contains(now))
+		})
+	}
+}
+
+func TestSchedule_Valid(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		want     bool
+	}{
+		{
+			name: "open window",
+			want: true,
+		},
+		{
+			name:     "only start",
+			schedule: Schedule{StartsAt: &later},
+			want:     true,
+		},
+		{
+			name:     "only end",
+			schedule: Schedule{EndsAt: &now},
+			want:     true,
+		},
+		{
+			name:     "start before end",
+			schedule: Schedule{StartsAt: &now, EndsAt: &later},
+			want:     true,
+		},
+		{
+			name:     "start equal to end",
+			schedule: Schedule{StartsAt: &now, EndsAt: &now},
+			want:     false,
+		},
+		{
+			name:     "start after end",
+			schedule: Schedule{StartsAt: &later, EndsAt: &now},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.schedule.Valid())
+		})
+	}
+}
+
+func TestUserBanner_VisibleTo(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		banner  UserBanner
+		isAdmin bool
+		want    bool
+	}{
+		{
+			name:   "active, user",
+			banner: UserBanner{IsActive: true},
+			want:   true,
+		},
+		{
+			name:   "inactive, user",
+			banner: UserBanner{IsActive: false},
+			want:   false,
+		},
+		{
+			name:    "inactive, admin",
+			banner:  UserBanner{IsActive: false},
+			isAdmin: true,
+			want:    true,
+		},
+		{
+			name:   "active, not started, user",
+			banner: UserBanner{IsActive: true, Schedule: Schedule{StartsAt: &future}},
+			want:   false,
+		},
+		{
+			name:   "active, ended, user",
+			banner: UserBanner{IsActive: true, Schedule: Schedule{EndsAt: &past}},
+			want:   false,
+		},
+		{
+			name:    "active, ended, admin",
+			banner:  UserBanner{IsActive: true, Schedule: Schedule{EndsAt: &past}},
+			isAdmin: true,
+			want:    true,
+		},
+		{
+			name:   "active, within the window, user",
+			banner: UserBanner{IsActive: true, Schedule: Schedule{StartsAt: &past, EndsAt: &future}},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.banner.VisibleTo(tt.isAdmin))
+		})
+	}
+}
+
+func TestOptionalTime_UnmarshalJSON(t *testing.T) {
+	at := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	type want struct {
+		startsAt OptionalTime
+		err      bool
+	}
+	tests := []struct {
+		name string
+		body string
+		want want
+	}{
+		{
+			name: "absent",
+			body: `{}`,
+		},
+		{
+			name: "null",
+			body: `{"starts_at":null}`,
+			want: want{
+				startsAt: OptionalTime{Set: true},
+			},
+		},
+		{
+			name: "time",
+			body: `{"starts_at":"2024-04-01T12:00:00Z"}`,
+			want: want{
+				startsAt: OptionalTime{Set: true, Time: &at},
+			},
+		},
+		{
+			name: "not a time",
+			body: `{"starts_at":"tomorrow"}`,
+			want: want{
+				err: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dto struct {
+				StartsAt OptionalTime `json:"starts_at"`
+			}
+			err := json.Unmarshal([]byte(tt.body), &dto)
+			if tt.want.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, tt.want.startsAt, dto.StartsAt)
+		})
+	}
+}
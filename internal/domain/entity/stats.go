@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// BannerStats holds the counters of a banner for one day (UTC).
+type BannerStats struct {
+	BannerID    int64
+	Day         time.Time
+	Impressions int64
+	Clicks      int64
+}
+
+// CTR is the share of impressions that were followed by a click.
+func (s BannerStats) CTR() float64 {
+	if s.Impressions == 0 {
+		return 0
+	}
+	return float64(s.Clicks) / float64(s.Impressions)
+}
@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// Token is an API token. Only a salted digest of the secret is stored, so
+// Token is set right after the token is issued and never again. Prefix is
+// the start of the secret that is kept to tell tokens apart; it is empty
+// for the short secrets of old tokens, which expire after a deprecation
+// window. A token with FeatureIDs may only manage banners of those
+// features. LastUsedAt is only updated when a check of the token misses the
+// token cache, so it lags behind by up to the cache TTL.
+type Token struct {
+	ID         int64      `json:"id"`
+	Prefix     string     `json:"prefix"`
+	Token      string     `json:"token,omitempty"`
+	Label      string     `json:"label"`
+	Role       Role       `json:"role"`
+	FeatureIDs []int64    `json:"feature_ids,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// TokenInvalidation names the cached token checks that are out of date: the
+// ones of TokenID.
+type TokenInvalidation struct {
+	TokenID int64
+}
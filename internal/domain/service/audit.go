@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/usecase"
+)
+
+var _ usecase.AuditService = new(auditService)
+
+// AuditStorage reads the audit log. Records are written by the banner
+// storage in the same transaction as the change they describe.
+type AuditStorage interface {
+	GetAuditRecords(ctx context.Context, dto entity.GetAuditRecordsDTO) ([]entity.AuditRecord, error)
+}
+
+type auditService struct {
+	storage AuditStorage
+}
+
+func NewAuditService(storage AuditStorage) *auditService {
+	return &auditService{storage: storage}
+}
+
+func (service *auditService) GetAuditRecords(ctx context.Context, dto entity.GetAuditRecordsDTO) ([]entity.AuditRecord, error) {
+	return service.storage.GetAuditRecords(ctx, dto)
+}
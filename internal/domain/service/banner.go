@@ -1,89 +1,451 @@
-package service
-
-import (
-	"context"
-	"log/slog"
-
-	"github.com/The-Gleb/banner_service/internal/domain/entity"
-	"github.com/The-Gleb/banner_service/internal/domain/usecase"
-)
-
-var _ usecase.BannerService = new(bannerService)
-var _ usecase.TokenService = new(tokenService)
-
-type BannerStorage interface {
-	CreateBanner(ctx context.Context, dto entity.CreateBannerDTO) (int64, error)
-	DeleteBanner(ctx context.Context, dto entity.DeleteBannerDTO) error
-	GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UpdateCacheDTO, error)
-	GetBanners(ctx context.Context, dto entity.GetBannersDTO) ([]entity.Banner, error)
-	UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) error
-}
-
-type BannerCache interface {
-	Set(ctx context.Context, dto entity.UpdateCacheDTO) error
-	Get(ctx context.Context, dto entity.GetUserBannerDTO) (entity.BannerContent, error)
-}
-
-type bannerService struct {
-	storage BannerStorage
-	cache   BannerCache
-}
-
-func NewBannerService(storage BannerStorage, cache BannerCache) *bannerService {
-	return &bannerService{
-		storage: storage,
-		cache:   cache,
-	}
-}
-
-func (service *bannerService) CreateBanner(ctx context.Context, dto entity.CreateBannerDTO) (int64, error) {
-	return service.storage.CreateBanner(ctx, dto)
-}
-
-func (service *bannerService) DeleteBanner(ctx context.Context, dto entity.DeleteBannerDTO) error {
-	return service.storage.DeleteBanner(ctx, dto)
-}
-
-func (service *bannerService) GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.BannerContent, error) {
-
-	if dto.UseLastRevision {
-		banner, err := service.storage.GetUserBanner(ctx, dto)
-		if err != nil {
-			return entity.BannerContent{}, err
-		}
-
-		err = service.cache.Set(ctx, banner)
-		if err != nil {
-			return entity.BannerContent{}, err
-		}
-
-		return banner.Content, err
-	}
-
-	content, err := service.cache.Get(ctx, dto)
-	if err == nil {
-		content.Title += " from cache" // added for tests
-		slog.Debug("banner found in cache")
-		return content, nil
-	}
-
-	updateCacheDTO, err := service.storage.GetUserBanner(ctx, dto)
-	if err != nil {
-		return entity.BannerContent{}, err
-	}
-
-	err = service.cache.Set(ctx, updateCacheDTO)
-	if err != nil {
-		return entity.BannerContent{}, err
-	}
-
-	return updateCacheDTO.Content, nil
-}
-
-func (service *bannerService) GetBanners(ctx context.Context, dto entity.GetBannersDTO) ([]entity.Banner, error) {
-	return service.storage.GetBanners(ctx, dto)
-}
-
-func (service *bannerService) UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) error {
-	return service.storage.UpdateBanner(ctx, dto)
-}
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/usecase"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/lru"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// cacheLockWait is how long a cache miss waits for the banner to be
+	// cached by the caller that holds the lock, before reading the storage
+	// itself.
+	cacheLockWait         = time.Second
+	cacheLockPollInterval = 25 * time.Millisecond
+)
+
+// deletedVersion is the version a deleted banner is invalidated with, so
+// that none of its versions is cached again.
+const deletedVersion = math.MaxInt64
+
+const (
+	// linkCacheSize banners are kept in memory for linkCacheTTL to redirect
+	// clicks to, unknown ones included. Changes made through other replicas
+	// are seen after linkCacheTTL at most.
+	linkCacheSize = 10000
+	linkCacheTTL  = 10 * time.Second
+)
+
+var _ usecase.BannerService = new(bannerService)
+var _ usecase.TokenService = new(tokenService)
+
+type BannerStorage interface {
+	CreateBanner(ctx context.Context, dto entity.CreateBannerDTO) (int64, error)
+	DeleteBanner(ctx context.Context, dto entity.DeleteBannerDTO) error
+	DeleteBanners(ctx context.Context, dto entity.DeleteBannersDTO, limit int) ([]int64, error)
+	GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UpdateCacheDTO, error)
+	GetBanners(ctx context.Context, dto entity.GetBannersDTO) ([]entity.Banner, error)
+	UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) (int64, error)
+	GetBanner(ctx context.Context, dto entity.GetBannerDTO) (entity.Banner, error)
+	GetBannerVersions(ctx context.Context, dto entity.GetBannerVersionsDTO) ([]entity.BannerVersion, error)
+	RestoreBanner(ctx context.Context, dto entity.RestoreBannerDTO) (int64, error)
+	ImportBanners(ctx context.Context, dto entity.ImportBannersDTO) (entity.ImportResult, error)
+	ExportBanners(ctx context.Context, dto entity.ExportBannersDTO, fn func(entity.Banner) error) error
+}
+
+type BannerCache interface {
+	Set(ctx context.Context, dto entity.UpdateCacheDTO) error
+	Get(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UserBanner, error)
+	// Invalidate drops the banner and keeps the versions before the given
+	// one from being cached again by readers that raced with the change.
+	Invalidate(ctx context.Context, bannerID, version int64) error
+	// Lock reports false if the banner of the pair is being refreshed by
+	// someone else. The token is passed back to Unlock.
+	Lock(ctx context.Context, featureID, tagID int64) (string, bool, error)
+	Unlock(ctx context.Context, featureID, tagID int64, token string) error
+}
+
+// bannerLink is a cached result of reading a banner for a click.
+type bannerLink struct {
+	banner entity.Banner
+	err    error
+}
+
+type bannerService struct {
+	storage BannerStorage
+	cache   BannerCache
+	// loads coalesces the concurrent cache misses of a feature and tag pair
+	// into a single storage read
+	loads singleflight.Group
+	links *lru.Cache[int64, bannerLink]
+	// linkGeneration is bumped whenever a link is dropped, so that a banner
+	// read before the change is not kept after it
+	linkGeneration atomic.Uint64
+}
+
+func NewBannerService(storage BannerStorage, cache BannerCache) *bannerService {
+	return &bannerService{
+		storage: storage,
+		cache:   cache,
+		links:   lru.New[int64, bannerLink](linkCacheSize, linkCacheTTL),
+	}
+}
+
+func (service *bannerService) CreateBanner(ctx context.Context, dto entity.CreateBannerDTO) (int64, error) {
+	if !dto.Principal.InScope(dto.FeatureID) {
+		return 0, errOutOfScope(dto.FeatureID)
+	}
+
+	return service.storage.CreateBanner(ctx, dto)
+}
+
+func (service *bannerService) DeleteBanner(ctx context.Context, dto entity.DeleteBannerDTO) error {
+	if dto.Principal.Scoped() {
+		banner, err := service.checkScope(ctx, dto.Principal, dto.BannerID)
+		if err != nil {
+			return err
+		}
+		if dto.ExpectedVersion == nil {
+			dto.ExpectedVersion = &banner.Version
+		}
+	}
+
+	err := service.storage.DeleteBanner(ctx, dto)
+	if err != nil {
+		return err
+	}
+
+	service.dropLink(dto.BannerID)
+	invalidate(ctx, service.cache, dto.BannerID, deletedVersion)
+	return nil
+}
+
+// invalidate drops the banner from the cache. The change is already stored,
+// so a failure is only logged; the banner is served stale until it expires.
+func invalidate(ctx context.Context, cache BannerCache, bannerID, version int64) {
+	err := cache.Invalidate(ctx, bannerID, version)
+	if err != nil {
+		cacheError("error invalidating banner in cache", err, "banner_id", bannerID)
+	}
+}
+
+// cacheError logs a failed cache call. The cache only saves trips to the
+// storage, so its failures do not fail the request. While the cache is known
+// to be unavailable they are not worth an error line each.
+func cacheError(msg string, err error, args ...any) {
+	args = append(args, "error", err)
+	if errors.Code(err) == errors.ErrCacheUnavailable {
+		slog.Debug(msg, args...)
+		return
+	}
+	slog.Error(msg, args...)
+}
+
+// checkScope returns the banner if the principal may manage it. Callers pin
+// the returned version as the expected one, so that the banner cannot move
+// to another feature before it is written.
+func (service *bannerService) checkScope(ctx context.Context, principal entity.Principal, bannerID int64) (entity.Banner, error) {
+	banner, err := service.storage.GetBanner(ctx, entity.GetBannerDTO{BannerID: bannerID})
+	if err != nil {
+		return entity.Banner{}, err
+	}
+
+	if !principal.InScope(banner.FeatureID) {
+		return entity.Banner{}, errOutOfScope(banner.FeatureID)
+	}
+
+	return banner, nil
+}
+
+func errOutOfScope(featureID int64) error {
+	return errors.NewDomainError(errors.ErrForbidden, "feature %d is out of the token scope", featureID)
+}
+
+func (service *bannerService) GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UserBanner, error) {
+
+	if dto.UseLastRevision {
+		banner, err := service.storage.GetUserBanner(ctx, dto)
+		if err != nil {
+			return entity.UserBanner{}, err
+		}
+
+		err = service.cache.Set(ctx, banner)
+		if err != nil {
+			cacheError("error caching banner", err)
+		}
+
+		return entity.UserBanner{
+			BannerID: banner.BannerID,
+			Content:  banner.Content,
+			Cache:    entity.CacheBypass,
+		}, nil
+	}
+
+	status := entity.CacheMiss
+	banner, err := service.cache.Get(ctx, dto)
+	switch {
+	case err == nil:
+		if banner.Stale {
+			service.refresh(ctx, dto)
+		}
+		slog.Debug("banner found in cache")
+		banner.Cache = entity.CacheHit
+		return banner, nil
+	case errors.Code(err) == errors.ErrForbidden:
+		return entity.UserBanner{}, err
+	case errors.Code(err) == errors.ErrCacheUnavailable:
+		status = entity.CacheBypass
+	case errors.Code(err) != errors.ErrNotCached:
+		cacheError("error getting banner from cache", err)
+	}
+
+	banner, err = service.load(ctx, dto)
+	if err != nil {
+		return entity.UserBanner{}, err
+	}
+
+	if !banner.VisibleTo(dto.IsAdmin) {
+		return entity.UserBanner{}, errors.NewDomainError(errors.ErrForbidden, "")
+	}
+
+	// the banner may have been cached by another replica while this one
+	// waited for the lock
+	banner.Cache = status
+	if !banner.CachedAt.IsZero() {
+		banner.Cache = entity.CacheHit
+	}
+
+	return banner, nil
+}
+
+// load reads the banner of the pair from the storage and caches it. The
+// banner is read as an admin would see it, so that one read serves all
+// callers; the callers check if they may see it.
+func (service *bannerService) load(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UserBanner, error) {
+	key := fmt.Sprintf("%d:%d", dto.FeatureID, dto.TagID)
+	v, err, _ := service.loads.Do(key, func() (any, error) {
+		return service.loadLocked(context.WithoutCancel(ctx), dto.FeatureID, dto.TagID, true)
+	})
+	if err != nil {
+		return entity.UserBanner{}, err
+	}
+
+	return v.(entity.UserBanner), nil
+}
+
+// refresh reloads a stale banner in the background, unless it is already
+// being refreshed here or on another replica.
+func (service *bannerService) refresh(ctx context.Context, dto entity.GetUserBannerDTO) {
+	key := fmt.Sprintf("refresh:%d:%d", dto.FeatureID, dto.TagID)
+	service.loads.DoChan(key, func() (any, error) {
+		banner, err := service.loadLocked(context.WithoutCancel(ctx), dto.FeatureID, dto.TagID, false)
+		if err != nil {
+			slog.Error("error refreshing banner", "feature_id", dto.FeatureID, "tag_id", dto.TagID, "error", err)
+		}
+		return banner, err
+	})
+}
+
+// loadLocked reads the storage under the cache lock of the pair. If the lock
+// is held by someone else, it returns nothing unless wait is set, in which
+// case it waits for the holder to cache the banner.
+func (service *bannerService) loadLocked(ctx context.Context, featureID, tagID int64, wait bool) (entity.UserBanner, error) {
+	dto := entity.GetUserBannerDTO{FeatureID: featureID, TagID: tagID, IsAdmin: true}
+
+	var waited time.Duration
+	for {
+		token, ok, err := service.cache.Lock(ctx, featureID, tagID)
+		if err != nil {
+			cacheError("error locking banner in cache", err)
+			break
+		}
+		if ok {
+			defer func() {
+				err := service.cache.Unlock(ctx, featureID, tagID, token)
+				if err != nil {
+					cacheError("error unlocking banner in cache", err)
+				}
+			}()
+			break
+		}
+		if !wait {
+			return entity.UserBanner{}, nil
+		}
+		if waited >= cacheLockWait {
+			slog.Debug("cache lock wait timed out", "feature_id", featureID, "tag_id", tagID)
+			break
+		}
+
+		time.Sleep(cacheLockPollInterval)
+		waited += cacheLockPollInterval
+
+		banner, err := service.cache.Get(ctx, dto)
+		if err == nil {
+			return banner, nil
+		}
+		if errors.Code(err) != errors.ErrNotCached {
+			cacheError("error getting banner from cache", err)
+			break
+		}
+	}
+
+	updateCacheDTO, err := service.storage.GetUserBanner(ctx, dto)
+	if err != nil {
+		return entity.UserBanner{}, err
+	}
+
+	err = service.cache.Set(ctx, updateCacheDTO)
+	if err != nil {
+		cacheError("error caching banner", err)
+	}
+
+	return entity.UserBanner{
+		BannerID: updateCacheDTO.BannerID,
+		Content:  updateCacheDTO.Content,
+		IsActive: updateCacheDTO.IsActive,
+		Schedule: updateCacheDTO.Schedule,
+	}, nil
+}
+
+// ImportBanners checks the scope of every row before any of them is written,
+// so that all problems of an import are reported at once.
+func (service *bannerService) ImportBanners(ctx context.Context, dto entity.ImportBannersDTO) (entity.ImportResult, error) {
+	var result entity.ImportResult
+	for _, row := range dto.Rows {
+		if !row.Banner.Principal.InScope(row.Banner.FeatureID) {
+			result.Errors = append(result.Errors, entity.ImportError{
+				Line:  row.Line,
+				Error: errOutOfScope(row.Banner.FeatureID).Error(),
+			})
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	return service.storage.ImportBanners(ctx, dto)
+}
+
+func (service *bannerService) ExportBanners(ctx context.Context, dto entity.ExportBannersDTO, fn func(entity.Banner) error) error {
+	if dto.Principal.Scoped() {
+		dto.FeatureIDs = dto.Principal.FeatureIDs
+	}
+
+	return service.storage.ExportBanners(ctx, dto, fn)
+}
+
+func (service *bannerService) GetBanners(ctx context.Context, dto entity.GetBannersDTO) ([]entity.Banner, error) {
+	if dto.Principal.Scoped() {
+		featureID, ok := dto.Filters[entity.BannerFilterFeature]
+		if ok && !dto.Principal.InScope(featureID) {
+			return nil, errOutOfScope(featureID)
+		}
+		dto.FeatureIDs = dto.Principal.FeatureIDs
+	}
+
+	return service.storage.GetBanners(ctx, dto)
+}
+
+func (service *bannerService) UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) (int64, error) {
+	if dto.Principal.Scoped() {
+		if dto.FeatureID != nil && !dto.Principal.InScope(*dto.FeatureID) {
+			return 0, errOutOfScope(*dto.FeatureID)
+		}
+
+		banner, err := service.checkScope(ctx, dto.Principal, dto.BannerID)
+		if err != nil {
+			return 0, err
+		}
+		if dto.ExpectedVersion == nil {
+			dto.ExpectedVersion = &banner.Version
+		}
+	}
+
+	version, err := service.storage.UpdateBanner(ctx, dto)
+	if err != nil {
+		return 0, err
+	}
+
+	service.dropLink(dto.BannerID)
+	invalidate(ctx, service.cache, dto.BannerID, version)
+	return version, nil
+}
+
+func (service *bannerService) GetBanner(ctx context.Context, dto entity.GetBannerDTO) (entity.Banner, error) {
+	if dto.Principal.Scoped() {
+		return service.checkScope(ctx, dto.Principal, dto.BannerID)
+	}
+
+	return service.storage.GetBanner(ctx, dto)
+}
+
+// GetBannerLink returns the banner to redirect a click on it to. Banners
+// are kept in memory, unknown ones too, so that the public click route does
+// not cost a storage read per request.
+func (service *bannerService) GetBannerLink(ctx context.Context, bannerID int64) (entity.Banner, error) {
+	if link, ok := service.links.Get(bannerID); ok {
+		return link.banner, link.err
+	}
+
+	generation := service.linkGeneration.Load()
+	banner, err := service.storage.GetBanner(ctx, entity.GetBannerDTO{BannerID: bannerID})
+
+	switch {
+	case service.linkGeneration.Load() != generation:
+	case err == nil:
+		service.links.Set(bannerID, bannerLink{banner: banner})
+	case errors.Code(err) == errors.ErrNoDataFound:
+		service.links.Set(bannerID, bannerLink{err: err})
+	}
+
+	return banner, err
+}
+
+func (service *bannerService) dropLink(bannerID int64) {
+	service.linkGeneration.Add(1)
+	service.links.Remove(bannerID)
+}
+
+// GetBannerVersions checks the scope against the current feature of the
+// banner, the versions of a banner that moved between features are all shown
+// to whoever manages it now.
+func (service *bannerService) GetBannerVersions(ctx context.Context, dto entity.GetBannerVersionsDTO) ([]entity.BannerVersion, error) {
+	if dto.Principal.Scoped() {
+		_, err := service.checkScope(ctx, dto.Principal, dto.BannerID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return service.storage.GetBannerVersions(ctx, dto)
+}
+
+func (service *bannerService) RestoreBanner(ctx context.Context, dto entity.RestoreBannerDTO) (int64, error) {
+	if dto.Principal.Scoped() {
+		banner, err := service.checkScope(ctx, dto.Principal, dto.BannerID)
+		if err != nil {
+			return 0, err
+		}
+		if dto.ExpectedVersion == nil {
+			dto.ExpectedVersion = &banner.Version
+		}
+
+		versions, err := service.storage.GetBannerVersions(ctx, entity.GetBannerVersionsDTO{BannerID: dto.BannerID})
+		if err != nil {
+			return 0, err
+		}
+		for _, v := range versions {
+			if v.Version == dto.Version && !dto.Principal.InScope(v.FeatureID) {
+				return 0, errOutOfScope(v.FeatureID)
+			}
+		}
+	}
+
+	version, err := service.storage.RestoreBanner(ctx, dto)
+	if err != nil {
+		return 0, err
+	}
+
+	service.dropLink(dto.BannerID)
+	invalidate(ctx, service.cache, dto.BannerID, version)
+	return version, nil
+}
@@ -0,0 +1,481 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStorage serves banner from GetUserBanner and counts the reads. If
+// release is set, the reads block until it is closed. stored and versions
+// are the banner that the admin API reads and changes; the changes are only
+// counted.
+type fakeStorage struct {
+	BannerStorage
+
+	mu      sync.Mutex
+	banner  entity.UpdateCacheDTO
+	reads   int
+	release chan struct{}
+
+	stored   entity.Banner
+	versions []entity.BannerVersion
+	writes   int
+	// getErr fails GetBanner, which counts its calls in gets
+	getErr error
+	gets   int
+}
+
+func (s *fakeStorage) GetBanner(ctx context.Context, dto entity.GetBannerDTO) (entity.Banner, error) {
+	s.mu.Lock()
+	s.gets++
+	s.mu.Unlock()
+
+	if s.getErr != nil {
+		return entity.Banner{}, s.getErr
+	}
+	return s.stored, nil
+}
+
+func (s *fakeStorage) GetBannerVersions(ctx context.Context, dto entity.GetBannerVersionsDTO) ([]entity.BannerVersion, error) {
+	return s.versions, nil
+}
+
+func (s *fakeStorage) GetBanners(ctx context.Context, dto entity.GetBannersDTO) ([]entity.Banner, error) {
+	return []entity.Banner{s.stored}, nil
+}
+
+func (s *fakeStorage) CreateBanner(ctx context.Context, dto entity.CreateBannerDTO) (int64, error) {
+	s.writes++
+	return 1, nil
+}
+
+func (s *fakeStorage) UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) (int64, error) {
+	s.writes++
+	return s.stored.Version + 1, nil
+}
+
+func (s *fakeStorage) DeleteBanner(ctx context.Context, dto entity.DeleteBannerDTO) error {
+	s.writes++
+	return nil
+}
+
+func (s *fakeStorage) RestoreBanner(ctx context.Context, dto entity.RestoreBannerDTO) (int64, error) {
+	s.writes++
+	return s.stored.Version + 1, nil
+}
+
+func (s *fakeStorage) ImportBanners(ctx context.Context, dto entity.ImportBannersDTO) (entity.ImportResult, error) {
+	s.writes++
+	return entity.ImportResult{BannerIDs: []int64{1}}, nil
+}
+
+func (s *fakeStorage) GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UpdateCacheDTO, error) {
+	s.mu.Lock()
+	s.reads++
+	s.mu.Unlock()
+
+	if s.release != nil {
+		<-s.release
+	}
+	return s.banner, nil
+}
+
+func (s *fakeStorage) readCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reads
+}
+
+// fakeCache misses the first misses Gets and serves banner afterwards. Lock
+// fails while locked is set, as if another replica held the lock.
+// Invalidations are kept by banner ID.
+type fakeCache struct {
+	mu          sync.Mutex
+	banner      entity.UserBanner
+	misses      int
+	gets        int
+	locked      bool
+	invalidated map[int64]int64
+}
+
+func (c *fakeCache) Set(ctx context.Context, dto entity.UpdateCacheDTO) error {
+	return nil
+}
+
+func (c *fakeCache) Get(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UserBanner, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gets++
+	if c.gets <= c.misses {
+		return entity.UserBanner{}, errors.NewDomainError(errors.ErrNotCached, "")
+	}
+	return c.banner, nil
+}
+
+func (c *fakeCache) getCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gets
+}
+
+func (c *fakeCache) Invalidate(ctx context.Context, bannerID, version int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.invalidated == nil {
+		c.invalidated = make(map[int64]int64)
+	}
+	c.invalidated[bannerID] = version
+	return nil
+}
+
+func (c *fakeCache) Lock(ctx context.Context, featureID, tagID int64) (string, bool, error) {
+	return "token", !c.locked, nil
+}
+
+func (c *fakeCache) Unlock(ctx context.Context, featureID, tagID int64, token string) error {
+	return nil
+}
+
+func Test_bannerService_GetUserBanner_Coalesce(t *testing.T) {
+	const callers = 20
+
+	storage := &fakeStorage{
+		banner:  entity.UpdateCacheDTO{BannerID: 1, IsActive: true},
+		release: make(chan struct{}),
+	}
+	cache := &fakeCache{misses: math.MaxInt}
+	service := NewBannerService(storage, cache)
+
+	type result struct {
+		banner entity.UserBanner
+		err    error
+	}
+	var wg sync.WaitGroup
+	results := make(chan result, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			banner, err := service.GetUserBanner(context.Background(), entity.GetUserBannerDTO{FeatureID: 1, TagID: 1})
+			results <- result{banner, err}
+		}()
+	}
+
+	// every caller has missed the cache and waits on the read in flight
+	require.Eventually(t, func() bool {
+		return cache.getCount() == callers && storage.readCount() == 1
+	}, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	close(storage.release)
+	wg.Wait()
+	close(results)
+
+	require.Equal(t, 1, storage.readCount())
+	for result := range results {
+		require.NoError(t, result.err)
+		require.EqualValues(t, 1, result.banner.BannerID)
+		require.Equal(t, entity.CacheMiss, result.banner.Cache)
+	}
+}
+
+func Test_bannerService_GetUserBanner_Locked(t *testing.T) {
+	storage := &fakeStorage{banner: entity.UpdateCacheDTO{BannerID: 1, IsActive: true}}
+	// the holder of the lock caches the banner while the caller polls
+	cache := &fakeCache{
+		banner: entity.UserBanner{BannerID: 1, IsActive: true, CachedAt: time.Now()},
+		misses: 2,
+		locked: true,
+	}
+	service := NewBannerService(storage, cache)
+
+	banner, err := service.GetUserBanner(context.Background(), entity.GetUserBannerDTO{FeatureID: 1, TagID: 1})
+	require.NoError(t, err)
+
+	require.EqualValues(t, 1, banner.BannerID)
+	require.Equal(t, entity.CacheHit, banner.Cache)
+	require.Zero(t, storage.readCount())
+}
+
+func Test_bannerService_GetUserBanner_Stale(t *testing.T) {
+	tests := []struct {
+		name      string
+		locked    bool
+		wantReads int
+	}{
+		{
+			name:      "stale banner is refreshed in the background",
+			locked:    false,
+			wantReads: 1,
+		},
+		{
+			name:      "stale banner refreshed elsewhere is left alone",
+			locked:    true,
+			wantReads: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &fakeStorage{banner: entity.UpdateCacheDTO{BannerID: 1, IsActive: true}}
+			cache := &fakeCache{
+				banner: entity.UserBanner{BannerID: 1, IsActive: true, Stale: true},
+				locked: tt.locked,
+			}
+			service := NewBannerService(storage, cache)
+
+			banner, err := service.GetUserBanner(context.Background(), entity.GetUserBannerDTO{FeatureID: 1, TagID: 1})
+			require.NoError(t, err)
+			require.Equal(t, entity.CacheHit, banner.Cache)
+
+			// joins the refresh if it is still running
+			service.loads.Do("refresh:1:1", func() (any, error) {
+				return nil, nil
+			})
+			require.Equal(t, tt.wantReads, storage.readCount())
+		})
+	}
+}
+
+func Test_bannerService_Scope(t *testing.T) {
+	scoped := entity.Principal{TokenID: 1, Role: entity.RolePublisher, FeatureIDs: []int64{1, 2}}
+	otherFeature := int64(3)
+	ownFeature := int64(2)
+
+	tests := []struct {
+		name string
+		// call runs the method under test as the scoped principal, on a
+		// banner of feature 1 whose version 1 belonged to feature 3
+		call    func(service *bannerService) error
+		wantErr bool
+	}{
+		{
+			name: "positive, create in scope",
+			call: func(service *bannerService) error {
+				_, err := service.CreateBanner(context.Background(), entity.CreateBannerDTO{FeatureID: 2, Principal: scoped})
+				return err
+			},
+		},
+		{
+			name: "negative, create out of scope",
+			call: func(service *bannerService) error {
+				_, err := service.CreateBanner(context.Background(), entity.CreateBannerDTO{FeatureID: 3, Principal: scoped})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "positive, update in scope",
+			call: func(service *bannerService) error {
+				_, err := service.UpdateBanner(context.Background(), entity.UpdateBannerDTO{BannerID: 1, FeatureID: &ownFeature, Principal: scoped})
+				return err
+			},
+		},
+		{
+			name: "negative, update moving the banner out of scope",
+			call: func(service *bannerService) error {
+				_, err := service.UpdateBanner(context.Background(), entity.UpdateBannerDTO{BannerID: 1, FeatureID: &otherFeature, Principal: scoped})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative, restore a version out of scope",
+			call: func(service *bannerService) error {
+				_, err := service.RestoreBanner(context.Background(), entity.RestoreBannerDTO{BannerID: 1, Version: 1, Principal: scoped})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "positive, restore a version in scope",
+			call: func(service *bannerService) error {
+				_, err := service.RestoreBanner(context.Background(), entity.RestoreBannerDTO{BannerID: 1, Version: 2, Principal: scoped})
+				return err
+			},
+		},
+		{
+			name: "positive, delete in scope",
+			call: func(service *bannerService) error {
+				return service.DeleteBanner(context.Background(), entity.DeleteBannerDTO{BannerID: 1, Principal: scoped})
+			},
+		},
+		{
+			name: "negative, list filtered by a feature out of scope",
+			call: func(service *bannerService) error {
+				_, err := service.GetBanners(context.Background(), entity.GetBannersDTO{
+					Filters:   map[string]int64{entity.BannerFilterFeature: 3},
+					Principal: scoped,
+				})
+				return err
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &fakeStorage{
+				stored: entity.Banner{BannerID: 1, FeatureID: 1, Version: 2},
+				versions: []entity.BannerVersion{
+					{Version: 1, FeatureID: 3},
+					{Version: 2, FeatureID: 1},
+				},
+			}
+			service := NewBannerService(storage, &fakeCache{})
+
+			err := tt.call(service)
+			if tt.wantErr {
+				require.Equal(t, errors.ErrForbidden, errors.Code(err))
+				require.Zero(t, storage.writes)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, 1, storage.writes)
+		})
+	}
+
+	t.Run("negative, banner out of scope", func(t *testing.T) {
+		storage := &fakeStorage{stored: entity.Banner{BannerID: 1, FeatureID: 3, Version: 1}}
+		service := NewBannerService(storage, &fakeCache{})
+
+		_, err := service.UpdateBanner(context.Background(), entity.UpdateBannerDTO{BannerID: 1, Principal: scoped})
+		require.Equal(t, errors.ErrForbidden, errors.Code(err))
+		err = service.DeleteBanner(context.Background(), entity.DeleteBannerDTO{BannerID: 1, Principal: scoped})
+		require.Equal(t, errors.ErrForbidden, errors.Code(err))
+		_, err = service.RestoreBanner(context.Background(), entity.RestoreBannerDTO{BannerID: 1, Version: 1, Principal: scoped})
+		require.Equal(t, errors.ErrForbidden, errors.Code(err))
+		require.Zero(t, storage.writes)
+
+		_, err = service.GetBanner(context.Background(), entity.GetBannerDTO{BannerID: 1, Principal: scoped})
+		require.Equal(t, errors.ErrForbidden, errors.Code(err))
+		_, err = service.GetBannerVersions(context.Background(), entity.GetBannerVersionsDTO{BannerID: 1, Principal: scoped})
+		require.Equal(t, errors.ErrForbidden, errors.Code(err))
+	})
+
+	t.Run("positive, read banner in scope", func(t *testing.T) {
+		storage := &fakeStorage{
+			stored:   entity.Banner{BannerID: 1, FeatureID: 1, Version: 2},
+			versions: []entity.BannerVersion{{Version: 1, FeatureID: 3}, {Version: 2, FeatureID: 1}},
+		}
+		service := NewBannerService(storage, &fakeCache{})
+
+		banner, err := service.GetBanner(context.Background(), entity.GetBannerDTO{BannerID: 1, Principal: scoped})
+		require.NoError(t, err)
+		require.Equal(t, storage.stored, banner)
+		versions, err := service.GetBannerVersions(context.Background(), entity.GetBannerVersionsDTO{BannerID: 1, Principal: scoped})
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+	})
+
+	t.Run("negative, import with a row out of scope writes nothing", func(t *testing.T) {
+		storage := &fakeStorage{}
+		service := NewBannerService(storage, &fakeCache{})
+
+		result, err := service.ImportBanners(context.Background(), entity.ImportBannersDTO{Rows: []entity.ImportRow{
+			{Line: 1, Banner: entity.CreateBannerDTO{FeatureID: 1, Principal: scoped}},
+			{Line: 2, Banner: entity.CreateBannerDTO{FeatureID: 3, Principal: scoped}},
+		}})
+		require.NoError(t, err)
+		require.Len(t, result.Errors, 1)
+		require.Equal(t, 2, result.Errors[0].Line)
+		require.Zero(t, storage.writes)
+	})
+
+	t.Run("positive, list is limited to the scope", func(t *testing.T) {
+		var got entity.GetBannersDTO
+		storage := &scopeRecorder{fakeStorage: &fakeStorage{}, dto: &got}
+		service := NewBannerService(storage, &fakeCache{})
+
+		_, err := service.GetBanners(context.Background(), entity.GetBannersDTO{Principal: scoped})
+		require.NoError(t, err)
+		require.Equal(t, scoped.FeatureIDs, got.FeatureIDs)
+	})
+}
+
+// scopeRecorder keeps the GetBannersDTO that reaches the storage.
+type scopeRecorder struct {
+	*fakeStorage
+	dto *entity.GetBannersDTO
+}
+
+func (s *scopeRecorder) GetBanners(ctx context.Context, dto entity.GetBannersDTO) ([]entity.Banner, error) {
+	*s.dto = dto
+	return nil, nil
+}
+
+func Test_bannerService_GetBannerLink(t *testing.T) {
+	t.Run("positive, served from memory until the banner changes", func(t *testing.T) {
+		storage := &fakeStorage{stored: entity.Banner{BannerID: 1, Content: entity.BannerContent{URL: "https://a.example"}}}
+		service := NewBannerService(storage, &fakeCache{})
+
+		for i := 0; i < 2; i++ {
+			banner, err := service.GetBannerLink(context.Background(), 1)
+			require.NoError(t, err)
+			require.Equal(t, "https://a.example", banner.Content.URL)
+		}
+		require.Equal(t, 1, storage.gets)
+
+		_, err := service.UpdateBanner(context.Background(), entity.UpdateBannerDTO{BannerID: 1})
+		require.NoError(t, err)
+		_, err = service.GetBannerLink(context.Background(), 1)
+		require.NoError(t, err)
+		require.Equal(t, 2, storage.gets)
+	})
+
+	t.Run("negative, unknown banners are kept too", func(t *testing.T) {
+		storage := &fakeStorage{getErr: errors.NewDomainError(errors.ErrNoDataFound, "")}
+		service := NewBannerService(storage, &fakeCache{})
+
+		for i := 0; i < 2; i++ {
+			_, err := service.GetBannerLink(context.Background(), 1)
+			require.Equal(t, errors.ErrNoDataFound, errors.Code(err))
+		}
+		require.Equal(t, 1, storage.gets)
+	})
+
+	t.Run("negative, storage failures are not kept", func(t *testing.T) {
+		storage := &fakeStorage{getErr: errors.NewDomainError(errors.ErrDB, "")}
+		service := NewBannerService(storage, &fakeCache{})
+
+		for i := 0; i < 2; i++ {
+			_, err := service.GetBannerLink(context.Background(), 1)
+			require.Equal(t, errors.ErrDB, errors.Code(err))
+		}
+		require.Equal(t, 2, storage.gets)
+	})
+
+	t.Run("negative, a read that raced with a change is not kept", func(t *testing.T) {
+		storage := &fakeStorage{stored: entity.Banner{BannerID: 1}}
+		service := NewBannerService(storage, &fakeCache{})
+		racing := &racingStorage{fakeStorage: storage, onGet: func() { service.dropLink(1) }}
+		service.storage = racing
+
+		_, err := service.GetBannerLink(context.Background(), 1)
+		require.NoError(t, err)
+		_, err = service.GetBannerLink(context.Background(), 1)
+		require.NoError(t, err)
+		require.Equal(t, 2, storage.gets)
+	})
+}
+
+// racingStorage runs onGet once, while the first GetBanner reads.
+type racingStorage struct {
+	*fakeStorage
+	onGet func()
+}
+
+func (s *racingStorage) GetBanner(ctx context.Context, dto entity.GetBannerDTO) (entity.Banner, error) {
+	banner, err := s.fakeStorage.GetBanner(ctx, dto)
+	if s.onGet != nil {
+		s.onGet()
+		s.onGet = nil
+	}
+	return banner, err
+}
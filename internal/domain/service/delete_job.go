@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"log/slog"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/usecase"
+	"github.com/The-Gleb/banner_service/internal/errors"
+)
+
+var _ usecase.DeleteJobService = new(deleteJobService)
+
+const (
+	deleteJobBatchSize    = 100
+	deleteJobQueueSize    = 64
+	deleteJobPollInterval = 30 * time.Second
+	// deleteJobLease is how long a running job may go without a heartbeat
+	// before another worker takes it over. The worker beats several times a
+	// lease, whether its batches make progress or wait on locks.
+	deleteJobLease = 5 * time.Minute
+)
+
+// errLeaseLost cancels a job whose lease has been taken over, or could not be
+// extended for a whole lease and may have been.
+var errLeaseLost = stdErrors.New("delete job lease lost")
+
+type DeleteJobStorage interface {
+	CreateDeleteJob(ctx context.Context, dto entity.DeleteBannersDTO) (entity.DeleteJob, error)
+	GetDeleteJob(ctx context.Context, dto entity.GetDeleteJobDTO) (entity.DeleteJob, error)
+	ClaimDeleteJob(ctx context.Context, jobID int64, lease time.Duration) (entity.DeleteJob, error)
+	GetPendingDeleteJobs(ctx context.Context, lease time.Duration) ([]int64, error)
+	HeartbeatDeleteJob(ctx context.Context, job entity.DeleteJob) error
+	UpdateDeleteJob(ctx context.Context, job entity.DeleteJob) error
+}
+
+// deleteJobService accepts bulk deletion requests and runs them in the
+// background. Jobs are persisted, so the ones that did not fit into the
+// queue, were left over by a restart or by a worker that died are picked up
+// by polling.
+type deleteJobService struct {
+	jobs    DeleteJobStorage
+	banners BannerStorage
+	cache   BannerCache
+	queue   chan int64
+	lease   time.Duration
+}
+
+func NewDeleteJobService(jobs DeleteJobStorage, banners BannerStorage, cache BannerCache) *deleteJobService {
+	return &deleteJobService{
+		jobs:    jobs,
+		banners: banners,
+		cache:   cache,
+		queue:   make(chan int64, deleteJobQueueSize),
+		lease:   deleteJobLease,
+	}
+}
+
+func (service *deleteJobService) CreateDeleteJob(ctx context.Context, dto entity.DeleteBannersDTO) (entity.DeleteJob, error) {
+	// the job runs without the principal, so the filter itself must keep
+	// it within the scope
+	if dto.Principal.Scoped() && !dto.Principal.InScope(dto.FeatureID) {
+		return entity.DeleteJob{}, errors.NewDomainError(errors.ErrForbidden, "feature_id out of the token scope")
+	}
+
+	job, err := service.jobs.CreateDeleteJob(ctx, dto)
+	if err != nil {
+		return entity.DeleteJob{}, err
+	}
+
+	select {
+	case service.queue <- job.ID:
+	default:
+		slog.Warn("delete job queue is full, job will be picked up by polling", "job_id", job.ID)
+	}
+
+	return job, nil
+}
+
+func (service *deleteJobService) GetDeleteJob(ctx context.Context, dto entity.GetDeleteJobDTO) (entity.DeleteJob, error) {
+	job, err := service.jobs.GetDeleteJob(ctx, dto)
+	if err != nil {
+		return entity.DeleteJob{}, err
+	}
+
+	// scoped principals can only create jobs filtered by a feature of their
+	// scope, so a job without a feature is never theirs
+	if dto.Principal.Scoped() && !dto.Principal.InScope(job.FeatureID) {
+		return entity.DeleteJob{}, errOutOfScope(job.FeatureID)
+	}
+
+	return job, nil
+}
+
+// Run processes delete jobs until ctx is cancelled.
+func (service *deleteJobService) Run(ctx context.Context) {
+	ticker := time.NewTicker(deleteJobPollInterval)
+	defer ticker.Stop()
+
+	service.processPending(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case jobID := <-service.queue:
+			service.process(ctx, jobID)
+		case <-ticker.C:
+			service.processPending(ctx)
+		}
+	}
+}
+
+func (service *deleteJobService) processPending(ctx context.Context) {
+	jobIDs, err := service.jobs.GetPendingDeleteJobs(ctx, service.lease)
+	if err != nil {
+		slog.Error("error getting pending delete jobs", "error", err)
+		return
+	}
+
+	for _, jobID := range jobIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		service.process(ctx, jobID)
+	}
+}
+
+func (service *deleteJobService) process(ctx context.Context, jobID int64) {
+	job, err := service.jobs.ClaimDeleteJob(ctx, jobID, service.lease)
+	if err != nil {
+		if errors.Code(err) != errors.ErrNoDataFound {
+			slog.Error("error claiming delete job", "job_id", jobID, "error", err)
+		}
+		return
+	}
+
+	// batches run under the lease, the heartbeat cancels them once it is lost
+	leaseCtx, cancel := context.WithCancelCause(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		service.heartbeat(leaseCtx, job, cancel)
+	}()
+	stop := func() {
+		cancel(nil)
+		<-stopped
+	}
+	defer stop()
+
+	slog.Info("delete job started", "job_id", job.ID, "feature_id", job.FeatureID, "tag_id", job.TagID)
+
+	filter := entity.DeleteBannersDTO{
+		FeatureID: job.FeatureID,
+		TagID:     job.TagID,
+		Principal: job.Principal,
+		RequestID: job.RequestID,
+	}
+	for {
+		bannerIDs, err := service.banners.DeleteBanners(leaseCtx, filter, deleteJobBatchSize)
+		if err != nil {
+			if stdErrors.Is(context.Cause(leaseCtx), errLeaseLost) {
+				slog.Warn("delete job lease lost, leaving the job to its new worker", "job_id", job.ID)
+				return
+			}
+			if ctx.Err() != nil {
+				// shutting down, let the job be resumed later
+				stop()
+				job.Status = entity.DeleteJobPending
+				service.saveJob(job)
+				return
+			}
+			job.Status = entity.DeleteJobFailed
+			job.Error = err.Error()
+			break
+		}
+
+		for _, bannerID := range bannerIDs {
+			invalidate(ctx, service.cache, bannerID, deletedVersion)
+		}
+
+		job.Deleted += int64(len(bannerIDs))
+		if len(bannerIDs) < deleteJobBatchSize {
+			job.Status = entity.DeleteJobDone
+			break
+		}
+
+		err = service.saveJob(job)
+		if errors.Code(err) == errors.ErrNoDataFound {
+			slog.Warn("delete job lease lost, leaving the job to its new worker", "job_id", job.ID)
+			return
+		}
+	}
+
+	// the job is no longer running once it is saved, so the heartbeat
+	// would find it taken over
+	stop()
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	err = service.saveJob(job)
+	if errors.Code(err) == errors.ErrNoDataFound {
+		slog.Warn("delete job lease lost, leaving the job to its new worker", "job_id", job.ID)
+		return
+	}
+
+	slog.Info("delete job finished", "job_id", job.ID, "status", job.Status, "deleted", job.Deleted)
+}
+
+// heartbeat extends the lease of the job until ctx is cancelled. It cancels
+// ctx with errLeaseLost if the job has been taken over, or if the lease could
+// not be extended for so long that another worker may have taken it over.
+func (service *deleteJobService) heartbeat(ctx context.Context, job entity.DeleteJob, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(service.lease / 5)
+	defer ticker.Stop()
+
+	lastBeat := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := service.jobs.HeartbeatDeleteJob(ctx, job)
+		switch {
+		case err == nil:
+			lastBeat = time.Now()
+		case errors.Code(err) == errors.ErrNoDataFound:
+			cancel(errLeaseLost)
+			return
+		case ctx.Err() != nil:
+			return
+		default:
+			slog.Error("error extending delete job lease", "job_id", job.ID, "error", err)
+			if time.Since(lastBeat) >= service.lease {
+				cancel(errLeaseLost)
+				return
+			}
+		}
+	}
+}
+
+// saveJob persists the job state even if the worker context is cancelled.
+// It returns ErrNoDataFound if the job has been taken over.
+func (service *deleteJobService) saveJob(job entity.DeleteJob) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := service.jobs.UpdateDeleteJob(ctx, job)
+	if err != nil && errors.Code(err) != errors.ErrNoDataFound {
+		slog.Error("error saving delete job", "job_id", job.ID, "error", err)
+	}
+	return err
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeJobStorage keeps the jobs in memory. Heartbeats and saves are fenced
+// by the lease like in the storage, and fail with heartbeatErr if it is set.
+type fakeJobStorage struct {
+	DeleteJobStorage
+
+	mu           sync.Mutex
+	jobs         []entity.DeleteJob
+	beats        int
+	saves        int
+	heartbeatErr error
+}
+
+func (s *fakeJobStorage) CreateDeleteJob(ctx context.Context, dto entity.DeleteBannersDTO) (entity.DeleteJob, error) {
+	job := entity.DeleteJob{
+		ID:        int64(len(s.jobs) + 1),
+		FeatureID: dto.FeatureID,
+		TagID:     dto.TagID,
+		Status:    entity.DeleteJobPending,
+	}
+	s.jobs = append(s.jobs, job)
+	return job, nil
+}
+
+func Test_deleteJobService_CreateDeleteJob(t *testing.T) {
+	scoped := entity.Principal{TokenID: 1, Role: entity.RolePublisher, FeatureIDs: []int64{1}}
+
+	tests := []struct {
+		name    string
+		dto     entity.DeleteBannersDTO
+		wantErr bool
+	}{
+		{
+			name: "positive, feature in scope",
+			dto:  entity.DeleteBannersDTO{FeatureID: 1, TagID: 2, Principal: scoped},
+		},
+		{
+			name: "positive, unscoped by tag",
+			dto:  entity.DeleteBannersDTO{TagID: 2},
+		},
+		{
+			name:    "negative, feature out of scope",
+			dto:     entity.DeleteBannersDTO{FeatureID: 2, Principal: scoped},
+			wantErr: true,
+		},
+		{
+			name:    "negative, scoped by tag only",
+			dto:     entity.DeleteBannersDTO{TagID: 2, Principal: scoped},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := &fakeJobStorage{}
+			service := NewDeleteJobService(jobs, &fakeStorage{}, &fakeCache{})
+
+			_, err := service.CreateDeleteJob(context.Background(), tt.dto)
+			if tt.wantErr {
+				require.Equal(t, errors.ErrForbidden, errors.Code(err))
+				require.Empty(t, jobs.jobs)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, jobs.jobs, 1)
+		})
+	}
+}
+
+func (s *fakeJobStorage) GetDeleteJob(ctx context.Context, dto entity.GetDeleteJobDTO) (entity.DeleteJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if dto.JobID < 1 || int(dto.JobID) > len(s.jobs) {
+		return entity.DeleteJob{}, errors.NewDomainError(errors.ErrNoDataFound, "")
+	}
+	return s.jobs[dto.JobID-1], nil
+}
+
+func Test_deleteJobService_GetDeleteJob(t *testing.T) {
+	scoped := entity.Principal{TokenID: 1, Role: entity.RolePublisher, FeatureIDs: []int64{1}}
+	jobs := &fakeJobStorage{jobs: []entity.DeleteJob{
+		{ID: 1, FeatureID: 1},
+		{ID: 2, FeatureID: 2},
+		{ID: 3, TagID: 2},
+	}}
+	service := NewDeleteJobService(jobs, &fakeStorage{}, &fakeCache{})
+
+	tests := []struct {
+		name      string
+		dto       entity.GetDeleteJobDTO
+		wantErr   bool
+		wantJobID int64
+	}{
+		{
+			name:      "positive, feature in scope",
+			dto:       entity.GetDeleteJobDTO{JobID: 1, Principal: scoped},
+			wantJobID: 1,
+		},
+		{
+			name:      "positive, unscoped",
+			dto:       entity.GetDeleteJobDTO{JobID: 2},
+			wantJobID: 2,
+		},
+		{
+			name:    "negative, feature out of scope",
+			dto:     entity.GetDeleteJobDTO{JobID: 2, Principal: scoped},
+			wantErr: true,
+		},
+		{
+			name:    "negative, job by tag only",
+			dto:     entity.GetDeleteJobDTO{JobID: 3, Principal: scoped},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := service.GetDeleteJob(context.Background(), tt.dto)
+			if tt.wantErr {
+				require.Equal(t, errors.ErrForbidden, errors.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantJobID, job.ID)
+		})
+	}
+}
+
+func (s *fakeJobStorage) ClaimDeleteJob(ctx context.Context, jobID int64, lease time.Duration) (entity.DeleteJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := &s.jobs[jobID-1]
+	job.Status = entity.DeleteJobRunning
+	job.Lease++
+	return *job, nil
+}
+
+func (s *fakeJobStorage) HeartbeatDeleteJob(ctx context.Context, job entity.DeleteJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.heartbeatErr != nil {
+		return s.heartbeatErr
+	}
+	stored := s.jobs[job.ID-1]
+	if stored.Lease != job.Lease || stored.Status != entity.DeleteJobRunning {
+		return errors.NewDomainError(errors.ErrNoDataFound, "")
+	}
+	s.beats++
+	return nil
+}
+
+func (s *fakeJobStorage) UpdateDeleteJob(ctx context.Context, job entity.DeleteJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.jobs[job.ID-1].Lease != job.Lease {
+		return errors.NewDomainError(errors.ErrNoDataFound, "")
+	}
+	s.jobs[job.ID-1] = job
+	s.saves++
+	return nil
+}
+
+func (s *fakeJobStorage) state() (job entity.DeleteJob, beats, saves int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[0], s.beats, s.saves
+}
+
+// blockedBanners deletes a single batch of three banners once release is
+// closed, as if the batch waited on locks until then. cancelled is closed if
+// the batch is cancelled instead.
+type blockedBanners struct {
+	BannerStorage
+
+	release   chan struct{}
+	cancelled chan struct{}
+}
+
+func (s *blockedBanners) DeleteBanners(ctx context.Context, dto entity.DeleteBannersDTO, limit int) ([]int64, error) {
+	select {
+	case <-s.release:
+		return []int64{1, 2, 3}, nil
+	case <-ctx.Done():
+		close(s.cancelled)
+		return nil, errors.NewDomainError(errors.ErrDB, "")
+	}
+}
+
+func Test_deleteJobService_process_Lease(t *testing.T) {
+	const lease = 50 * time.Millisecond
+
+	tests := []struct {
+		name string
+		// during runs while the batch is blocked, cancel shuts the worker
+		// down
+		during       func(t *testing.T, jobs *fakeJobStorage, banners *blockedBanners, cancel context.CancelFunc)
+		heartbeatErr error
+		wantStatus   entity.DeleteJobStatus
+		wantDeleted  int64
+		wantLease    int64
+		wantSaves    int
+	}{
+		{
+			name: "positive, beats while the batch waits on locks",
+			during: func(t *testing.T, jobs *fakeJobStorage, banners *blockedBanners, cancel context.CancelFunc) {
+				require.Eventually(t, func() bool {
+					_, beats, _ := jobs.state()
+					return beats >= 3
+				}, time.Second, time.Millisecond)
+			},
+			wantStatus:  entity.DeleteJobDone,
+			wantDeleted: 3,
+			wantLease:   1,
+			wantSaves:   1,
+		},
+		{
+			name: "negative, taken over by another worker",
+			during: func(t *testing.T, jobs *fakeJobStorage, banners *blockedBanners, cancel context.CancelFunc) {
+				_, err := jobs.ClaimDeleteJob(context.Background(), 1, lease)
+				require.NoError(t, err)
+				waitClosed(t, banners.cancelled)
+			},
+			wantStatus: entity.DeleteJobRunning,
+			wantLease:  2,
+		},
+		{
+			name:         "negative, lease not extended for a whole lease",
+			heartbeatErr: errors.NewDomainError(errors.ErrDB, ""),
+			during: func(t *testing.T, jobs *fakeJobStorage, banners *blockedBanners, cancel context.CancelFunc) {
+				waitClosed(t, banners.cancelled)
+			},
+			wantStatus: entity.DeleteJobRunning,
+			wantLease:  1,
+		},
+		{
+			name: "positive, shutdown leaves the job pending",
+			during: func(t *testing.T, jobs *fakeJobStorage, banners *blockedBanners, cancel context.CancelFunc) {
+				cancel()
+				waitClosed(t, banners.cancelled)
+			},
+			wantStatus: entity.DeleteJobPending,
+			wantLease:  1,
+			wantSaves:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := &fakeJobStorage{heartbeatErr: tt.heartbeatErr}
+			_, err := jobs.CreateDeleteJob(context.Background(), entity.DeleteBannersDTO{FeatureID: 1})
+			require.NoError(t, err)
+			banners := &blockedBanners{release: make(chan struct{}), cancelled: make(chan struct{})}
+			service := NewDeleteJobService(jobs, banners, &fakeCache{})
+			service.lease = lease
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				service.process(ctx, 1)
+			}()
+
+			require.Eventually(t, func() bool {
+				job, _, _ := jobs.state()
+				return job.Status == entity.DeleteJobRunning
+			}, time.Second, time.Millisecond)
+			tt.during(t, jobs, banners, cancel)
+			close(banners.release)
+			waitClosed(t, done)
+
+			job, _, saves := jobs.state()
+			require.Equal(t, tt.wantStatus, job.Status)
+			require.Equal(t, tt.wantDeleted, job.Deleted)
+			require.Equal(t, tt.wantLease, job.Lease)
+			require.Equal(t, tt.wantSaves, saves)
+		})
+	}
+}
+
+func waitClosed(t *testing.T, ch chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/usecase"
+)
+
+var _ usecase.FeatureService = new(featureService)
+
+type FeatureStorage interface {
+	CreateFeature(ctx context.Context, dto entity.CreateFeatureDTO) (entity.Feature, error)
+	GetFeatures(ctx context.Context, dto entity.GetFeaturesDTO) ([]entity.Feature, error)
+	UpdateFeature(ctx context.Context, dto entity.UpdateFeatureDTO) (entity.Feature, error)
+	DeleteFeature(ctx context.Context, dto entity.DeleteFeatureDTO) ([]int64, error)
+}
+
+type featureService struct {
+	storage FeatureStorage
+	cache   BannerCache
+}
+
+func NewFeatureService(storage FeatureStorage, cache BannerCache) *featureService {
+	return &featureService{
+		storage: storage,
+		cache:   cache,
+	}
+}
+
+func (service *featureService) CreateFeature(ctx context.Context, dto entity.CreateFeatureDTO) (entity.Feature, error) {
+	return service.storage.CreateFeature(ctx, dto)
+}
+
+func (service *featureService) GetFeatures(ctx context.Context, dto entity.GetFeaturesDTO) ([]entity.Feature, error) {
+	return service.storage.GetFeatures(ctx, dto)
+}
+
+func (service *featureService) UpdateFeature(ctx context.Context, dto entity.UpdateFeatureDTO) (entity.Feature, error) {
+	return service.storage.UpdateFeature(ctx, dto)
+}
+
+func (service *featureService) DeleteFeature(ctx context.Context, dto entity.DeleteFeatureDTO) error {
+	bannerIDs, err := service.storage.DeleteFeature(ctx, dto)
+	if err != nil {
+		return err
+	}
+
+	for _, bannerID := range bannerIDs {
+		invalidate(ctx, service.cache, bannerID, deletedVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFeatureStorage deletes features together with bannerIDs.
+type fakeFeatureStorage struct {
+	FeatureStorage
+
+	bannerIDs []int64
+}
+
+func (s *fakeFeatureStorage) DeleteFeature(ctx context.Context, dto entity.DeleteFeatureDTO) ([]int64, error) {
+	return s.bannerIDs, nil
+}
+
+func Test_featureService_DeleteFeature(t *testing.T) {
+	cache := &fakeCache{}
+	service := NewFeatureService(&fakeFeatureStorage{bannerIDs: []int64{1, 2}}, cache)
+
+	err := service.DeleteFeature(context.Background(), entity.DeleteFeatureDTO{FeatureID: 7, Cascade: true})
+	require.NoError(t, err)
+
+	require.Equal(t, map[int64]int64{1: deletedVersion, 2: deletedVersion}, cache.invalidated)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"log/slog"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/usecase"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/jwks"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var _ usecase.JWTService = new(jwtService)
+
+// jwtLeeway is the clock skew allowed when checking exp, nbf and iat.
+const jwtLeeway = 30 * time.Second
+
+// jwtClaims are the claims a bearer token is mapped to a principal from. If
+// role is not set, admin decides between the admin and the user role.
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Role     entity.Role `json:"role"`
+	Admin    bool        `json:"admin"`
+	Features []int64     `json:"features"`
+}
+
+type jwtService struct {
+	parser  *jwt.Parser
+	secrets jwt.VerificationKeySet
+	keys    []jwks.Key
+}
+
+// NewJWTService returns a service that accepts HS256 tokens signed with one
+// of the secrets and RS256, ES256, ES384 or ES512 tokens signed with one of
+// the keys. The issuer and audience are checked if they are not empty.
+func NewJWTService(secrets []string, keys []jwks.Key, issuer, audience string) *jwtService {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(jwtLeeway),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+
+	service := &jwtService{
+		parser: jwt.NewParser(opts...),
+		keys:   keys,
+	}
+	for _, secret := range secrets {
+		service.secrets.Keys = append(service.secrets.Keys, []byte(secret))
+	}
+
+	return service
+}
+
+func (service *jwtService) CheckJWT(ctx context.Context, token string) (entity.Principal, error) {
+	if len(service.secrets.Keys) == 0 && len(service.keys) == 0 {
+		return entity.Principal{}, errors.NewDomainError(errors.ErrUnauthorized, "bearer tokens are not accepted")
+	}
+
+	var claims jwtClaims
+	_, err := service.parser.ParseWithClaims(token, &claims, service.key)
+	if err != nil {
+		slog.Debug("invalid bearer token", "error", err)
+		return entity.Principal{}, errors.NewDomainError(errors.ErrUnauthorized, "")
+	}
+
+	role := claims.Role
+	if role == "" {
+		role = entity.RoleUser
+		if claims.Admin {
+			role = entity.RoleAdmin
+		}
+	}
+	if !role.Valid() {
+		slog.Debug("unknown role in bearer token", "role", role, "sub", claims.Subject)
+		return entity.Principal{}, errors.NewDomainError(errors.ErrUnauthorized, "")
+	}
+
+	return entity.Principal{
+		Subject:    claims.Subject,
+		Role:       role,
+		FeatureIDs: claims.Features,
+		ExpiresAt:  &claims.ExpiresAt.Time,
+	}, nil
+}
+
+// key returns the keys the token may be signed with. If the token names its
+// key with kid, only keys with that ID are tried.
+func (service *jwtService) key(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		return service.secrets, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	var set jwt.VerificationKeySet
+	for _, key := range service.keys {
+		if kid != "" && key.ID != kid {
+			continue
+		}
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+				continue
+			}
+		case *ecdsa.PublicKey:
+			// each ES method is bound to the curve of its size
+			method, ok := token.Method.(*jwt.SigningMethodECDSA)
+			if !ok || method.CurveBits != public.Curve.Params().BitSize {
+				continue
+			}
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, key.Public)
+	}
+
+	return set, nil
+}
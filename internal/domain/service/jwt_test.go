@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/jwks"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_jwtService_CheckJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	p521Key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	require.NoError(t, err)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	service := NewJWTService(
+		[]string{"secret"},
+		[]jwks.Key{
+			{ID: "rsa", Public: &rsaKey.PublicKey},
+			{ID: "ec", Public: &ecKey.PublicKey},
+			{ID: "p384", Public: &p384Key.PublicKey},
+			{ID: "p521", Public: &p521Key.PublicKey},
+		},
+		"issuer", "audience",
+	)
+
+	now := time.Now()
+	claims := func(modify func(c jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":      "subject",
+			"iss":      "issuer",
+			"aud":      "audience",
+			"iat":      now.Unix(),
+			"exp":      now.Add(time.Hour).Unix(),
+			"role":     "editor",
+			"features": []int64{1, 2},
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+	sign := func(method jwt.SigningMethod, kid string, c jwt.MapClaims, key any) string {
+		token := jwt.NewWithClaims(method, c)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	type want struct {
+		role       entity.Role
+		featureIDs []int64
+		err        bool
+	}
+	tests := []struct {
+		name  string
+		token string
+		want  want
+	}{
+		{
+			name:  "positive, HS256",
+			token: sign(jwt.SigningMethodHS256, "", claims(nil), []byte("secret")),
+			want: want{
+				role:       entity.RoleEditor,
+				featureIDs: []int64{1, 2},
+			},
+		},
+		{
+			name:  "positive, RS256 with kid",
+			token: sign(jwt.SigningMethodRS256, "rsa", claims(nil), rsaKey),
+			want: want{
+				role:       entity.RoleEditor,
+				featureIDs: []int64{1, 2},
+			},
+		},
+		{
+			name:  "positive, ES256 without kid",
+			token: sign(jwt.SigningMethodES256, "", claims(nil), ecKey),
+			want: want{
+				role:       entity.RoleEditor,
+				featureIDs: []int64{1, 2},
+			},
+		},
+		{
+			name:  "positive, ES384 with kid",
+			token: sign(jwt.SigningMethodES384, "p384", claims(nil), p384Key),
+			want: want{
+				role:       entity.RoleEditor,
+				featureIDs: []int64{1, 2},
+			},
+		},
+		{
+			name:  "positive, ES512 without kid",
+			token: sign(jwt.SigningMethodES512, "", claims(nil), p521Key),
+			want: want{
+				role:       entity.RoleEditor,
+				featureIDs: []int64{1, 2},
+			},
+		},
+		{
+			name: "positive, admin claim without role",
+			token: sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) {
+				delete(c, "role")
+				c["admin"] = true
+			}), []byte("secret")),
+			want: want{
+				role:       entity.RoleAdmin,
+				featureIDs: []int64{1, 2},
+			},
+		},
+		{
+			name: "positive, no role",
+			token: sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) {
+				delete(c, "role")
+				delete(c, "features")
+			}), []byte("secret")),
+			want: want{
+				role: entity.RoleUser,
+			},
+		},
+		{
+			name:  "negative, wrong secret",
+			token: sign(jwt.SigningMethodHS256, "", claims(nil), []byte("other secret")),
+			want:  want{err: true},
+		},
+		{
+			name:  "negative, HS256 signed with the RSA public key",
+			token: sign(jwt.SigningMethodHS256, "rsa", claims(nil), publicPEM),
+			want:  want{err: true},
+		},
+		{
+			name:  "negative, alg none",
+			token: sign(jwt.SigningMethodNone, "", claims(nil), jwt.UnsafeAllowNoneSignatureType),
+			want:  want{err: true},
+		},
+		{
+			name:  "negative, HS384 is not accepted",
+			token: sign(jwt.SigningMethodHS384, "", claims(nil), []byte("secret")),
+			want:  want{err: true},
+		},
+		{
+			name:  "negative, kid of another key",
+			token: sign(jwt.SigningMethodRS256, "ec", claims(nil), rsaKey),
+			want:  want{err: true},
+		},
+		{
+			name:  "negative, kid of a key on another curve",
+			token: sign(jwt.SigningMethodES512, "p384", claims(nil), p521Key),
+			want:  want{err: true},
+		},
+		{
+			name:  "negative, unknown kid",
+			token: sign(jwt.SigningMethodES256, "other", claims(nil), ecKey),
+			want:  want{err: true},
+		},
+		{
+			name: "negative, missing exp",
+			token: sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) {
+				delete(c, "exp")
+			}), []byte("secret")),
+			want: want{err: true},
+		},
+		{
+			name: "negative, expired",
+			token: sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) {
+				c["exp"] = now.Add(-time.Hour).Unix()
+			}), []byte("secret")),
+			want: want{err: true},
+		},
+		{
+			name: "positive, expired within the leeway",
+			token: sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) {
+				c["exp"] = now.Add(-jwtLeeway / 2).Unix()
+			}), []byte("secret")),
+			want: want{
+				role:       entity.RoleEditor,
+				featureIDs: []int64{1, 2},
+			},
+		},
+		{
+			name: "negative, issued in the future",
+			token: sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) {
+				c["iat"] = now.Add(time.Hour).Unix()
+			}), []byte("secret")),
+			want: want{err: true},
+		},
+		{
+			name: "negative, not valid yet",
+			token: sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) {
+				c["nbf"] = now.Add(time.Hour).Unix()
+			}), []byte("secret")),
+			want: want{err: true},
+		},
+		{
+			name: "negative, wrong issuer",
+			token: sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) {
+				c["iss"] = "other"
+			}), []byte("secret")),
+			want: want{err: true},
+		},
+		{
+			name: "negative, missing issuer",
+			token: sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) {
+				delete(c, "iss")
+			}), []byte("secret")),
+			want: want{err: true},
+		},
+		{
+			name: "negative, wrong audience",
+			token: sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) {
+				c["aud"] = []string{"other"}
+			}), []byte("secret")),
+			want: want{err: true},
+		},
+		{
+			name: "negative, unknown role",
+			token: sign(jwt.SigningMethodHS256, "", claims(func(c jwt.MapClaims) {
+				c["role"] = "root"
+			}), []byte("secret")),
+			want: want{err: true},
+		},
+		{
+			name:  "negative, malformed",
+			token: "not.a.token",
+			want:  want{err: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := service.CheckJWT(context.Background(), tt.token)
+			if tt.want.err {
+				require.Error(t, err)
+				require.Equal(t, errors.ErrUnauthorized, errors.Code(err))
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, "subject", principal.Subject)
+			require.Equal(t, tt.want.role, principal.Role)
+			require.Equal(t, tt.want.featureIDs, principal.FeatureIDs)
+			require.NotNil(t, principal.ExpiresAt)
+		})
+	}
+}
+
+func Test_jwtService_CheckJWT_Disabled(t *testing.T) {
+	service := NewJWTService(nil, nil, "", "")
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(""))
+	require.NoError(t, err)
+
+	_, err = service.CheckJWT(context.Background(), token)
+	require.Error(t, err)
+	require.Equal(t, errors.ErrUnauthorized, errors.Code(err))
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/usecase"
+)
+
+var _ usecase.StatsService = new(statsService)
+
+const statsFlushInterval = 10 * time.Second
+
+// maxPendingStats bounds the banner and day pairs counted in memory, which
+// keep piling up while the storage is unavailable. Beyond it new pairs are
+// not counted.
+const maxPendingStats = 100000
+
+// statsDropped counts the impressions and clicks that were not counted
+// because maxPendingStats was reached.
+var statsDropped = expvar.NewInt("banner_stats_dropped")
+
+type StatsStorage interface {
+	AddBannerStats(ctx context.Context, stats []entity.BannerStats) error
+	GetBannerStats(ctx context.Context, dto entity.GetBannerStatsDTO) ([]entity.BannerStats, error)
+}
+
+type statsKey struct {
+	bannerID int64
+	day      time.Time
+}
+
+type statsCounters struct {
+	impressions int64
+	clicks      int64
+}
+
+// statsService counts impressions and clicks in memory and adds them to the
+// storage in batches, so serving a banner does not cost a write.
+type statsService struct {
+	storage StatsStorage
+	banners BannerStorage
+
+	mu      sync.Mutex
+	pending map[statsKey]statsCounters
+}
+
+func NewStatsService(storage StatsStorage, banners BannerStorage) *statsService {
+	return &statsService{
+		storage: storage,
+		banners: banners,
+		pending: make(map[statsKey]statsCounters),
+	}
+}
+
+func (service *statsService) RecordImpression(bannerID int64) {
+	service.add(todayKey(bannerID), statsCounters{impressions: 1})
+}
+
+func (service *statsService) RecordClick(bannerID int64) {
+	service.add(todayKey(bannerID), statsCounters{clicks: 1})
+}
+
+func todayKey(bannerID int64) statsKey {
+	return statsKey{
+		bannerID: bannerID,
+		day:      time.Now().UTC().Truncate(24 * time.Hour),
+	}
+}
+
+func (service *statsService) add(key statsKey, c statsCounters) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	sum, ok := service.pending[key]
+	if !ok && len(service.pending) >= maxPendingStats {
+		statsDropped.Add(c.impressions + c.clicks)
+		return
+	}
+	sum.impressions += c.impressions
+	sum.clicks += c.clicks
+	service.pending[key] = sum
+}
+
+// GetBannerStats returns the stored counters. The ones that are not flushed
+// yet are not included.
+func (service *statsService) GetBannerStats(ctx context.Context, dto entity.GetBannerStatsDTO) ([]entity.BannerStats, error) {
+	if dto.Principal.Scoped() {
+		banner, err := service.banners.GetBanner(ctx, entity.GetBannerDTO{BannerID: dto.BannerID})
+		if err != nil {
+			return nil, err
+		}
+		if !dto.Principal.InScope(banner.FeatureID) {
+			return nil, errOutOfScope(banner.FeatureID)
+		}
+	}
+
+	return service.storage.GetBannerStats(ctx, dto)
+}
+
+// Run flushes the counters every statsFlushInterval until ctx is cancelled,
+// and once more before returning.
+func (service *statsService) Run(ctx context.Context) {
+	ticker := time.NewTicker(statsFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			ctxFlush, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			service.flush(ctxFlush)
+			cancel()
+			return
+		case <-ticker.C:
+			service.flush(ctx)
+		}
+	}
+}
+
+func (service *statsService) flush(ctx context.Context) {
+	service.mu.Lock()
+	pending := service.pending
+	service.pending = make(map[statsKey]statsCounters)
+	service.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	stats := make([]entity.BannerStats, 0, len(pending))
+	for key, c := range pending {
+		stats = append(stats, entity.BannerStats{
+			BannerID:    key.bannerID,
+			Day:         key.day,
+			Impressions: c.impressions,
+			Clicks:      c.clicks,
+		})
+	}
+
+	err := service.storage.AddBannerStats(ctx, stats)
+	if err != nil {
+		slog.Error("error flushing banner stats, keeping them for the next flush", "error", err)
+		// the counters recorded meanwhile take precedence if the pending
+		// ones no longer fit
+		for key, c := range pending {
+			service.add(key, c)
+		}
+	}
+}
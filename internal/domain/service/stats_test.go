@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStatsStorage sums the stats it is given by banner, unless err is set.
+type fakeStatsStorage struct {
+	StatsStorage
+
+	err     error
+	batches int
+	stored  map[int64]statsCounters
+}
+
+func (s *fakeStatsStorage) AddBannerStats(ctx context.Context, stats []entity.BannerStats) error {
+	s.batches++
+	if s.err != nil {
+		return s.err
+	}
+
+	if s.stored == nil {
+		s.stored = make(map[int64]statsCounters)
+	}
+	for _, st := range stats {
+		sum := s.stored[st.BannerID]
+		sum.impressions += st.Impressions
+		sum.clicks += st.Clicks
+		s.stored[st.BannerID] = sum
+	}
+	return nil
+}
+
+func (s *fakeStatsStorage) GetBannerStats(ctx context.Context, dto entity.GetBannerStatsDTO) ([]entity.BannerStats, error) {
+	return []entity.BannerStats{{BannerID: dto.BannerID, Day: dto.From}}, nil
+}
+
+func Test_statsService_GetBannerStats(t *testing.T) {
+	scoped := entity.Principal{TokenID: 1, Role: entity.RoleViewer, FeatureIDs: []int64{1}}
+
+	tests := []struct {
+		name      string
+		featureID int64
+		principal entity.Principal
+		wantErr   bool
+	}{
+		{
+			name:      "positive, banner in scope",
+			featureID: 1,
+			principal: scoped,
+		},
+		{
+			name:      "positive, unscoped",
+			featureID: 2,
+		},
+		{
+			name:      "negative, banner out of scope",
+			featureID: 2,
+			principal: scoped,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			banners := &fakeStorage{stored: entity.Banner{BannerID: 1, FeatureID: tt.featureID}}
+			service := NewStatsService(&fakeStatsStorage{}, banners)
+
+			stats, err := service.GetBannerStats(context.Background(), entity.GetBannerStatsDTO{BannerID: 1, Principal: tt.principal})
+			if tt.wantErr {
+				require.Equal(t, errors.ErrForbidden, errors.Code(err))
+				require.Empty(t, stats)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, stats, 1)
+		})
+	}
+}
+
+func Test_statsService_flush(t *testing.T) {
+	storage := &fakeStatsStorage{}
+	service := NewStatsService(storage, &fakeStorage{})
+
+	service.flush(context.Background())
+	require.Zero(t, storage.batches, "nothing to flush")
+
+	service.RecordImpression(1)
+	service.RecordImpression(1)
+	service.RecordClick(1)
+	service.RecordImpression(2)
+
+	storage.err = errors.NewDomainError(errors.ErrDB, "")
+	service.flush(context.Background())
+	require.Equal(t, 1, storage.batches)
+	require.Nil(t, storage.stored)
+
+	// the counters of the failed flush are added to the ones recorded since
+	service.RecordImpression(1)
+	storage.err = nil
+	service.flush(context.Background())
+	require.Equal(t, 2, storage.batches)
+	require.Equal(t, map[int64]statsCounters{
+		1: {impressions: 3, clicks: 1},
+		2: {impressions: 1},
+	}, storage.stored)
+
+	service.flush(context.Background())
+	require.Equal(t, 2, storage.batches, "flushed counters are not sent again")
+}
+
+func Test_statsService_add(t *testing.T) {
+	service := NewStatsService(&fakeStatsStorage{}, &fakeStorage{})
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+
+	for i := 0; i < maxPendingStats; i++ {
+		service.add(statsKey{bannerID: int64(i), day: day}, statsCounters{impressions: 1})
+	}
+	dropped := statsDropped.Value()
+
+	// pairs already counted still are, new ones are dropped
+	service.add(statsKey{bannerID: 0, day: day}, statsCounters{clicks: 1})
+	service.add(statsKey{bannerID: maxPendingStats, day: day}, statsCounters{impressions: 1, clicks: 1})
+
+	require.Len(t, service.pending, maxPendingStats)
+	require.Equal(t, statsCounters{impressions: 1, clicks: 1}, service.pending[statsKey{bannerID: 0, day: day}])
+	require.Equal(t, dropped+2, statsDropped.Value())
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/domain/usecase"
+)
+
+var _ usecase.TagService = new(tagService)
+
+type TagStorage interface {
+	CreateTag(ctx context.Context, dto entity.CreateTagDTO) (entity.Tag, error)
+	GetTags(ctx context.Context, dto entity.GetTagsDTO) ([]entity.Tag, error)
+	UpdateTag(ctx context.Context, dto entity.UpdateTagDTO) (entity.Tag, error)
+	DeleteTag(ctx context.Context, dto entity.DeleteTagDTO) ([]entity.Banner, []int64, error)
+}
+
+type tagService struct {
+	storage TagStorage
+	cache   BannerCache
+}
+
+func NewTagService(storage TagStorage, cache BannerCache) *tagService {
+	return &tagService{
+		storage: storage,
+		cache:   cache,
+	}
+}
+
+func (service *tagService) CreateTag(ctx context.Context, dto entity.CreateTagDTO) (entity.Tag, error) {
+	return service.storage.CreateTag(ctx, dto)
+}
+
+func (service *tagService) GetTags(ctx context.Context, dto entity.GetTagsDTO) ([]entity.Tag, error) {
+	return service.storage.GetTags(ctx, dto)
+}
+
+func (service *tagService) UpdateTag(ctx context.Context, dto entity.UpdateTagDTO) (entity.Tag, error) {
+	return service.storage.UpdateTag(ctx, dto)
+}
+
+func (service *tagService) DeleteTag(ctx context.Context, dto entity.DeleteTagDTO) error {
+	banners, deletedIDs, err := service.storage.DeleteTag(ctx, dto)
+	if err != nil {
+		return err
+	}
+
+	for _, banner := range banners {
+		invalidate(ctx, service.cache, banner.BannerID, banner.Version)
+	}
+	for _, bannerID := range deletedIDs {
+		invalidate(ctx, service.cache, bannerID, deletedVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTagStorage returns afters and deletedIDs from DeleteTag, or fails with
+// err.
+type fakeTagStorage struct {
+	TagStorage
+
+	afters     []entity.Banner
+	deletedIDs []int64
+	err        error
+}
+
+func (s *fakeTagStorage) DeleteTag(ctx context.Context, dto entity.DeleteTagDTO) ([]entity.Banner, []int64, error) {
+	return s.afters, s.deletedIDs, s.err
+}
+
+func Test_tagService_DeleteTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage *fakeTagStorage
+		want    map[int64]int64
+	}{
+		{
+			name: "positive, banners are invalidated at their new versions",
+			storage: &fakeTagStorage{afters: []entity.Banner{
+				{BannerID: 1, Version: 3},
+				{BannerID: 2, Version: 7},
+			}},
+			want: map[int64]int64{1: 3, 2: 7},
+		},
+		{
+			name: "positive, banners left without tags are invalidated for good",
+			storage: &fakeTagStorage{
+				afters:     []entity.Banner{{BannerID: 1, Version: 3}},
+				deletedIDs: []int64{2},
+			},
+			want: map[int64]int64{1: 3, 2: deletedVersion},
+		},
+		{
+			name:    "negative, nothing is invalidated if the tag is in use",
+			storage: &fakeTagStorage{err: errors.NewDomainError(errors.ErrInUse, "tag is used by 2 banners")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &fakeCache{}
+			service := NewTagService(tt.storage, cache)
+
+			err := service.DeleteTag(context.Background(), entity.DeleteTagDTO{TagID: 1, Cascade: true})
+			require.Equal(t, errors.Code(tt.storage.err), errors.Code(err))
+
+			require.Equal(t, tt.want, cache.invalidated)
+		})
+	}
+}
@@ -1,19 +1,181 @@
-package service
-
-import "context"
-
-type TokenStorage interface {
-	CheckToken(ctx context.Context, token string) (bool, error)
-}
-
-type tokenService struct {
-	storage TokenStorage
-}
-
-func NewTokenService(storage TokenStorage) *tokenService {
-	return &tokenService{storage: storage}
-}
-
-func (service *tokenService) CheckToken(ctx context.Context, token string) (bool, error) {
-	return service.storage.CheckToken(ctx, token)
-}
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/The-Gleb/banner_service/pkg/lru"
+)
+
+// tokenSecretSize is the number of random bytes in an issued token.
+const tokenSecretSize = 32
+
+type TokenStorage interface {
+	CheckToken(ctx context.Context, token string) (entity.Principal, error)
+	CreateToken(ctx context.Context, dto entity.IssueTokenDTO) (entity.Token, error)
+	EnsureToken(ctx context.Context, dto entity.IssueTokenDTO) error
+	GetTokens(ctx context.Context, dto entity.GetTokensDTO) ([]entity.Token, error)
+	UpdateToken(ctx context.Context, dto entity.UpdateTokenDTO) (entity.Token, error)
+	RevokeToken(ctx context.Context, dto entity.RevokeTokenDTO) (entity.Token, error)
+}
+
+// TokenInvalidations tells every replica which cached token checks are out
+// of date.
+type TokenInvalidations interface {
+	Publish(ctx context.Context, inv entity.TokenInvalidation) error
+	// Subscribe passes the invalidations published by any replica, this one
+	// included, to fn until ctx is done. reset is called whenever
+	// invalidations may have been missed.
+	Subscribe(ctx context.Context, fn func(inv entity.TokenInvalidation), reset func())
+}
+
+// tokenCheck is a cached result of TokenStorage.CheckToken.
+type tokenCheck struct {
+	principal entity.Principal
+	err       error
+}
+
+type tokenService struct {
+	storage       TokenStorage
+	invalidations TokenInvalidations
+	// checks are keyed by the SHA-256 of the token, so that the secrets are
+	// not kept in memory
+	checks *lru.Cache[[sha256.Size]byte, tokenCheck]
+	// generation is bumped on every invalidation, so that a check that read
+	// the storage before the invalidation is not cached after it
+	generation atomic.Uint64
+}
+
+// NewTokenService returns a service that caches up to cacheSize token checks,
+// unknown tokens included, for cacheTTL. Tokens updated or revoked through
+// any replica are dropped from the cache at once, as long as Run is running
+// and the invalidations reach it; if some are missed, changes are seen after
+// cacheTTL at most.
+//
+// The last use of a token is recorded by the storage, so it is only updated
+// when a check misses the cache and lags behind by up to cacheTTL.
+func NewTokenService(storage TokenStorage, invalidations TokenInvalidations, cacheSize int, cacheTTL time.Duration) *tokenService {
+	return &tokenService{
+		storage:       storage,
+		invalidations: invalidations,
+		checks:        lru.New[[sha256.Size]byte, tokenCheck](cacheSize, cacheTTL),
+	}
+}
+
+// Run applies the invalidations published by the replicas until ctx is
+// done. The cache is purged whenever some may have been missed.
+func (service *tokenService) Run(ctx context.Context) {
+	service.invalidations.Subscribe(ctx, service.apply, service.purge)
+}
+
+func (service *tokenService) CheckToken(ctx context.Context, token string) (entity.Principal, error) {
+	key := sha256.Sum256([]byte(token))
+	if check, ok := service.checks.Get(key); ok {
+		return check.principal, check.err
+	}
+
+	generation := service.generation.Load()
+	principal, err := service.storage.CheckToken(ctx, token)
+
+	switch {
+	case service.generation.Load() != generation:
+	case err == nil && principal.ExpiresAt != nil:
+		service.checks.SetWithTTL(key, tokenCheck{principal: principal}, time.Until(*principal.ExpiresAt))
+	case err == nil:
+		service.checks.Set(key, tokenCheck{principal: principal})
+	case errors.Code(err) == errors.ErrUnauthorized:
+		service.checks.Set(key, tokenCheck{err: err})
+	}
+
+	return principal, err
+}
+
+// IssueToken creates a token with a random secret. The secret is returned
+// only here and cannot be read back later.
+func (service *tokenService) IssueToken(ctx context.Context, dto entity.IssueTokenDTO) (entity.Token, error) {
+	secret := make([]byte, tokenSecretSize)
+	_, err := rand.Read(secret)
+	if err != nil {
+		slog.Error("error generating token", "error", err)
+		return entity.Token{}, errors.NewDomainError(errors.ErrDB, "")
+	}
+	dto.Token = base64.RawURLEncoding.EncodeToString(secret)
+
+	token, err := service.storage.CreateToken(ctx, dto)
+	if err != nil {
+		return entity.Token{}, err
+	}
+	token.Token = dto.Token
+
+	return token, nil
+}
+
+// EnsureAdminToken makes the given secret a valid admin token, so that the
+// first tokens can be issued through the API.
+func (service *tokenService) EnsureAdminToken(ctx context.Context, token string) error {
+	return service.storage.EnsureToken(ctx, entity.IssueTokenDTO{
+		Label: "bootstrap",
+		Role:  entity.RoleAdmin,
+		Token: token,
+	})
+}
+
+func (service *tokenService) GetTokens(ctx context.Context, dto entity.GetTokensDTO) ([]entity.Token, error) {
+	return service.storage.GetTokens(ctx, dto)
+}
+
+func (service *tokenService) UpdateToken(ctx context.Context, dto entity.UpdateTokenDTO) (entity.Token, error) {
+	token, err := service.storage.UpdateToken(ctx, dto)
+	if err != nil {
+		return entity.Token{}, err
+	}
+	service.invalidate(ctx, entity.TokenInvalidation{TokenID: token.ID})
+
+	return token, nil
+}
+
+func (service *tokenService) RevokeToken(ctx context.Context, dto entity.RevokeTokenDTO) (entity.Token, error) {
+	token, err := service.storage.RevokeToken(ctx, dto)
+	if err != nil {
+		return entity.Token{}, err
+	}
+	service.invalidate(ctx, entity.TokenInvalidation{TokenID: token.ID})
+
+	return token, nil
+}
+
+// invalidate drops the cached checks here and tells the other replicas to
+// drop them too. The change is already stored, so a failure to tell them is
+// only logged; they see it after the cache TTL.
+func (service *tokenService) invalidate(ctx context.Context, inv entity.TokenInvalidation) {
+	service.apply(inv)
+
+	err := service.invalidations.Publish(ctx, inv)
+	if err != nil {
+		cacheError("error publishing token invalidation", err, "token_id", inv.TokenID)
+	}
+}
+
+// apply drops the cached checks of the invalidated principals. Rejections
+// are dropped too, as an expired token may have been given a new expiry.
+func (service *tokenService) apply(inv entity.TokenInvalidation) {
+	service.generation.Add(1)
+	service.checks.RemoveFunc(func(_ [sha256.Size]byte, check tokenCheck) bool {
+		if check.err != nil {
+			return true
+		}
+		return check.principal.TokenID == inv.TokenID
+	})
+}
+
+func (service *tokenService) purge() {
+	service.generation.Add(1)
+	service.checks.Purge()
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenStorage accepts the tokens in principals and counts the checks.
+// onCheck runs before a check returns.
+type fakeTokenStorage struct {
+	TokenStorage
+
+	principals map[string]entity.Principal
+	checks     int
+	onCheck    func()
+}
+
+func (s *fakeTokenStorage) CheckToken(ctx context.Context, token string) (entity.Principal, error) {
+	s.checks++
+	principal, ok := s.principals[token]
+	if s.onCheck != nil {
+		s.onCheck()
+	}
+	if !ok {
+		return entity.Principal{}, errors.NewDomainError(errors.ErrUnauthorized, "")
+	}
+	return principal, nil
+}
+
+func (s *fakeTokenStorage) UpdateToken(ctx context.Context, dto entity.UpdateTokenDTO) (entity.Token, error) {
+	return entity.Token{ID: dto.TokenID}, nil
+}
+
+// RevokeToken stops accepting the tokens of the principal.
+func (s *fakeTokenStorage) RevokeToken(ctx context.Context, dto entity.RevokeTokenDTO) (entity.Token, error) {
+	for token, principal := range s.principals {
+		if principal.TokenID == dto.TokenID {
+			delete(s.principals, token)
+		}
+	}
+	return entity.Token{ID: dto.TokenID}, nil
+}
+
+// fakeInvalidations records the published invalidations. Subscribe passes on
+// the ones sent to received and calls reset for every value sent to resets.
+type fakeInvalidations struct {
+	mu        sync.Mutex
+	published []entity.TokenInvalidation
+	received  chan entity.TokenInvalidation
+	resets    chan struct{}
+}
+
+func (i *fakeInvalidations) Publish(ctx context.Context, inv entity.TokenInvalidation) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.published = append(i.published, inv)
+	return nil
+}
+
+func (i *fakeInvalidations) Subscribe(ctx context.Context, fn func(inv entity.TokenInvalidation), reset func()) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case inv := <-i.received:
+			fn(inv)
+		case <-i.resets:
+			reset()
+		}
+	}
+}
+
+func Test_tokenService_CheckToken_Unknown(t *testing.T) {
+	storage := &fakeTokenStorage{principals: map[string]entity.Principal{}}
+	service := NewTokenService(storage, &fakeInvalidations{}, 10, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		_, err := service.CheckToken(context.Background(), "unknown")
+		require.Equal(t, errors.ErrUnauthorized, errors.Code(err))
+	}
+	require.Equal(t, 1, storage.checks)
+
+	// a rejection may be out of date after any change, such as a new expiry
+	_, err := service.UpdateToken(context.Background(), entity.UpdateTokenDTO{TokenID: 7})
+	require.NoError(t, err)
+	_, err = service.CheckToken(context.Background(), "unknown")
+	require.Equal(t, errors.ErrUnauthorized, errors.Code(err))
+	require.Equal(t, 2, storage.checks)
+}
+
+func Test_tokenService_invalidate(t *testing.T) {
+	tests := []struct {
+		name string
+		// change updates or revokes token 1 through the service
+		change func(service *tokenService) error
+		// wantErr is the code the next check of token 1 fails with
+		wantErr errors.ErrorCode
+	}{
+		{
+			name: "revoke",
+			change: func(service *tokenService) error {
+				_, err := service.RevokeToken(context.Background(), entity.RevokeTokenDTO{TokenID: 1})
+				return err
+			},
+			wantErr: errors.ErrUnauthorized,
+		},
+		{
+			name: "update",
+			change: func(service *tokenService) error {
+				label := "renamed"
+				_, err := service.UpdateToken(context.Background(), entity.UpdateTokenDTO{TokenID: 1, Label: &label})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &fakeTokenStorage{principals: map[string]entity.Principal{
+				"one": {TokenID: 1, Role: entity.RoleAdmin},
+				"two": {TokenID: 2, Role: entity.RoleAdmin},
+			}}
+			invalidations := &fakeInvalidations{}
+			service := NewTokenService(storage, invalidations, 10, time.Minute)
+
+			for _, token := range []string{"one", "two"} {
+				_, err := service.CheckToken(context.Background(), token)
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, tt.change(service))
+			require.Equal(t, []entity.TokenInvalidation{{TokenID: 1}}, invalidations.published)
+
+			_, err := service.CheckToken(context.Background(), "one")
+			require.Equal(t, tt.wantErr, errors.Code(err))
+			_, err = service.CheckToken(context.Background(), "two")
+			require.NoError(t, err)
+			// only the changed token is read again
+			require.Equal(t, 3, storage.checks)
+		})
+	}
+}
+
+func Test_tokenService_CheckToken_Generation(t *testing.T) {
+	storage := &fakeTokenStorage{principals: map[string]entity.Principal{
+		"one": {TokenID: 1, Role: entity.RoleAdmin},
+	}}
+	service := NewTokenService(storage, &fakeInvalidations{}, 10, time.Minute)
+	// the token is revoked on another replica while the check reads the
+	// storage
+	storage.onCheck = func() {
+		storage.onCheck = nil
+		service.apply(entity.TokenInvalidation{TokenID: 1})
+	}
+
+	_, err := service.CheckToken(context.Background(), "one")
+	require.NoError(t, err)
+	_, err = service.CheckToken(context.Background(), "one")
+	require.NoError(t, err)
+	// the check that raced with the invalidation was not cached
+	require.Equal(t, 2, storage.checks)
+}
+
+func Test_tokenService_Run(t *testing.T) {
+	storage := &fakeTokenStorage{principals: map[string]entity.Principal{
+		"one": {TokenID: 1, Role: entity.RoleAdmin},
+		"two": {TokenID: 2, Role: entity.RoleAdmin},
+	}}
+	invalidations := &fakeInvalidations{
+		received: make(chan entity.TokenInvalidation),
+		resets:   make(chan struct{}),
+	}
+	service := NewTokenService(storage, invalidations, 10, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		service.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	checkAll := func() {
+		for _, token := range []string{"one", "two"} {
+			_, err := service.CheckToken(context.Background(), token)
+			require.NoError(t, err)
+		}
+	}
+
+	checkAll()
+	require.Equal(t, 2, storage.checks)
+
+	// an invalidation published by another replica drops the token here;
+	// the empty one matches nothing and only returns once the first one has
+	// been applied
+	invalidations.received <- entity.TokenInvalidation{TokenID: 1}
+	invalidations.received <- entity.TokenInvalidation{}
+	checkAll()
+	require.Equal(t, 3, storage.checks)
+
+	// invalidations may have been missed, so every token is read again
+	invalidations.resets <- struct{}{}
+	invalidations.received <- entity.TokenInvalidation{}
+	checkAll()
+	require.Equal(t, 5, storage.checks)
+}
@@ -1,19 +1,36 @@
 package usecase
 
-import "context"
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
 
 type TokenService interface {
-	CheckToken(ctx context.Context, token string) (bool, error)
+	CheckToken(ctx context.Context, token string) (entity.Principal, error)
+	IssueToken(ctx context.Context, dto entity.IssueTokenDTO) (entity.Token, error)
+	GetTokens(ctx context.Context, dto entity.GetTokensDTO) ([]entity.Token, error)
+	UpdateToken(ctx context.Context, dto entity.UpdateTokenDTO) (entity.Token, error)
+	RevokeToken(ctx context.Context, dto entity.RevokeTokenDTO) (entity.Token, error)
+}
+
+type JWTService interface {
+	CheckJWT(ctx context.Context, token string) (entity.Principal, error)
 }
 
 type checkTokenUsecase struct {
 	tokenService TokenService
+	jwtService   JWTService
 }
 
-func NewCheckTokenUsecase(tokenService TokenService) *checkTokenUsecase {
-	return &checkTokenUsecase{tokenService}
+func NewCheckTokenUsecase(tokenService TokenService, jwtService JWTService) *checkTokenUsecase {
+	return &checkTokenUsecase{tokenService, jwtService}
 }
 
-func (u *checkTokenUsecase) CheckToken(ctx context.Context, token string) (bool, error) {
+func (u *checkTokenUsecase) CheckToken(ctx context.Context, token string) (entity.Principal, error) {
 	return u.tokenService.CheckToken(ctx, token)
 }
+
+func (u *checkTokenUsecase) CheckJWT(ctx context.Context, token string) (entity.Principal, error) {
+	return u.jwtService.CheckJWT(ctx, token)
+}
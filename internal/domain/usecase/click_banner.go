@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+	"github.com/The-Gleb/banner_service/internal/errors"
+)
+
+type clickBannerUsecase struct {
+	bannerService BannerService
+	statsService  StatsService
+}
+
+func NewClickBannerUsecase(bannerService BannerService, statsService StatsService) *clickBannerUsecase {
+	return &clickBannerUsecase{bannerService, statsService}
+}
+
+// ClickBanner records a click on the banner and returns the URL to send the
+// user to. Banners that are not shown to users can only be clicked by admins.
+func (u *clickBannerUsecase) ClickBanner(ctx context.Context, dto entity.ClickBannerDTO) (string, error) {
+	banner, err := u.bannerService.GetBannerLink(ctx, dto.BannerID)
+	if err != nil {
+		return "", err
+	}
+
+	if (!banner.IsActive || !banner.Schedule.Contains(time.Now())) && !dto.IsAdmin {
+		return "", errors.NewDomainError(errors.ErrForbidden, "")
+	}
+
+	u.statsService.RecordClick(banner.BannerID)
+
+	return banner.Content.URL, nil
+}
@@ -9,9 +9,15 @@ import (
 type BannerService interface {
 	CreateBanner(ctx context.Context, dto entity.CreateBannerDTO) (int64, error)
 	DeleteBanner(ctx context.Context, dto entity.DeleteBannerDTO) error
-	GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.BannerContent, error)
+	GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UserBanner, error)
 	GetBanners(ctx context.Context, dto entity.GetBannersDTO) ([]entity.Banner, error)
-	UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) error
+	UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) (int64, error)
+	GetBanner(ctx context.Context, dto entity.GetBannerDTO) (entity.Banner, error)
+	GetBannerLink(ctx context.Context, bannerID int64) (entity.Banner, error)
+	GetBannerVersions(ctx context.Context, dto entity.GetBannerVersionsDTO) ([]entity.BannerVersion, error)
+	RestoreBanner(ctx context.Context, dto entity.RestoreBannerDTO) (int64, error)
+	ImportBanners(ctx context.Context, dto entity.ImportBannersDTO) (entity.ImportResult, error)
+	ExportBanners(ctx context.Context, dto entity.ExportBannersDTO, fn func(entity.Banner) error) error
 }
 
 type createBannerUsecase struct {
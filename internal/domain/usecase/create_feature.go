@@ -0,0 +1,26 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type FeatureService interface {
+	CreateFeature(ctx context.Context, dto entity.CreateFeatureDTO) (entity.Feature, error)
+	GetFeatures(ctx context.Context, dto entity.GetFeaturesDTO) ([]entity.Feature, error)
+	UpdateFeature(ctx context.Context, dto entity.UpdateFeatureDTO) (entity.Feature, error)
+	DeleteFeature(ctx context.Context, dto entity.DeleteFeatureDTO) error
+}
+
+type createFeatureUsecase struct {
+	featureService FeatureService
+}
+
+func NewCreateFeatureUsecase(featureService FeatureService) *createFeatureUsecase {
+	return &createFeatureUsecase{featureService}
+}
+
+func (u *createFeatureUsecase) CreateFeature(ctx context.Context, dto entity.CreateFeatureDTO) (entity.Feature, error) {
+	return u.featureService.CreateFeature(ctx, dto)
+}
@@ -0,0 +1,26 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type TagService interface {
+	CreateTag(ctx context.Context, dto entity.CreateTagDTO) (entity.Tag, error)
+	GetTags(ctx context.Context, dto entity.GetTagsDTO) ([]entity.Tag, error)
+	UpdateTag(ctx context.Context, dto entity.UpdateTagDTO) (entity.Tag, error)
+	DeleteTag(ctx context.Context, dto entity.DeleteTagDTO) error
+}
+
+type createTagUsecase struct {
+	tagService TagService
+}
+
+func NewCreateTagUsecase(tagService TagService) *createTagUsecase {
+	return &createTagUsecase{tagService}
+}
+
+func (u *createTagUsecase) CreateTag(ctx context.Context, dto entity.CreateTagDTO) (entity.Tag, error) {
+	return u.tagService.CreateTag(ctx, dto)
+}
@@ -0,0 +1,24 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type DeleteJobService interface {
+	CreateDeleteJob(ctx context.Context, dto entity.DeleteBannersDTO) (entity.DeleteJob, error)
+	GetDeleteJob(ctx context.Context, dto entity.GetDeleteJobDTO) (entity.DeleteJob, error)
+}
+
+type deleteBannersUsecase struct {
+	deleteJobService DeleteJobService
+}
+
+func NewDeleteBannersUsecase(deleteJobService DeleteJobService) *deleteBannersUsecase {
+	return &deleteBannersUsecase{deleteJobService}
+}
+
+func (u *deleteBannersUsecase) DeleteBanners(ctx context.Context, dto entity.DeleteBannersDTO) (entity.DeleteJob, error) {
+	return u.deleteJobService.CreateDeleteJob(ctx, dto)
+}
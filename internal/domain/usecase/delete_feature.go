@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type deleteFeatureUsecase struct {
+	featureService FeatureService
+}
+
+func NewDeleteFeatureUsecase(featureService FeatureService) *deleteFeatureUsecase {
+	return &deleteFeatureUsecase{featureService}
+}
+
+func (u *deleteFeatureUsecase) DeleteFeature(ctx context.Context, dto entity.DeleteFeatureDTO) error {
+	return u.featureService.DeleteFeature(ctx, dto)
+}
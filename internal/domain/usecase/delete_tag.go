@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type deleteTagUsecase struct {
+	tagService TagService
+}
+
+func NewDeleteTagUsecase(tagService TagService) *deleteTagUsecase {
+	return &deleteTagUsecase{tagService}
+}
+
+func (u *deleteTagUsecase) DeleteTag(ctx context.Context, dto entity.DeleteTagDTO) error {
+	return u.tagService.DeleteTag(ctx, dto)
+}
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type exportBannersUsecase struct {
+	bannerService BannerService
+}
+
+func NewExportBannersUsecase(bannerService BannerService) *exportBannersUsecase {
+	return &exportBannersUsecase{bannerService}
+}
+
+func (u *exportBannersUsecase) ExportBanners(ctx context.Context, dto entity.ExportBannersDTO, fn func(entity.Banner) error) error {
+	return u.bannerService.ExportBanners(ctx, dto, fn)
+}
@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type AuditService interface {
+	GetAuditRecords(ctx context.Context, dto entity.GetAuditRecordsDTO) ([]entity.AuditRecord, error)
+}
+
+type getAuditRecordsUsecase struct {
+	auditService AuditService
+}
+
+func NewGetAuditRecordsUsecase(auditService AuditService) *getAuditRecordsUsecase {
+	return &getAuditRecordsUsecase{auditService}
+}
+
+func (u *getAuditRecordsUsecase) GetAuditRecords(ctx context.Context, dto entity.GetAuditRecordsDTO) ([]entity.AuditRecord, error) {
+	return u.auditService.GetAuditRecords(ctx, dto)
+}
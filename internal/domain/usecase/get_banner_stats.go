@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type StatsService interface {
+	RecordImpression(bannerID int64)
+	RecordClick(bannerID int64)
+	GetBannerStats(ctx context.Context, dto entity.GetBannerStatsDTO) ([]entity.BannerStats, error)
+}
+
+type getBannerStatsUsecase struct {
+	statsService StatsService
+}
+
+func NewGetBannerStatsUsecase(statsService StatsService) *getBannerStatsUsecase {
+	return &getBannerStatsUsecase{statsService}
+}
+
+func (u *getBannerStatsUsecase) GetBannerStats(ctx context.Context, dto entity.GetBannerStatsDTO) ([]entity.BannerStats, error) {
+	return u.statsService.GetBannerStats(ctx, dto)
+}
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type getBannerVersionsUsecase struct {
+	bannerService BannerService
+}
+
+func NewGetBannerVersionsUsecase(bannerService BannerService) *getBannerVersionsUsecase {
+	return &getBannerVersionsUsecase{bannerService}
+}
+
+func (u *getBannerVersionsUsecase) GetBannerVersions(ctx context.Context, dto entity.GetBannerVersionsDTO) ([]entity.BannerVersion, error) {
+	return u.bannerService.GetBannerVersions(ctx, dto)
+}
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type getDeleteJobUsecase struct {
+	deleteJobService DeleteJobService
+}
+
+func NewGetDeleteJobUsecase(deleteJobService DeleteJobService) *getDeleteJobUsecase {
+	return &getDeleteJobUsecase{deleteJobService}
+}
+
+func (u *getDeleteJobUsecase) GetDeleteJob(ctx context.Context, dto entity.GetDeleteJobDTO) (entity.DeleteJob, error) {
+	return u.deleteJobService.GetDeleteJob(ctx, dto)
+}
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type getFeaturesUsecase struct {
+	featureService FeatureService
+}
+
+func NewGetFeaturesUsecase(featureService FeatureService) *getFeaturesUsecase {
+	return &getFeaturesUsecase{featureService}
+}
+
+func (u *getFeaturesUsecase) GetFeatures(ctx context.Context, dto entity.GetFeaturesDTO) ([]entity.Feature, error) {
+	return u.featureService.GetFeatures(ctx, dto)
+}
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type getTagsUsecase struct {
+	tagService TagService
+}
+
+func NewGetTagsUsecase(tagService TagService) *getTagsUsecase {
+	return &getTagsUsecase{tagService}
+}
+
+func (u *getTagsUsecase) GetTags(ctx context.Context, dto entity.GetTagsDTO) ([]entity.Tag, error) {
+	return u.tagService.GetTags(ctx, dto)
+}
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type getTokensUsecase struct {
+	tokenService TokenService
+}
+
+func NewGetTokensUsecase(tokenService TokenService) *getTokensUsecase {
+	return &getTokensUsecase{tokenService}
+}
+
+func (u *getTokensUsecase) GetTokens(ctx context.Context, dto entity.GetTokensDTO) ([]entity.Token, error) {
+	return u.tokenService.GetTokens(ctx, dto)
+}
@@ -8,12 +8,20 @@ import (
 
 type getUserBannerUsecase struct {
 	bannerService BannerService
+	statsService  StatsService
 }
 
-func NewGetUserBannerUsecase(bannerService BannerService) *getUserBannerUsecase {
-	return &getUserBannerUsecase{bannerService}
+func NewGetUserBannerUsecase(bannerService BannerService, statsService StatsService) *getUserBannerUsecase {
+	return &getUserBannerUsecase{bannerService, statsService}
 }
 
-func (u *getUserBannerUsecase) GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.BannerContent, error) {
-	return u.bannerService.GetUserBanner(ctx, dto)
+func (u *getUserBannerUsecase) GetUserBanner(ctx context.Context, dto entity.GetUserBannerDTO) (entity.UserBanner, error) {
+	banner, err := u.bannerService.GetUserBanner(ctx, dto)
+	if err != nil {
+		return entity.UserBanner{}, err
+	}
+
+	u.statsService.RecordImpression(banner.BannerID)
+
+	return banner, nil
 }
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type importBannersUsecase struct {
+	bannerService BannerService
+}
+
+func NewImportBannersUsecase(bannerService BannerService) *importBannersUsecase {
+	return &importBannersUsecase{bannerService}
+}
+
+func (u *importBannersUsecase) ImportBanners(ctx context.Context, dto entity.ImportBannersDTO) (entity.ImportResult, error) {
+	return u.bannerService.ImportBanners(ctx, dto)
+}
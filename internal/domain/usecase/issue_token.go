@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type issueTokenUsecase struct {
+	tokenService TokenService
+}
+
+func NewIssueTokenUsecase(tokenService TokenService) *issueTokenUsecase {
+	return &issueTokenUsecase{tokenService}
+}
+
+func (u *issueTokenUsecase) IssueToken(ctx context.Context, dto entity.IssueTokenDTO) (entity.Token, error) {
+	return u.tokenService.IssueToken(ctx, dto)
+}
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type restoreBannerUsecase struct {
+	bannerService BannerService
+}
+
+func NewRestoreBannerUsecase(bannerService BannerService) *restoreBannerUsecase {
+	return &restoreBannerUsecase{bannerService}
+}
+
+func (u *restoreBannerUsecase) RestoreBanner(ctx context.Context, dto entity.RestoreBannerDTO) (int64, error) {
+	return u.bannerService.RestoreBanner(ctx, dto)
+}
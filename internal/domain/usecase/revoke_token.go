@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type revokeTokenUsecase struct {
+	tokenService TokenService
+}
+
+func NewRevokeTokenUsecase(tokenService TokenService) *revokeTokenUsecase {
+	return &revokeTokenUsecase{tokenService}
+}
+
+func (u *revokeTokenUsecase) RevokeToken(ctx context.Context, dto entity.RevokeTokenDTO) (entity.Token, error) {
+	return u.tokenService.RevokeToken(ctx, dto)
+}
@@ -14,6 +14,6 @@ func NewUpdateBannerUsecase(bannerService BannerService) *updateBannerUsecase {
 	return &updateBannerUsecase{bannerService}
 }
 
-func (u *updateBannerUsecase) UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) error {
+func (u *updateBannerUsecase) UpdateBanner(ctx context.Context, dto entity.UpdateBannerDTO) (int64, error) {
 	return u.bannerService.UpdateBanner(ctx, dto)
 }
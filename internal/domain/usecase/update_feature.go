@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type updateFeatureUsecase struct {
+	featureService FeatureService
+}
+
+func NewUpdateFeatureUsecase(featureService FeatureService) *updateFeatureUsecase {
+	return &updateFeatureUsecase{featureService}
+}
+
+func (u *updateFeatureUsecase) UpdateFeature(ctx context.Context, dto entity.UpdateFeatureDTO) (entity.Feature, error) {
+	return u.featureService.UpdateFeature(ctx, dto)
+}
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type updateTagUsecase struct {
+	tagService TagService
+}
+
+func NewUpdateTagUsecase(tagService TagService) *updateTagUsecase {
+	return &updateTagUsecase{tagService}
+}
+
+func (u *updateTagUsecase) UpdateTag(ctx context.Context, dto entity.UpdateTagDTO) (entity.Tag, error) {
+	return u.tagService.UpdateTag(ctx, dto)
+}
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/The-Gleb/banner_service/internal/domain/entity"
+)
+
+type updateTokenUsecase struct {
+	tokenService TokenService
+}
+
+func NewUpdateTokenUsecase(tokenService TokenService) *updateTokenUsecase {
+	return &updateTokenUsecase{tokenService}
+}
+
+func (u *updateTokenUsecase) UpdateToken(ctx context.Context, dto entity.UpdateTokenDTO) (entity.Token, error) {
+	return u.tokenService.UpdateToken(ctx, dto)
+}
@@ -13,12 +13,18 @@ const (
 	ErrAlreadyExists   ErrorCode = "already exists"
 	ErrTagNotFound     ErrorCode = "tag not found"
 	ErrFeatureNotFound ErrorCode = "feature not found"
+	ErrInUse           ErrorCode = "still in use"
+
+	ErrInvalidInput ErrorCode = "invalid input"
 
 	ErrUnauthorized ErrorCode = "Unauthorized"
 
 	ErrForbidden ErrorCode = "access is forbidden"
 
-	ErrNotCached ErrorCode = "banner not found in cache"
+	ErrPreconditionFailed ErrorCode = "banner was modified by someone else"
+
+	ErrNotCached        ErrorCode = "banner not found in cache"
+	ErrCacheUnavailable ErrorCode = "cache is unavailable"
 )
 
 type domainError struct {
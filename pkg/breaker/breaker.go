@@ -0,0 +1,82 @@
+// Package breaker implements a circuit breaker that stops the calls to a
+// failing dependency for a cool-down period.
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// Breaker is safe for concurrent use.
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	coolDown  time.Duration
+	failures  int // consecutive
+	openUntil time.Time
+	probing   bool
+}
+
+// New returns a breaker that opens after threshold consecutive failures and
+// stays open for coolDown.
+func New(threshold int, coolDown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Breaker{
+		threshold: threshold,
+		coolDown:  coolDown,
+	}
+}
+
+// Allow reports whether a call may be made. Once the cool-down is over, a
+// single call is let through to probe the dependency, the others are still
+// rejected until its outcome is recorded.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+
+	b.probing = true
+	return true
+}
+
+// Success records an allowed call that succeeded and closes the breaker.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+// Cancel records an allowed call whose outcome is unknown, for example
+// because the caller gave up. If it was the probe, the next call probes.
+func (b *Breaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// Failure records an allowed call that failed. It reports whether the
+// breaker has opened, or opened again after a failed probe.
+func (b *Breaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failures < b.threshold {
+		return false
+	}
+
+	b.openUntil = time.Now().Add(b.coolDown)
+	b.probing = false
+	return true
+}
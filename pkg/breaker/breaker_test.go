@@ -0,0 +1,160 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const coolDown = 30 * time.Millisecond
+
+// step is a call on the breaker and the result it should give. Success and
+// Cancel give no result, wait sleeps through the cool-down.
+type step struct {
+	op   string
+	want bool
+}
+
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		steps     []step
+	}{
+		{
+			name:      "closed while failures stay below the threshold",
+			threshold: 3,
+			steps: []step{
+				{"failure", false},
+				{"failure", false},
+				{"allow", true},
+				{"success", false},
+				{"failure", false},
+				{"failure", false},
+				{"allow", true},
+			},
+		},
+		{
+			name:      "opens at the threshold",
+			threshold: 2,
+			steps: []step{
+				{"failure", false},
+				{"failure", true},
+				{"allow", false},
+				{"allow", false},
+			},
+		},
+		{
+			name:      "threshold below one opens at the first failure",
+			threshold: 0,
+			steps: []step{
+				{"failure", true},
+				{"allow", false},
+			},
+		},
+		{
+			name:      "lets a single probe through after the cool-down",
+			threshold: 1,
+			steps: []step{
+				{"failure", true},
+				{"wait", false},
+				{"allow", true},
+				{"allow", false},
+				{"allow", false},
+			},
+		},
+		{
+			name:      "successful probe closes",
+			threshold: 1,
+			steps: []step{
+				{"failure", true},
+				{"wait", false},
+				{"allow", true},
+				{"success", false},
+				{"allow", true},
+				{"allow", true},
+			},
+		},
+		{
+			name:      "failed probe opens again",
+			threshold: 2,
+			steps: []step{
+				{"failure", false},
+				{"failure", true},
+				{"wait", false},
+				{"allow", true},
+				{"failure", true},
+				{"allow", false},
+				{"wait", false},
+				{"allow", true},
+			},
+		},
+		{
+			name:      "cancelled probe lets the next call probe",
+			threshold: 1,
+			steps: []step{
+				{"failure", true},
+				{"wait", false},
+				{"allow", true},
+				{"cancel", false},
+				{"allow", true},
+				{"allow", false},
+			},
+		},
+		{
+			name:      "cancel does not count as a failure",
+			threshold: 1,
+			steps: []step{
+				{"allow", true},
+				{"cancel", false},
+				{"allow", true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(tt.threshold, coolDown)
+
+			for i, s := range tt.steps {
+				switch s.op {
+				case "allow":
+					require.Equal(t, s.want, b.Allow(), "step %d", i)
+				case "failure":
+					require.Equal(t, s.want, b.Failure(), "step %d", i)
+				case "success":
+					b.Success()
+				case "cancel":
+					b.Cancel()
+				case "wait":
+					time.Sleep(coolDown)
+				default:
+					t.Fatalf("unknown step %q", s.op)
+				}
+			}
+		})
+	}
+}
+
+func TestBreaker_SingleProbe(t *testing.T) {
+	b := New(1, coolDown)
+	b.Failure()
+	time.Sleep(coolDown)
+
+	const callers = 50
+
+	allowed := make(chan bool, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			allowed <- b.Allow()
+		}()
+	}
+
+	probes := 0
+	for i := 0; i < callers; i++ {
+		if <-allowed {
+			probes++
+		}
+	}
+	require.Equal(t, 1, probes)
+}
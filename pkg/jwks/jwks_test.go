@@ -0,0 +1,172 @@
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func rsaJWK(key *rsa.PublicKey, kid string) jsonWebKey {
+	return jsonWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(key *ecdsa.PublicKey, kid string) jsonWebKey {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return jsonWebKey{
+		Kty: "EC",
+		Kid: kid,
+		Crv: key.Curve.Params().Name,
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+func writeKeySet(t *testing.T, keys ...jsonWebKey) string {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	err = os.WriteFile(path, data, 0o600)
+	require.NoError(t, err)
+
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	shortRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	encryption := rsaJWK(&rsaKey.PublicKey, "enc")
+	encryption.Use = "enc"
+
+	offCurve := ecJWK(&p256Key.PublicKey, "off")
+	offCurve.Y = offCurve.X
+
+	unknownCurve := ecJWK(&p256Key.PublicKey, "curve")
+	unknownCurve.Crv = "secp256k1"
+
+	shortCoordinate := ecJWK(&p256Key.PublicKey, "short")
+	shortCoordinate.X = base64.RawURLEncoding.EncodeToString([]byte{1})
+
+	smallExponent := rsaJWK(&rsaKey.PublicKey, "e")
+	smallExponent.E = base64.RawURLEncoding.EncodeToString([]byte{1})
+
+	type want struct {
+		ids []string
+		err bool
+	}
+	tests := []struct {
+		name string
+		keys []jsonWebKey
+		want want
+	}{
+		{
+			name: "positive, RSA and EC keys",
+			keys: []jsonWebKey{
+				rsaJWK(&rsaKey.PublicKey, "rsa"),
+				ecJWK(&p256Key.PublicKey, "p256"),
+				ecJWK(&p384Key.PublicKey, "p384"),
+			},
+			want: want{ids: []string{"rsa", "p256", "p384"}},
+		},
+		{
+			name: "positive, unsupported keys are skipped",
+			keys: []jsonWebKey{
+				{Kty: "oct", Kid: "secret"},
+				encryption,
+				rsaJWK(&rsaKey.PublicKey, "rsa"),
+			},
+			want: want{ids: []string{"rsa"}},
+		},
+		{
+			name: "positive, empty set",
+			want: want{ids: []string{}},
+		},
+		{
+			name: "negative, modulus shorter than 2048 bits",
+			keys: []jsonWebKey{rsaJWK(&shortRSAKey.PublicKey, "short")},
+			want: want{err: true},
+		},
+		{
+			name: "negative, exponent too small",
+			keys: []jsonWebKey{smallExponent},
+			want: want{err: true},
+		},
+		{
+			name: "negative, point not on the curve",
+			keys: []jsonWebKey{offCurve},
+			want: want{err: true},
+		},
+		{
+			name: "negative, unknown curve",
+			keys: []jsonWebKey{unknownCurve},
+			want: want{err: true},
+		},
+		{
+			name: "negative, coordinate of the wrong length",
+			keys: []jsonWebKey{shortCoordinate},
+			want: want{err: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := LoadFile(writeKeySet(t, tt.keys...))
+			if tt.want.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			ids := make([]string, 0, len(keys))
+			for _, key := range keys {
+				ids = append(ids, key.ID)
+			}
+			require.Equal(t, tt.want.ids, ids)
+		})
+	}
+
+	t.Run("positive, keys match the originals", func(t *testing.T) {
+		keys, err := LoadFile(writeKeySet(t, rsaJWK(&rsaKey.PublicKey, "rsa"), ecJWK(&p256Key.PublicKey, "p256")))
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+
+		require.True(t, rsaKey.PublicKey.Equal(keys[0].Public))
+		require.True(t, p256Key.PublicKey.Equal(keys[1].Public))
+	})
+
+	t.Run("negative, missing file", func(t *testing.T) {
+		_, err := LoadFile(filepath.Join(t.TempDir(), "missing.json"))
+		require.Error(t, err)
+	})
+
+	t.Run("negative, invalid json", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		err := os.WriteFile(path, []byte(`{"keys":`), 0o600)
+		require.NoError(t, err)
+
+		_, err = LoadFile(path)
+		require.Error(t, err)
+	})
+}
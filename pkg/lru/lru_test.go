@@ -0,0 +1,190 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Eviction(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		ops  func(c *Cache[string, int])
+		want map[string]int
+	}{
+		{
+			name: "keeps entries up to the size",
+			size: 2,
+			ops: func(c *Cache[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+			},
+			want: map[string]int{"a": 1, "b": 2},
+		},
+		{
+			name: "evicts the least recently set entry",
+			size: 2,
+			ops: func(c *Cache[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("c", 3)
+			},
+			want: map[string]int{"b": 2, "c": 3},
+		},
+		{
+			name: "get makes an entry recently used",
+			size: 2,
+			ops: func(c *Cache[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Get("a")
+				c.Set("c", 3)
+			},
+			want: map[string]int{"a": 1, "c": 3},
+		},
+		{
+			name: "overwriting does not evict",
+			size: 2,
+			ops: func(c *Cache[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("a", 10)
+			},
+			want: map[string]int{"a": 10, "b": 2},
+		},
+		{
+			name: "overwriting makes an entry recently used",
+			size: 2,
+			ops: func(c *Cache[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("a", 10)
+				c.Set("c", 3)
+			},
+			want: map[string]int{"a": 10, "c": 3},
+		},
+		{
+			name: "size below one holds one entry",
+			size: 0,
+			ops: func(c *Cache[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+			},
+			want: map[string]int{"b": 2},
+		},
+		{
+			name: "remove",
+			size: 2,
+			ops: func(c *Cache[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Remove("a")
+				c.Remove("missing")
+			},
+			want: map[string]int{"b": 2},
+		},
+		{
+			name: "remove func",
+			size: 3,
+			ops: func(c *Cache[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("c", 3)
+				c.RemoveFunc(func(key string, value int) bool {
+					return value%2 == 1
+				})
+			},
+			want: map[string]int{"b": 2},
+		},
+		{
+			name: "purge",
+			size: 2,
+			ops: func(c *Cache[string, int]) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Purge()
+				c.Set("c", 3)
+			},
+			want: map[string]int{"c": 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New[string, int](tt.size, time.Hour)
+			tt.ops(c)
+
+			require.Equal(t, len(tt.want), c.Len())
+			for key, want := range tt.want {
+				got, ok := c.Get(key)
+				require.True(t, ok, key)
+				require.Equal(t, want, got)
+			}
+		})
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	const ttl = 50 * time.Millisecond
+
+	tests := []struct {
+		name  string
+		set   func(c *Cache[string, int])
+		after time.Duration
+		want  bool
+	}{
+		{
+			name:  "fresh entry",
+			set:   func(c *Cache[string, int]) { c.Set("a", 1) },
+			after: 0,
+			want:  true,
+		},
+		{
+			name:  "expired entry",
+			set:   func(c *Cache[string, int]) { c.Set("a", 1) },
+			after: ttl,
+			want:  false,
+		},
+		{
+			name:  "shorter ttl",
+			set:   func(c *Cache[string, int]) { c.SetWithTTL("a", 1, ttl/5) },
+			after: ttl / 2,
+			want:  false,
+		},
+		{
+			name:  "longer ttl is capped",
+			set:   func(c *Cache[string, int]) { c.SetWithTTL("a", 1, time.Hour) },
+			after: ttl,
+			want:  false,
+		},
+		{
+			name:  "non-positive ttl is not cached",
+			set:   func(c *Cache[string, int]) { c.SetWithTTL("a", 1, 0) },
+			after: 0,
+			want:  false,
+		},
+		{
+			name: "non-positive ttl removes the entry",
+			set: func(c *Cache[string, int]) {
+				c.Set("a", 1)
+				c.SetWithTTL("a", 2, -time.Second)
+			},
+			after: 0,
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New[string, int](2, ttl)
+			tt.set(c)
+			time.Sleep(tt.after)
+
+			_, ok := c.Get("a")
+			require.Equal(t, tt.want, ok)
+			if !ok {
+				require.Zero(t, c.Len())
+			}
+		})
+	}
+}